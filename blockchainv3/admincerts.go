@@ -0,0 +1,326 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// Constants associated with the AdminCertUpdate.Status property.
+const (
+	AdminCertUpdate_Status_Failed         = "failed"
+	AdminCertUpdate_Status_RollbackFailed = "rollback_failed"
+	AdminCertUpdate_Status_RolledBack     = "rolled_back"
+	AdminCertUpdate_Status_Rotated        = "rotated"
+	AdminCertUpdate_Status_Unchanged      = "unchanged"
+)
+
+// AdminCertUpdate : The outcome of an admin certificate rotation for one component.
+type AdminCertUpdate struct {
+	ID string
+
+	// ComponentType_FabricPeer, ComponentType_FabricOrderer or ComponentType_Msp.
+	Type string
+
+	// One of AdminCertUpdate_Status_*. Components that do not have the old certificate are unchanged.
+	Status string
+
+	// Why the rotation or its rollback failed.
+	Error error
+}
+
+// AdminCertRotation : The outcome of RotateAdminCert.
+type AdminCertRotation struct {
+	MspID string
+
+	// The peers and orderers, then the MSP definitions, in the order they were rotated.
+	Updates []*AdminCertUpdate
+}
+
+// RotateAdminCert replaces the admin certificate oldPEM by newPEM on every peer and orderer of mspID, and in the MSP
+// definitions of mspID. Certificates may be given as PEM or as base 64 encoded PEM.
+//
+// The certificates of a node are changed with EditAdminCerts, appending the new certificate before removing the old
+// one so that the node always has an admin, and each call is verified with the certificates it returns. MSP
+// definitions are changed with EditMsp. If a step fails, the components changed so far are changed back and the
+// rotation reports which of them could not be.
+func (blockchain *BlockchainV3) RotateAdminCert(ctx context.Context, mspID string, oldPEM string, newPEM string) (rotation *AdminCertRotation, err error) {
+	oldCert, err := parseAdminCert(oldPEM)
+	if err != nil {
+		return nil, fmt.Errorf("old certificate: %s", err.Error())
+	}
+	newCert, err := parseAdminCert(newPEM)
+	if err != nil {
+		return nil, fmt.Errorf("new certificate: %s", err.Error())
+	}
+	if oldCert.equal(newCert) {
+		return nil, fmt.Errorf("the old and the new certificate are the same")
+	}
+
+	options := blockchain.NewListComponentsOptions().SetDeploymentAttrs(ListComponentsOptions_DeploymentAttrs_Included)
+	components, _, err := blockchain.ListComponentsWithContext(ctx, options)
+	if err != nil {
+		return
+	}
+	rotation = &AdminCertRotation{MspID: mspID}
+	var nodes, msps []*GenericComponentResponse
+	for i := range components.Components {
+		component := &components.Components[i]
+		if component.ID == nil || component.Type == nil || component.MspID == nil || *component.MspID != mspID {
+			continue
+		}
+		switch *component.Type {
+		case ComponentType_FabricPeer, ComponentType_FabricOrderer:
+			nodes = append(nodes, component)
+		case ComponentType_Msp:
+			msps = append(msps, component)
+		}
+	}
+
+	var rollbacks []func() error
+	fail := func(update *AdminCertUpdate, cause error) error {
+		update.Status = AdminCertUpdate_Status_Failed
+		update.Error = cause
+		failures := rollBackAdminCerts(rotation.Updates[:len(rotation.Updates)-1], rollbacks)
+		if len(failures) > 0 {
+			return fmt.Errorf("rotation of the admin certificate failed on %s: %s; rolling back failed on %s", update.ID, cause.Error(), strings.Join(failures, ", "))
+		}
+		return fmt.Errorf("rotation of the admin certificate failed on %s: %s; all changes were rolled back", update.ID, cause.Error())
+	}
+
+	for _, node := range nodes {
+		update := &AdminCertUpdate{ID: *node.ID, Type: *node.Type, Status: AdminCertUpdate_Status_Unchanged}
+		rotation.Updates = append(rotation.Updates, update)
+		var current []string
+		if node.Msp != nil && node.Msp.Component != nil {
+			current = node.Msp.Component.AdminCerts
+		}
+		if !oldCert.in(current) {
+			continue
+		}
+		id := *node.ID
+		oldCert := oldCert.as(current)
+		// a node that already had the new certificate keeps it when rolling back
+		hadNew := newCert.in(current)
+		removeNew := func() error {
+			if hadNew {
+				return nil
+			}
+			return blockchain.editAdminCerts(ctx, id, nil, newCert, oldCert, newCert)
+		}
+		if !hadNew {
+			if err = blockchain.editAdminCerts(ctx, id, newCert, nil, newCert, nil); err != nil {
+				return rotation, fail(update, err)
+			}
+		}
+		if err = blockchain.editAdminCerts(ctx, id, nil, oldCert, newCert, oldCert); err != nil {
+			// the node has both certificates
+			if rollbackErr := removeNew(); rollbackErr != nil {
+				err = fmt.Errorf("%s; removing the new certificate again failed: %s", err.Error(), rollbackErr.Error())
+			}
+			return rotation, fail(update, err)
+		}
+		update.Status = AdminCertUpdate_Status_Rotated
+		rollbacks = append(rollbacks, func() error {
+			if err := blockchain.editAdminCerts(ctx, id, oldCert, nil, oldCert, nil); err != nil {
+				return err
+			}
+			return removeNew()
+		})
+	}
+
+	for _, msp := range msps {
+		update := &AdminCertUpdate{ID: *msp.ID, Type: ComponentType_Msp, Status: AdminCertUpdate_Status_Unchanged}
+		rotation.Updates = append(rotation.Updates, update)
+		var admins []string
+		admins, err = blockchain.getMspAdmins(ctx, *msp.ID)
+		if err != nil {
+			return rotation, fail(update, err)
+		}
+		if !oldCert.in(admins) {
+			continue
+		}
+		var rotated []string
+		for _, admin := range admins {
+			if oldCert.matches(admin) {
+				admin = newCert.encoded
+			}
+			if !containsString(rotated, admin) {
+				rotated = append(rotated, admin)
+			}
+		}
+		id := *msp.ID
+		if err = blockchain.editMspAdmins(ctx, id, rotated, newCert, oldCert); err != nil {
+			return rotation, fail(update, err)
+		}
+		update.Status = AdminCertUpdate_Status_Rotated
+		rollbacks = append(rollbacks, func() error {
+			return blockchain.editMspAdmins(ctx, id, admins, oldCert, newCert)
+		})
+	}
+	return rotation, nil
+}
+
+// rollBackAdminCerts rolls back the rotated updates, last first, and returns the ids that could not be rolled back.
+// rollbacks holds the rollback of each rotated update, in the same order.
+func rollBackAdminCerts(updates []*AdminCertUpdate, rollbacks []func() error) (failures []string) {
+	for i := len(updates) - 1; i >= 0; i-- {
+		update := updates[i]
+		if update.Status != AdminCertUpdate_Status_Rotated {
+			continue
+		}
+		rollback := rollbacks[len(rollbacks)-1]
+		rollbacks = rollbacks[:len(rollbacks)-1]
+		if err := rollback(); err != nil {
+			update.Status = AdminCertUpdate_Status_RollbackFailed
+			update.Error = err
+			failures = append(failures, update.ID)
+			continue
+		}
+		update.Status = AdminCertUpdate_Status_RolledBack
+	}
+	return
+}
+
+// editAdminCerts appends or removes a certificate of a node with EditAdminCerts, and checks that the certificates it
+// returns include present and exclude absent.
+func (blockchain *BlockchainV3) editAdminCerts(ctx context.Context, id string, appendCert *adminCert, removeCert *adminCert, present *adminCert, absent *adminCert) error {
+	options := blockchain.NewEditAdminCertsOptions(id)
+	if appendCert != nil {
+		options.SetAppendAdminCerts([]string{appendCert.encoded})
+	}
+	if removeCert != nil {
+		options.SetRemoveAdminCerts([]string{removeCert.encoded})
+	}
+	result, _, err := blockchain.EditAdminCertsWithContext(ctx, options)
+	if err != nil {
+		return err
+	}
+	var certs []string
+	if result != nil {
+		for _, item := range result.SetAdminCerts {
+			if item.Base64Pem != nil {
+				certs = append(certs, *item.Base64Pem)
+			}
+		}
+	}
+	return checkAdminCerts(id, certs, present, absent)
+}
+
+// editMspAdmins sets the admins of an MSP definition with EditMsp and checks the admins it returns.
+func (blockchain *BlockchainV3) editMspAdmins(ctx context.Context, id string, admins []string, present *adminCert, absent *adminCert) error {
+	result, _, err := blockchain.EditMspWithContext(ctx, blockchain.NewEditMspOptions(id).SetAdmins(admins))
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return fmt.Errorf("the console returned no MSP %s", id)
+	}
+	return checkAdminCerts(id, result.Admins, present, absent)
+}
+
+// getMspAdmins returns the admins of an MSP definition, which GenericComponentResponse does not have.
+func (blockchain *BlockchainV3) getMspAdmins(ctx context.Context, id string) (admins []string, err error) {
+	rawComponent, err := blockchain.getRawComponent(ctx, id, map[string]string{"cache": GetComponentOptions_Cache_Skip})
+	if err != nil {
+		return
+	}
+	if raw, ok := rawComponent["admins"]; ok {
+		if json.Unmarshal(raw, &admins) != nil {
+			err = fmt.Errorf("the admins of MSP %s are not a list of certificates", id)
+		}
+	}
+	return
+}
+
+func checkAdminCerts(id string, certs []string, present *adminCert, absent *adminCert) error {
+	if present != nil && !present.in(certs) {
+		return fmt.Errorf("the admin certificates of %s do not include %s", id, present.subject)
+	}
+	if absent != nil && absent.in(certs) {
+		return fmt.Errorf("the admin certificates of %s still include %s", id, absent.subject)
+	}
+	return nil
+}
+
+// adminCert is an admin certificate as the console expects it, and its DER bytes to compare it with others.
+type adminCert struct {
+	encoded string
+	der     []byte
+	subject string
+}
+
+// parseAdminCert accepts a PEM certificate or a base 64 encoded one.
+func parseAdminCert(cert string) (*adminCert, error) {
+	cert = strings.TrimSpace(cert)
+	encoded := cert
+	pemBytes := []byte(cert + "\n")
+	if strings.HasPrefix(cert, "-----BEGIN") {
+		encoded = base64.StdEncoding.EncodeToString(pemBytes)
+	} else {
+		decoded, err := base64.StdEncoding.DecodeString(cert)
+		if err != nil {
+			return nil, fmt.Errorf("not a PEM or base 64 encoded PEM certificate")
+		}
+		pemBytes = decoded
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("not a PEM or base 64 encoded PEM certificate")
+	}
+	subject := "the certificate"
+	if parsed, err := x509.ParseCertificate(block.Bytes); err == nil {
+		subject = fmt.Sprintf("the certificate of %q", parsed.Subject.String())
+	}
+	return &adminCert{encoded: encoded, der: block.Bytes, subject: subject}, nil
+}
+
+// matches reports whether cert, as returned by the console, is this certificate.
+func (cert *adminCert) matches(other string) bool {
+	parsed, err := parseAdminCert(other)
+	return err == nil && cert.equal(parsed)
+}
+
+func (cert *adminCert) equal(other *adminCert) bool {
+	return bytes.Equal(cert.der, other.der)
+}
+
+// as returns the certificate encoded as in certs, so that the console finds it when removing it.
+func (cert *adminCert) as(certs []string) *adminCert {
+	for _, other := range certs {
+		if cert.matches(other) {
+			return &adminCert{encoded: other, der: cert.der, subject: cert.subject}
+		}
+	}
+	return cert
+}
+
+func (cert *adminCert) in(certs []string) bool {
+	for _, other := range certs {
+		if cert.matches(other) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,227 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+)
+
+// testCertificate returns a self-signed PEM certificate.
+func testCertificate(commonName string) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).To(BeNil())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).To(BeNil())
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+var _ = Describe(`BlockchainV3 admin certificate rotation`, func() {
+	var testServer *httptest.Server
+	var blockchainService *blockchainv3.BlockchainV3
+	var certs map[string][]string
+	var edits []string
+	// the edits that fail, with the occurrence of the edit that fails
+	var failOn map[string]int
+	var seen map[string]int
+	var oldPEM, newPEM, otherPEM string
+	encode := func(cert string) string { return base64.StdEncoding.EncodeToString([]byte(cert)) }
+
+	BeforeEach(func() {
+		oldPEM, newPEM, otherPEM = testCertificate("old admin"), testCertificate("new admin"), testCertificate("other admin")
+		old, other := encode(oldPEM), encode(otherPEM)
+		certs = map[string][]string{
+			"peer1": {old, other}, "os1": {old}, "peer2": {old}, "peer3": {other}, "msp1": {other, old},
+		}
+		msps := map[string]string{"peer1": "org1", "os1": "org1", "peer2": "org2", "peer3": "org1", "msp1": "org1"}
+		types := map[string]string{"peer1": "fabric-peer", "os1": "fabric-orderer", "peer2": "fabric-peer", "peer3": "fabric-peer", "msp1": "msp"}
+		edits = nil
+		failOn = map[string]int{}
+		seen = map[string]int{}
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			res.Header().Set("Content-type", "application/json")
+			op, ok := blockchainv3.LookupOperation(req.Method, req.URL.Path)
+			Expect(ok).To(BeTrue())
+			id := strings.TrimSuffix(req.URL.Path, "/certs")
+			id = id[strings.LastIndex(id, "/")+1:]
+			switch op.Name {
+			case "ListComponents":
+				Expect(req.URL.Query().Get("deployment_attrs")).To(Equal("included"))
+				var list []interface{}
+				for _, id := range []string{"peer1", "os1", "peer2", "peer3", "msp1"} {
+					component := map[string]interface{}{"id": id, "type": types[id], "msp_id": msps[id]}
+					if types[id] != "msp" {
+						component["msp"] = map[string]interface{}{"component": map[string]interface{}{"admin_certs": certs[id]}}
+					}
+					list = append(list, component)
+				}
+				json.NewEncoder(res).Encode(map[string]interface{}{"components": list})
+				return
+			case "GetComponent":
+				json.NewEncoder(res).Encode(map[string]interface{}{"id": id, "type": types[id], "msp_id": msps[id], "admins": certs[id]})
+				return
+			}
+			var body map[string][]string
+			Expect(json.NewDecoder(req.Body).Decode(&body)).To(BeNil())
+			action := ""
+			if len(body["append_admin_certs"]) > 0 {
+				action = " append"
+			}
+			if len(body["remove_admin_certs"]) > 0 {
+				action += " remove"
+			}
+			edit := op.Name + " " + id + action
+			edits = append(edits, edit)
+			seen[edit]++
+			if failOn[edit] == seen[edit] {
+				res.WriteHeader(500)
+				fmt.Fprint(res, `{"message": "boom"}`)
+				return
+			}
+			switch op.Name {
+			case "EditAdminCerts":
+				current := certs[id]
+				var updated []string
+				for _, cert := range current {
+					if !containsCert(body["remove_admin_certs"], cert) {
+						updated = append(updated, cert)
+					}
+				}
+				certs[id] = append(updated, body["append_admin_certs"]...)
+				var set []interface{}
+				for _, cert := range certs[id] {
+					set = append(set, map[string]string{"base_64_pem": cert})
+				}
+				json.NewEncoder(res).Encode(map[string]interface{}{"changes_made": 1, "set_admin_certs": set})
+			case "EditMsp":
+				certs[id] = body["admins"]
+				json.NewEncoder(res).Encode(map[string]interface{}{"id": id, "admins": certs[id]})
+			}
+		}))
+		var serviceErr error
+		blockchainService, serviceErr = blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(serviceErr).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	statuses := func(rotation *blockchainv3.AdminCertRotation) map[string]string {
+		result := map[string]string{}
+		for _, update := range rotation.Updates {
+			result[update.ID] = update.Status
+		}
+		return result
+	}
+
+	It(`Rotates the certificate on every node and MSP of the organization`, func() {
+		rotation, err := blockchainService.RotateAdminCert(context.Background(), "org1", oldPEM, newPEM)
+		Expect(err).To(BeNil())
+		Expect(edits).To(Equal([]string{
+			"EditAdminCerts peer1 append", "EditAdminCerts peer1 remove",
+			"EditAdminCerts os1 append", "EditAdminCerts os1 remove",
+			"EditMsp msp1",
+		}))
+		Expect(statuses(rotation)).To(Equal(map[string]string{"peer1": "rotated", "os1": "rotated", "peer3": "unchanged", "msp1": "rotated"}))
+		Expect(certs["peer1"]).To(Equal([]string{encode(otherPEM), encode(newPEM)}))
+		Expect(certs["msp1"]).To(Equal([]string{encode(otherPEM), encode(newPEM)}))
+		Expect(certs["peer2"]).To(Equal([]string{encode(oldPEM)}))
+
+		// running it again changes nothing
+		edits = nil
+		_, err = blockchainService.RotateAdminCert(context.Background(), "org1", encode(oldPEM), encode(newPEM))
+		Expect(err).To(BeNil())
+		Expect(edits).To(BeEmpty())
+	})
+	It(`Rolls back the components already changed`, func() {
+		failOn["EditMsp msp1"] = 1
+		rotation, err := blockchainService.RotateAdminCert(context.Background(), "org1", oldPEM, newPEM)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(HavePrefix("rotation of the admin certificate failed on msp1: "))
+		Expect(err.Error()).To(HaveSuffix("; all changes were rolled back"))
+		Expect(statuses(rotation)).To(Equal(map[string]string{"peer1": "rolled_back", "os1": "rolled_back", "peer3": "unchanged", "msp1": "failed"}))
+		Expect(edits[5:]).To(Equal([]string{
+			"EditAdminCerts os1 append", "EditAdminCerts os1 remove",
+			"EditAdminCerts peer1 append", "EditAdminCerts peer1 remove",
+		}))
+		Expect(certs["peer1"]).To(Equal([]string{encode(otherPEM), encode(oldPEM)}))
+		Expect(certs["os1"]).To(Equal([]string{encode(oldPEM)}))
+	})
+	It(`Removes the new certificate of a node that could not drop the old one`, func() {
+		failOn["EditAdminCerts os1 remove"] = 1
+		rotation, err := blockchainService.RotateAdminCert(context.Background(), "org1", oldPEM, newPEM)
+		Expect(err).ToNot(BeNil())
+		Expect(statuses(rotation)).To(Equal(map[string]string{"peer1": "rolled_back", "os1": "failed"}))
+		Expect(certs["os1"]).To(Equal([]string{encode(oldPEM)}))
+		Expect(certs["peer1"]).To(Equal([]string{encode(otherPEM), encode(oldPEM)}))
+	})
+	It(`Reports components that could not be rolled back`, func() {
+		failOn["EditMsp msp1"] = 1
+		// the rollback of peer1 appends the old certificate again
+		failOn["EditAdminCerts peer1 append"] = 2
+		rotation, err := blockchainService.RotateAdminCert(context.Background(), "org1", oldPEM, newPEM)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(HaveSuffix("; rolling back failed on peer1"))
+		Expect(statuses(rotation)).To(Equal(map[string]string{"peer1": "rollback_failed", "os1": "rolled_back", "peer3": "unchanged", "msp1": "failed"}))
+		Expect(rotation.Updates[0].Error).ToNot(BeNil())
+		Expect(certs["peer1"]).To(Equal([]string{encode(otherPEM), encode(newPEM)}))
+	})
+	It(`Rejects invalid certificates`, func() {
+		_, err := blockchainService.RotateAdminCert(context.Background(), "org1", "nope", newPEM)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(HavePrefix("old certificate: "))
+		_, err = blockchainService.RotateAdminCert(context.Background(), "org1", oldPEM, encode(oldPEM))
+		Expect(err).ToNot(BeNil())
+		Expect(edits).To(BeEmpty())
+	})
+})
+
+func containsCert(certs []string, cert string) bool {
+	for _, c := range certs {
+		if c == cert {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,373 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"bytes"
+	"container/list"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Operations that can be served from the client-side response cache. The values are Operation names.
+const (
+	CacheOperationListComponents      = "ListComponents"
+	CacheOperationGetComponentsByType = "GetComponentsByType"
+	CacheOperationGetMspCertificate   = "GetMspCertificate"
+)
+
+// Defaults used by NewCacheOptions().
+const (
+	DefaultCacheTTL        = 10 * time.Second
+	DefaultCacheMaxEntries = 64
+)
+
+// CachePolicy : How long responses of one operation stay fresh and how many distinct responses are kept.
+type CachePolicy struct {
+	// Time a cached response is served before it is fetched again. Zero disables caching of the operation.
+	TTL time.Duration
+
+	// Maximum number of responses kept for the operation (one per distinct query). The least recently used response is
+	// evicted first. Zero means DefaultCacheMaxEntries.
+	MaxEntries int
+}
+
+// CacheOptions : The EnableCache options.
+type CacheOptions struct {
+	// Per-operation policies, keyed by the CacheOperation* constants. Operations without a policy are not cached.
+	Policies map[string]CachePolicy
+}
+
+// NewCacheOptions : Instantiate CacheOptions with the default policy for every cacheable operation
+func (*BlockchainV3) NewCacheOptions() *CacheOptions {
+	return &CacheOptions{
+		Policies: map[string]CachePolicy{
+			CacheOperationListComponents:      {TTL: DefaultCacheTTL, MaxEntries: DefaultCacheMaxEntries},
+			CacheOperationGetComponentsByType: {TTL: DefaultCacheTTL, MaxEntries: DefaultCacheMaxEntries},
+			CacheOperationGetMspCertificate:   {TTL: DefaultCacheTTL, MaxEntries: DefaultCacheMaxEntries},
+		},
+	}
+}
+
+// SetPolicy : Allow user to set the policy of one operation
+func (options *CacheOptions) SetPolicy(operation string, ttl time.Duration, maxEntries int) *CacheOptions {
+	if options.Policies == nil {
+		options.Policies = map[string]CachePolicy{}
+	}
+	options.Policies[operation] = CachePolicy{TTL: ttl, MaxEntries: maxEntries}
+	return options
+}
+
+// CacheOperationStats : Cache counters of a single operation.
+type CacheOperationStats struct {
+	// Requests answered from the cache.
+	Hits int64
+
+	// Requests sent to the console because no fresh response was cached.
+	Misses int64
+
+	// Requests sent to the console because they were made with `cache=skip`.
+	Bypassed int64
+
+	// Responses dropped to honor CachePolicy.MaxEntries.
+	Evictions int64
+
+	// Responses currently held.
+	Entries int
+}
+
+// CacheStats : Cache counters of a client.
+type CacheStats struct {
+	CacheOperationStats
+
+	// Number of times the whole cache was emptied, by a mutating request or by InvalidateCache().
+	Invalidations int64
+
+	// Counters per operation, keyed by the CacheOperation* constants.
+	Operations map[string]CacheOperationStats
+}
+
+// EnableCache turns on the client-side read-through cache for ListComponents, GetComponentsByType and
+// GetMspCertificate. Any non-GET request sent by this client (EditPeer, DeleteComponent, UpdateOrderer, ...) empties
+// the cache. Requests made with the `Cache` option set to `skip` always reach the console and refresh the cached
+// response. Calling EnableCache again replaces the policies and empties the cache.
+func (blockchain *BlockchainV3) EnableCache(options *CacheOptions) {
+	if options == nil {
+		options = blockchain.NewCacheOptions()
+	}
+	policies := map[string]CachePolicy{}
+	for operation, policy := range options.Policies {
+		if policy.MaxEntries <= 0 {
+			policy.MaxEntries = DefaultCacheMaxEntries
+		}
+		policies[operation] = policy
+	}
+
+	if cache := blockchain.responseCache(); cache != nil {
+		cache.setPolicies(policies)
+		return
+	}
+	cache := &responseCache{responseCacheState: &responseCacheState{}}
+	cache.setPolicies(policies)
+	blockchain.addTransportLayer(cache)
+}
+
+// DisableCache turns off the client-side cache and discards its content.
+func (blockchain *BlockchainV3) DisableCache() {
+	blockchain.removeTransportLayer(isResponseCache)
+}
+
+// InvalidateCache empties the client-side cache, if enabled.
+func (blockchain *BlockchainV3) InvalidateCache() {
+	if cache := blockchain.responseCache(); cache != nil {
+		cache.invalidate()
+	}
+}
+
+// GetCacheStats returns the hit/miss counters of the client-side cache. The zero value is returned when the cache is
+// not enabled.
+func (blockchain *BlockchainV3) GetCacheStats() (stats CacheStats) {
+	if cache := blockchain.responseCache(); cache != nil {
+		stats = cache.stats()
+	}
+	return
+}
+
+func (blockchain *BlockchainV3) responseCache() *responseCache {
+	layer := blockchain.findTransportLayer(isResponseCache)
+	if layer == nil {
+		return nil
+	}
+	return layer.(*responseCache)
+}
+
+func isResponseCache(layer transportLayer) bool {
+	_, ok := layer.(*responseCache)
+	return ok
+}
+
+// cachedResponse is a successful response held by the cache.
+type cachedResponse struct {
+	key        string
+	statusCode int
+	header     http.Header
+	body       []byte
+	expires    time.Time
+}
+
+// operationCache holds the responses of one operation in least recently used order.
+type operationCache struct {
+	policy  CachePolicy
+	entries map[string]*list.Element
+	lru     *list.List
+	stats   CacheOperationStats
+}
+
+// responseCache is the transport layer implementing the client-side cache.
+type responseCache struct {
+	transport http.RoundTripper
+
+	*responseCacheState
+}
+
+// responseCacheState is the content of a responseCache, shared by its clones.
+type responseCacheState struct {
+	mutex         sync.Mutex
+	operations    map[string]*operationCache
+	generation    uint64
+	invalidations int64
+}
+
+func (cache *responseCache) rank() int {
+	return transportRankCache
+}
+
+func (cache *responseCache) next() http.RoundTripper {
+	return cache.transport
+}
+
+func (cache *responseCache) setNext(next http.RoundTripper) {
+	cache.transport = next
+}
+
+func (cache *responseCache) clone() transportLayer {
+	copied := *cache
+	return &copied
+}
+
+func (cache *responseCache) setPolicies(policies map[string]CachePolicy) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.operations = map[string]*operationCache{}
+	for operation, policy := range policies {
+		if policy.TTL <= 0 {
+			continue
+		}
+		cache.operations[operation] = &operationCache{
+			policy:  policy,
+			entries: map[string]*list.Element{},
+			lru:     list.New(),
+		}
+	}
+	cache.generation++
+}
+
+func (cache *responseCache) invalidate() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	for _, op := range cache.operations {
+		op.entries = map[string]*list.Element{}
+		op.lru.Init()
+	}
+	cache.generation++
+	cache.invalidations++
+}
+
+func (cache *responseCache) stats() (stats CacheStats) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	stats.Invalidations = cache.invalidations
+	stats.Operations = map[string]CacheOperationStats{}
+	for operation, op := range cache.operations {
+		opStats := op.stats
+		opStats.Entries = op.lru.Len()
+		stats.Operations[operation] = opStats
+		stats.Hits += opStats.Hits
+		stats.Misses += opStats.Misses
+		stats.Bypassed += opStats.Bypassed
+		stats.Evictions += opStats.Evictions
+		stats.Entries += opStats.Entries
+	}
+	return
+}
+
+// RoundTrip serves cacheable GET requests from the cache and empties the cache after any other request.
+func (cache *responseCache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		// The outcome of a failed mutation is unknown, so the cache is emptied either way.
+		defer cache.invalidate()
+		return cache.transport.RoundTrip(req)
+	}
+
+	operation, ok := LookupOperation(req.Method, req.URL.Path)
+	if !ok {
+		return cache.transport.RoundTrip(req)
+	}
+	key := cacheKeyOf(req)
+	skip := req.URL.Query().Get("cache") == ListComponentsOptions_Cache_Skip
+
+	cache.mutex.Lock()
+	op := cache.operations[operation.Name]
+	if op == nil {
+		cache.mutex.Unlock()
+		return cache.transport.RoundTrip(req)
+	}
+	if skip {
+		op.stats.Bypassed++
+	} else if entry := op.lookup(key, time.Now()); entry != nil {
+		op.stats.Hits++
+		cache.mutex.Unlock()
+		return entry.toResponse(req), nil
+	} else {
+		op.stats.Misses++
+	}
+	generation := cache.generation
+	cache.mutex.Unlock()
+
+	resp, err := cache.transport.RoundTrip(req)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	// Do not store a response that may predate a mutation sent while it was in flight.
+	if generation == cache.generation {
+		op.store(&cachedResponse{
+			key:        key,
+			statusCode: resp.StatusCode,
+			header:     resp.Header.Clone(),
+			body:       body,
+			expires:    time.Now().Add(op.policy.TTL),
+		})
+	}
+	return resp, nil
+}
+
+// lookup returns the fresh response stored under key, or nil. Expired responses are dropped.
+func (op *operationCache) lookup(key string, now time.Time) *cachedResponse {
+	element, ok := op.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := element.Value.(*cachedResponse)
+	if now.After(entry.expires) {
+		op.lru.Remove(element)
+		delete(op.entries, key)
+		return nil
+	}
+	op.lru.MoveToFront(element)
+	return entry
+}
+
+// store adds or replaces a response and evicts the least recently used ones beyond the policy limit.
+func (op *operationCache) store(entry *cachedResponse) {
+	if element, ok := op.entries[entry.key]; ok {
+		element.Value = entry
+		op.lru.MoveToFront(element)
+	} else {
+		op.entries[entry.key] = op.lru.PushFront(entry)
+	}
+	for op.lru.Len() > op.policy.MaxEntries {
+		oldest := op.lru.Back()
+		op.lru.Remove(oldest)
+		delete(op.entries, oldest.Value.(*cachedResponse).key)
+		op.stats.Evictions++
+	}
+}
+
+func (entry *cachedResponse) toResponse(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        http.StatusText(entry.statusCode),
+		StatusCode:    entry.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(entry.body)),
+		ContentLength: int64(len(entry.body)),
+		Request:       req,
+	}
+}
+
+// cacheKeyOf identifies a response by its URL, ignoring the `cache` query parameter so that a `cache=skip` request
+// refreshes the response used by later requests.
+func cacheKeyOf(req *http.Request) string {
+	u := *req.URL
+	query := u.Query()
+	query.Del("cache")
+	u.RawQuery = query.Encode()
+	return u.String()
+}
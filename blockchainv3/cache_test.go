@@ -0,0 +1,173 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe(`BlockchainV3 response cache`, func() {
+	var testServer *httptest.Server
+	var requestCount map[string]int
+	var blockchainService *blockchainv3.BlockchainV3
+
+	BeforeEach(func() {
+		requestCount = map[string]int{}
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			requestCount[req.Method+" "+req.URL.Path]++
+			res.Header().Set("Content-type", "application/json")
+			res.WriteHeader(200)
+			if req.Method == "GET" {
+				fmt.Fprintf(res, `{"components": [{"id": "mypeer", "display_name": "call %d"}]}`, requestCount[req.Method+" "+req.URL.Path])
+			} else {
+				fmt.Fprintf(res, `{"id": "mypeer", "type": "fabric-peer"}`)
+			}
+		}))
+		var serviceErr error
+		blockchainService, serviceErr = blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(serviceErr).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Does not cache unless enabled`, func() {
+		for i := 0; i < 2; i++ {
+			_, _, err := blockchainService.ListComponents(blockchainService.NewListComponentsOptions())
+			Expect(err).To(BeNil())
+		}
+		Expect(requestCount["GET /ak/api/v3/components"]).To(Equal(2))
+		Expect(blockchainService.GetCacheStats().Hits).To(BeZero())
+	})
+	It(`Serves repeated reads from the cache`, func() {
+		blockchainService.EnableCache(nil)
+		first, _, err := blockchainService.ListComponents(blockchainService.NewListComponentsOptions())
+		Expect(err).To(BeNil())
+		second, response, err := blockchainService.ListComponents(blockchainService.NewListComponentsOptions())
+		Expect(err).To(BeNil())
+		Expect(response.StatusCode).To(Equal(200))
+		Expect(*second.Components[0].DisplayName).To(Equal(*first.Components[0].DisplayName))
+		Expect(requestCount["GET /ak/api/v3/components"]).To(Equal(1))
+
+		stats := blockchainService.GetCacheStats()
+		Expect(stats.Hits).To(Equal(int64(1)))
+		Expect(stats.Misses).To(Equal(int64(1)))
+		Expect(stats.Operations[blockchainv3.CacheOperationListComponents].Entries).To(Equal(1))
+	})
+	It(`Keeps a separate response per query`, func() {
+		blockchainService.EnableCache(nil)
+		for _, componentType := range []string{"fabric-peer", "fabric-orderer", "fabric-peer"} {
+			_, _, err := blockchainService.GetComponentsByType(blockchainService.NewGetComponentsByTypeOptions(componentType))
+			Expect(err).To(BeNil())
+		}
+		Expect(requestCount["GET /ak/api/v3/components/types/fabric-peer"]).To(Equal(1))
+		Expect(requestCount["GET /ak/api/v3/components/types/fabric-orderer"]).To(Equal(1))
+		Expect(blockchainService.GetCacheStats().Operations[blockchainv3.CacheOperationGetComponentsByType].Entries).To(Equal(2))
+	})
+	It(`Bypasses the cache with cache=skip`, func() {
+		blockchainService.EnableCache(nil)
+		opts := blockchainService.NewListComponentsOptions()
+		_, _, err := blockchainService.ListComponents(opts)
+		Expect(err).To(BeNil())
+		opts.SetCache(blockchainv3.ListComponentsOptions_Cache_Skip)
+		result, _, err := blockchainService.ListComponents(opts)
+		Expect(err).To(BeNil())
+		Expect(*result.Components[0].DisplayName).To(Equal("call 2"))
+
+		// the skipped request refreshed the cached response
+		result, _, err = blockchainService.ListComponents(blockchainService.NewListComponentsOptions())
+		Expect(err).To(BeNil())
+		Expect(*result.Components[0].DisplayName).To(Equal("call 2"))
+		Expect(blockchainService.GetCacheStats().Bypassed).To(Equal(int64(1)))
+	})
+	It(`Invalidates the cache on mutating calls`, func() {
+		blockchainService.EnableCache(nil)
+		_, _, err := blockchainService.ListComponents(blockchainService.NewListComponentsOptions())
+		Expect(err).To(BeNil())
+		_, _, err = blockchainService.EditPeer(blockchainService.NewEditPeerOptions("mypeer"))
+		Expect(err).To(BeNil())
+		_, _, err = blockchainService.ListComponents(blockchainService.NewListComponentsOptions())
+		Expect(err).To(BeNil())
+		Expect(requestCount["GET /ak/api/v3/components"]).To(Equal(2))
+		Expect(blockchainService.GetCacheStats().Invalidations).To(Equal(int64(1)))
+	})
+	It(`Expires responses after the TTL and evicts beyond the size limit`, func() {
+		options := blockchainService.NewCacheOptions()
+		options.SetPolicy(blockchainv3.CacheOperationGetMspCertificate, 50*time.Millisecond, 1)
+		blockchainService.EnableCache(options)
+
+		_, _, err := blockchainService.GetMspCertificate(blockchainService.NewGetMspCertificateOptions("org1"))
+		Expect(err).To(BeNil())
+		_, _, err = blockchainService.GetMspCertificate(blockchainService.NewGetMspCertificateOptions("org2"))
+		Expect(err).To(BeNil())
+		Expect(blockchainService.GetCacheStats().Evictions).To(Equal(int64(1)))
+
+		_, _, err = blockchainService.GetMspCertificate(blockchainService.NewGetMspCertificateOptions("org2"))
+		Expect(err).To(BeNil())
+		Expect(requestCount["GET /ak/api/v3/components/msps/org2"]).To(Equal(1))
+
+		time.Sleep(60 * time.Millisecond)
+		_, _, err = blockchainService.GetMspCertificate(blockchainService.NewGetMspCertificateOptions("org2"))
+		Expect(err).To(BeNil())
+		Expect(requestCount["GET /ak/api/v3/components/msps/org2"]).To(Equal(2))
+	})
+	It(`Stops caching once disabled`, func() {
+		blockchainService.EnableCache(nil)
+		blockchainService.DisableCache()
+		for i := 0; i < 2; i++ {
+			_, _, err := blockchainService.ListComponents(blockchainService.NewListComponentsOptions())
+			Expect(err).To(BeNil())
+		}
+		Expect(requestCount["GET /ak/api/v3/components"]).To(Equal(2))
+	})
+	It(`Leaves the transport of earlier clones unchanged`, func() {
+		listTwice := func(service *blockchainv3.BlockchainV3) {
+			for i := 0; i < 2; i++ {
+				_, _, err := service.ListComponents(service.NewListComponentsOptions())
+				Expect(err).To(BeNil())
+			}
+		}
+		// installs the dry-run layer, which sits in front of the cache
+		blockchainService.DryRunContext(context.Background())
+		before := blockchainService.Clone()
+		blockchainService.EnableCache(nil)
+		after := blockchainService.Clone()
+
+		listTwice(before)
+		Expect(requestCount["GET /ak/api/v3/components"]).To(Equal(2))
+		listTwice(blockchainService)
+		Expect(requestCount["GET /ak/api/v3/components"]).To(Equal(3))
+
+		blockchainService.DisableCache()
+		listTwice(after)
+		Expect(requestCount["GET /ak/api/v3/components"]).To(Equal(3))
+		listTwice(blockchainService)
+		Expect(requestCount["GET /ak/api/v3/components"]).To(Equal(5))
+	})
+})
@@ -0,0 +1,405 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/IBM/go-sdk-core/v4/core"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Constants associated with the CassetteOptions.Mode property.
+const (
+	// Send requests to the console and capture every interaction.
+	CassetteOptions_Mode_Record = "record"
+
+	// Answer requests from the captured interactions without contacting the console.
+	CassetteOptions_Mode_Replay = "replay"
+)
+
+// CassetteGeneratedCryptoFields are the JSON fields of request bodies that hold crypto material generated for a run,
+// such as the certificates of identities enrolled with a Fabric CA and certificate signing requests. Pass them to
+// SetIgnoreBodyFields to replay a run that enrolls new identities.
+var CassetteGeneratedCryptoFields = []string{
+	"admin_certs",
+	"admins",
+	"cacert",
+	"cert",
+	"csr",
+	"ecert",
+	"intermediate_certs",
+	"root_certs",
+	"signcerts",
+	"tls_cert",
+	"tls_intermediate_certs",
+	"tls_root_certs",
+}
+
+// Cassette : HTTP interactions captured from a console, in the order they happened.
+type Cassette struct {
+	Interactions []CassetteInteraction `json:"interactions"`
+}
+
+// CassetteInteraction : One captured request and the response the console gave to it.
+type CassetteInteraction struct {
+	Request CassetteRequest `json:"request"`
+
+	Response CassetteResponse `json:"response"`
+}
+
+// CassetteRequest : A captured request. Credential headers and secrets in the body are redacted.
+type CassetteRequest struct {
+	// Operation name, e.g. `ListComponents`. Informational only.
+	Operation string `json:"operation,omitempty"`
+
+	Method string `json:"method"`
+
+	// The URL path, including the path of the service URL.
+	Path string `json:"path"`
+
+	// The encoded query string, sorted by key.
+	Query string `json:"query,omitempty"`
+
+	Headers http.Header `json:"headers,omitempty"`
+
+	// The request body, decompressed.
+	Body string `json:"body,omitempty"`
+}
+
+// CassetteResponse : A captured response. Credential headers and secrets in the body are redacted.
+type CassetteResponse struct {
+	StatusCode int `json:"status_code"`
+
+	Headers http.Header `json:"headers,omitempty"`
+
+	Body string `json:"body,omitempty"`
+}
+
+// LoadCassette reads a cassette file.
+func LoadCassette(path string) (cassette *Cassette, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	cassette = new(Cassette)
+	err = json.Unmarshal(data, cassette)
+	if err != nil {
+		err = fmt.Errorf("cassette %s: %s", path, err.Error())
+		cassette = nil
+	}
+	return
+}
+
+// Save writes the cassette to path, creating missing directories.
+func (cassette *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// CassetteOptions : The UseCassette options.
+type CassetteOptions struct {
+	// The cassette file.
+	Path *string `validate:"required,ne="`
+
+	// `record` or `replay`.
+	Mode *string `validate:"required,oneof=record replay"`
+
+	// In replay mode, answer a request with the last matching interaction once all matching interactions were used,
+	// instead of failing. Useful for polling loops whose number of iterations varies.
+	AllowRepeats *bool
+
+	// In replay mode, JSON fields of request bodies, at any depth, whose values are not compared. See
+	// CassetteGeneratedCryptoFields.
+	IgnoreBodyFields []string
+}
+
+// NewCassetteOptions : Instantiate CassetteOptions
+func (*BlockchainV3) NewCassetteOptions(path string, mode string) *CassetteOptions {
+	return &CassetteOptions{
+		Path: &path,
+		Mode: &mode,
+	}
+}
+
+// SetAllowRepeats : Allow user to set AllowRepeats
+func (options *CassetteOptions) SetAllowRepeats(allowRepeats bool) *CassetteOptions {
+	options.AllowRepeats = &allowRepeats
+	return options
+}
+
+// SetIgnoreBodyFields : Allow user to set IgnoreBodyFields
+func (options *CassetteOptions) SetIgnoreBodyFields(ignoreBodyFields []string) *CassetteOptions {
+	options.IgnoreBodyFields = ignoreBodyFields
+	return options
+}
+
+// UseCassette routes the requests of this client through a cassette. In record mode requests reach the console and
+// every interaction is captured, with `Authorization` headers and crypto secrets (enroll secrets, private keys,
+// passwords, pins) redacted; the file is written by EjectCassette(). In replay mode the file is loaded and requests are
+// answered from it, matched on method, path, query and body (except the IgnoreBodyFields), and requests are not
+// authenticated. A client holds at most one cassette.
+//
+// Only the requests of this client are captured. Requests sent by other clients, such as enrollments with a Fabric CA
+// client, go to the network in both modes.
+func (blockchain *BlockchainV3) UseCassette(options *CassetteOptions) (err error) {
+	err = core.ValidateNotNil(options, "cassetteOptions cannot be nil")
+	if err != nil {
+		return
+	}
+	err = core.ValidateStruct(options, "cassetteOptions")
+	if err != nil {
+		return
+	}
+	if blockchain.findTransportLayer(isCassetteTransport) != nil {
+		return fmt.Errorf("a cassette is already in use, eject it first")
+	}
+
+	layer := &cassetteTransport{
+		path:         *options.Path,
+		mode:         *options.Mode,
+		cassetteTape: &cassetteTape{cassette: &Cassette{}},
+	}
+	if options.AllowRepeats != nil {
+		layer.allowRepeats = *options.AllowRepeats
+	}
+	if len(options.IgnoreBodyFields) > 0 {
+		layer.ignoreFields = map[string]bool{}
+		for _, field := range options.IgnoreBodyFields {
+			layer.ignoreFields[strings.ToLower(field)] = true
+		}
+	}
+	if layer.mode == CassetteOptions_Mode_Replay {
+		layer.cassette, err = LoadCassette(layer.path)
+		if err != nil {
+			return
+		}
+		layer.used = make([]bool, len(layer.cassette.Interactions))
+		layer.authenticator = &skippingAuthenticator{
+			Authenticator: blockchain.Service.Options.Authenticator,
+			skip:          func(*http.Request) bool { return true },
+		}
+		blockchain.Service.Options.Authenticator = layer.authenticator
+	}
+	blockchain.addTransportLayer(layer)
+	return
+}
+
+// EjectCassette stops routing requests through the cassette. In record mode the captured interactions are written to
+// the cassette file.
+func (blockchain *BlockchainV3) EjectCassette() (err error) {
+	found := blockchain.findTransportLayer(isCassetteTransport)
+	if found == nil {
+		return fmt.Errorf("no cassette in use")
+	}
+	layer := found.(*cassetteTransport)
+	blockchain.removeTransportLayer(isCassetteTransport)
+	if layer.authenticator != nil && blockchain.Service.Options.Authenticator == layer.authenticator {
+		blockchain.Service.Options.Authenticator = layer.authenticator.Authenticator
+	}
+	if layer.mode == CassetteOptions_Mode_Record {
+		layer.mutex.Lock()
+		defer layer.mutex.Unlock()
+		err = layer.cassette.Save(layer.path)
+	}
+	return
+}
+
+func isCassetteTransport(layer transportLayer) bool {
+	_, ok := layer.(*cassetteTransport)
+	return ok
+}
+
+// cassetteTransport is the transport layer that records or replays interactions.
+type cassetteTransport struct {
+	transport     http.RoundTripper
+	path          string
+	mode          string
+	allowRepeats  bool
+	ignoreFields  map[string]bool
+	authenticator *skippingAuthenticator
+
+	*cassetteTape
+}
+
+// cassetteTape is the content of a cassetteTransport, shared by its clones.
+type cassetteTape struct {
+	mutex    sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+func (layer *cassetteTransport) rank() int {
+	return transportRankCassette
+}
+
+func (layer *cassetteTransport) next() http.RoundTripper {
+	return layer.transport
+}
+
+func (layer *cassetteTransport) setNext(next http.RoundTripper) {
+	layer.transport = next
+}
+
+func (layer *cassetteTransport) clone() transportLayer {
+	copied := *layer
+	return &copied
+}
+
+// RoundTrip records or replays req.
+func (layer *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	_, body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	recorded := CassetteRequest{
+		Method:  req.Method,
+		Path:    req.URL.Path,
+		Query:   req.URL.Query().Encode(),
+		Headers: redactHeaders(req.Header),
+		Body:    string(redactJSON(body)),
+	}
+	if op, ok := LookupOperation(req.Method, req.URL.Path); ok {
+		recorded.Operation = op.Name
+	}
+
+	if layer.mode == CassetteOptions_Mode_Replay {
+		return layer.replay(req, &recorded)
+	}
+	return layer.record(req, &recorded)
+}
+
+func (layer *cassetteTransport) record(req *http.Request, recorded *CassetteRequest) (*http.Response, error) {
+	resp, err := layer.transport.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	layer.mutex.Lock()
+	defer layer.mutex.Unlock()
+	layer.cassette.Interactions = append(layer.cassette.Interactions, CassetteInteraction{
+		Request: *recorded,
+		Response: CassetteResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    redactHeaders(resp.Header),
+			Body:       string(redactJSON(body)),
+		},
+	})
+	return resp, nil
+}
+
+func (layer *cassetteTransport) replay(req *http.Request, recorded *CassetteRequest) (*http.Response, error) {
+	layer.mutex.Lock()
+	defer layer.mutex.Unlock()
+
+	last := -1
+	for i, interaction := range layer.cassette.Interactions {
+		if !interaction.Request.matches(recorded, layer.ignoreFields) {
+			continue
+		}
+		if !layer.used[i] {
+			layer.used[i] = true
+			return interaction.Response.toResponse(req), nil
+		}
+		last = i
+	}
+	if last >= 0 && layer.allowRepeats {
+		return layer.cassette.Interactions[last].Response.toResponse(req), nil
+	}
+	return nil, fmt.Errorf("cassette %s: no unused interaction matches %s %s", layer.path, req.Method, (&url.URL{Path: recorded.Path, RawQuery: recorded.Query}).String())
+}
+
+// matches reports whether two captured requests have the same method, path, query and body, apart from the values of
+// the ignored body fields.
+func (request *CassetteRequest) matches(other *CassetteRequest, ignoreFields map[string]bool) bool {
+	return request.Method == other.Method &&
+		request.Path == other.Path &&
+		request.Query == other.Query &&
+		string(ignoreJSONFields(redactJSON([]byte(request.Body)), ignoreFields)) == string(ignoreJSONFields([]byte(other.Body), ignoreFields))
+}
+
+// ignoreJSONFields replaces the values of fields of a JSON body with the same placeholder. Bodies that are not JSON are
+// returned as they are.
+func ignoreJSONFields(body []byte, fields map[string]bool) []byte {
+	if len(fields) == 0 || len(body) == 0 {
+		return body
+	}
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if decoder.Decode(&doc) != nil {
+		return body
+	}
+	var ignore func(value interface{}) interface{}
+	ignore = func(value interface{}) interface{} {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, item := range v {
+				if fields[strings.ToLower(key)] {
+					v[key] = "[IGNORED]"
+				} else {
+					v[key] = ignore(item)
+				}
+			}
+		case []interface{}:
+			for i, item := range v {
+				v[i] = ignore(item)
+			}
+		}
+		return value
+	}
+	ignored, err := json.Marshal(ignore(doc))
+	if err != nil {
+		return body
+	}
+	return ignored
+}
+
+func (response *CassetteResponse) toResponse(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        http.StatusText(response.StatusCode),
+		StatusCode:    response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        response.Headers.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(response.Body))),
+		ContentLength: int64(len(response.Body)),
+		Request:       req,
+	}
+}
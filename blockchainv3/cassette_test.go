@@ -0,0 +1,177 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe(`BlockchainV3 record/replay cassettes`, func() {
+	var testServer *httptest.Server
+	var requestCount int
+	var cassettePath string
+	var tempDir string
+
+	newService := func(url string) *blockchainv3.BlockchainV3 {
+		blockchainService, serviceErr := blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL: url,
+			Authenticator: &core.BearerTokenAuthenticator{
+				BearerToken: "my-secret-token",
+			},
+		})
+		Expect(serviceErr).To(BeNil())
+		return blockchainService
+	}
+
+	BeforeEach(func() {
+		requestCount = 0
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			requestCount++
+			res.Header().Set("Content-type", "application/json")
+			res.WriteHeader(200)
+			if req.Method == "GET" {
+				fmt.Fprintf(res, `{"id": "myca", "display_name": "call %d"}`, requestCount)
+			} else {
+				fmt.Fprintf(res, `{"id": "myca", "type": "fabric-ca"}`)
+			}
+		}))
+		var err error
+		tempDir, err = ioutil.TempDir("", "cassette")
+		Expect(err).To(BeNil())
+		cassettePath = filepath.Join(tempDir, "fixtures", "ca.json")
+	})
+	AfterEach(func() {
+		testServer.Close()
+		os.RemoveAll(tempDir)
+	})
+
+	record := func() {
+		blockchainService := newService(testServer.URL)
+		Expect(blockchainService.UseCassette(blockchainService.NewCassetteOptions(cassettePath, blockchainv3.CassetteOptions_Mode_Record))).To(BeNil())
+
+		_, _, err := blockchainService.GetComponent(blockchainService.NewGetComponentOptions("myca"))
+		Expect(err).To(BeNil())
+		identity, _ := blockchainService.NewConfigCARegistryIdentitiesItem("admin", "adminpw", "client")
+		registry, _ := blockchainService.NewConfigCARegistry(-1, []blockchainv3.ConfigCARegistryIdentitiesItem{*identity})
+		caConfig, _ := blockchainService.NewConfigCACreate(registry)
+		override, _ := blockchainService.NewCreateCaBodyConfigOverride(caConfig)
+		_, _, err = blockchainService.CreateCa(blockchainService.NewCreateCaOptions("My CA", override))
+		Expect(err).To(BeNil())
+		_, _, err = blockchainService.GetComponent(blockchainService.NewGetComponentOptions("myca"))
+		Expect(err).To(BeNil())
+
+		Expect(blockchainService.EjectCassette()).To(BeNil())
+		Expect(requestCount).To(Equal(3))
+	}
+
+	It(`Records interactions with secrets scrubbed`, func() {
+		record()
+		cassette, err := blockchainv3.LoadCassette(cassettePath)
+		Expect(err).To(BeNil())
+		Expect(cassette.Interactions).To(HaveLen(3))
+		Expect(cassette.Interactions[1].Request.Operation).To(Equal("CreateCa"))
+		Expect(cassette.Interactions[1].Request.Headers.Get("Authorization")).To(Equal(blockchainv3.RedactedValue))
+		Expect(cassette.Interactions[1].Request.Body).To(ContainSubstring(`"pass":"[REDACTED]"`))
+		Expect(cassette.Interactions[1].Request.Body).ToNot(ContainSubstring("adminpw"))
+		Expect(cassette.Interactions[2].Response.Body).To(ContainSubstring("call 3"))
+
+		data, err := ioutil.ReadFile(cassettePath)
+		Expect(err).To(BeNil())
+		Expect(string(data)).ToNot(ContainSubstring("my-secret-token"))
+	})
+	It(`Replays interactions in order without contacting the console`, func() {
+		record()
+		testServer.Close()
+
+		blockchainService := newService(testServer.URL)
+		Expect(blockchainService.UseCassette(blockchainService.NewCassetteOptions(cassettePath, blockchainv3.CassetteOptions_Mode_Replay))).To(BeNil())
+
+		result, response, err := blockchainService.GetComponent(blockchainService.NewGetComponentOptions("myca"))
+		Expect(err).To(BeNil())
+		Expect(response.StatusCode).To(Equal(200))
+		Expect(*result.DisplayName).To(Equal("call 1"))
+
+		identity, _ := blockchainService.NewConfigCARegistryIdentitiesItem("admin", "adminpw", "client")
+		registry, _ := blockchainService.NewConfigCARegistry(-1, []blockchainv3.ConfigCARegistryIdentitiesItem{*identity})
+		caConfig, _ := blockchainService.NewConfigCACreate(registry)
+		override, _ := blockchainService.NewCreateCaBodyConfigOverride(caConfig)
+		ca, _, err := blockchainService.CreateCa(blockchainService.NewCreateCaOptions("My CA", override))
+		Expect(err).To(BeNil())
+		Expect(*ca.ID).To(Equal("myca"))
+
+		result, _, err = blockchainService.GetComponent(blockchainService.NewGetComponentOptions("myca"))
+		Expect(err).To(BeNil())
+		Expect(*result.DisplayName).To(Equal("call 3"))
+
+		// every recorded GetComponent was used
+		_, _, err = blockchainService.GetComponent(blockchainService.NewGetComponentOptions("myca"))
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("no unused interaction"))
+		Expect(blockchainService.EjectCassette()).To(BeNil())
+	})
+	It(`Rejects requests that were not recorded`, func() {
+		record()
+		blockchainService := newService(testServer.URL)
+		Expect(blockchainService.UseCassette(blockchainService.NewCassetteOptions(cassettePath, blockchainv3.CassetteOptions_Mode_Replay).SetAllowRepeats(true))).To(BeNil())
+
+		_, _, err := blockchainService.GetComponent(blockchainService.NewGetComponentOptions("otherca"))
+		Expect(err).ToNot(BeNil())
+		for i := 0; i < 3; i++ {
+			_, _, err = blockchainService.GetComponent(blockchainService.NewGetComponentOptions("myca"))
+			Expect(err).To(BeNil())
+		}
+		Expect(requestCount).To(Equal(3))
+	})
+	It(`Ignores generated crypto when asked to`, func() {
+		importMsp := func(blockchainService *blockchainv3.BlockchainV3, cert string) error {
+			_, _, err := blockchainService.ImportMsp(blockchainService.NewImportMspOptions("org1", "Org 1", []string{cert}).SetAdmins([]string{cert}))
+			return err
+		}
+		blockchainService := newService(testServer.URL)
+		Expect(blockchainService.UseCassette(blockchainService.NewCassetteOptions(cassettePath, blockchainv3.CassetteOptions_Mode_Record))).To(BeNil())
+		Expect(importMsp(blockchainService, "cmVjb3JkZWQ=")).To(BeNil())
+		Expect(blockchainService.EjectCassette()).To(BeNil())
+
+		blockchainService = newService(testServer.URL)
+		Expect(blockchainService.UseCassette(blockchainService.NewCassetteOptions(cassettePath, blockchainv3.CassetteOptions_Mode_Replay))).To(BeNil())
+		err := importMsp(blockchainService, "cmVwbGF5ZWQ=")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("no unused interaction"))
+		Expect(blockchainService.EjectCassette()).To(BeNil())
+
+		Expect(blockchainService.UseCassette(blockchainService.NewCassetteOptions(cassettePath, blockchainv3.CassetteOptions_Mode_Replay).
+			SetIgnoreBodyFields(blockchainv3.CassetteGeneratedCryptoFields))).To(BeNil())
+		Expect(importMsp(blockchainService, "cmVwbGF5ZWQ=")).To(BeNil())
+		Expect(requestCount).To(Equal(1))
+	})
+	It(`Validates the options`, func() {
+		blockchainService := newService(testServer.URL)
+		Expect(blockchainService.UseCassette(nil)).ToNot(BeNil())
+		Expect(blockchainService.UseCassette(blockchainService.NewCassetteOptions(cassettePath, "rewind"))).ToNot(BeNil())
+		Expect(blockchainService.UseCassette(blockchainService.NewCassetteOptions(cassettePath, blockchainv3.CassetteOptions_Mode_Replay))).ToNot(BeNil())
+		Expect(blockchainService.EjectCassette()).ToNot(BeNil())
+	})
+})
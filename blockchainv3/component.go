@@ -0,0 +1,418 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/common"
+	"github.com/IBM/go-sdk-core/v4/core"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Component types, the values of GenericComponentResponse.Type.
+const (
+	ComponentType_FabricCa      = GenericComponentResponse_Type_FabricCa
+	ComponentType_FabricOrderer = GenericComponentResponse_Type_FabricOrderer
+	ComponentType_FabricPeer    = GenericComponentResponse_Type_FabricPeer
+	ComponentType_Msp           = GetComponentsByTypeOptions_Type_Msp
+)
+
+// UnsupportedActionError : A Component action the type of the component does not support, e.g. reenrolling a CA.
+type UnsupportedActionError struct {
+	ID string
+
+	Type string
+
+	Action string
+}
+
+// Error implements the error interface.
+func (err *UnsupportedActionError) Error() string {
+	return fmt.Sprintf("%s is not supported by component %s of type %q", err.Action, err.ID, err.Type)
+}
+
+// Component : A handle on a component of any type, returned by LookupComponent. Its methods call the operation of its
+// type, e.g. Edit calls EditCa, EditPeer, EditOrderer or EditMsp, and return an *UnsupportedActionError for actions the
+// type does not have. The Result of the returned responses is the result of that operation.
+type Component struct {
+	blockchain *BlockchainV3
+
+	mutex   sync.Mutex
+	id      string
+	details *GenericComponentResponse
+}
+
+// LookupComponent gets a component and returns a handle on it.
+func (blockchain *BlockchainV3) LookupComponent(ctx context.Context, id string) (component *Component, err error) {
+	component = &Component{blockchain: blockchain, id: id}
+	err = component.Refresh(ctx)
+	if err != nil {
+		component = nil
+	}
+	return
+}
+
+// ID returns the id of the component.
+func (component *Component) ID() string {
+	return component.id
+}
+
+// Type returns the type of the component, one of ComponentType_*.
+func (component *Component) Type() string {
+	component.mutex.Lock()
+	defer component.mutex.Unlock()
+	if component.details.Type == nil {
+		return ""
+	}
+	return *component.details.Type
+}
+
+// Details returns the component as of the last lookup or Refresh. Edits and updates are not reflected until the next
+// Refresh.
+func (component *Component) Details() *GenericComponentResponse {
+	component.mutex.Lock()
+	defer component.mutex.Unlock()
+	return component.details
+}
+
+// Refresh gets the component again, bypassing the caches of the console.
+func (component *Component) Refresh(ctx context.Context) error {
+	blockchain := component.blockchain
+	details, _, err := blockchain.GetComponentWithContext(ctx, blockchain.NewGetComponentOptions(component.id).SetCache(GetComponentOptions_Cache_Skip))
+	if err == nil && details == nil {
+		err = fmt.Errorf("component %s not found", component.id)
+	}
+	if err != nil {
+		return err
+	}
+	component.mutex.Lock()
+	defer component.mutex.Unlock()
+	component.details = details
+	return nil
+}
+
+func (component *Component) unsupported(action string) error {
+	return &UnsupportedActionError{ID: component.id, Type: component.Type(), Action: action}
+}
+
+// ComponentEdit : The fields Component.Edit changes. Fields that are nil are left unchanged. Each field lists the
+// component types that have it.
+type ComponentEdit struct {
+	// All types.
+	DisplayName *string
+
+	// CAs, peers and orderers.
+	ApiURL        *string
+	OperationsURL *string
+	Location      *string
+	Tags          []string
+
+	// Peers and orderers.
+	GrpcwpURL *string
+
+	// Peers, orderers and MSPs.
+	MspID *string
+
+	// CAs.
+	CaName *string
+
+	// Orderers.
+	ClusterName          *string
+	ConsenterProposalFin *bool
+	SystemChannelID      *string
+
+	// MSPs.
+	RootCerts         []string
+	IntermediateCerts []string
+	Admins            []string
+	TlsRootCerts      []string
+}
+
+// Edit changes the metadata of the component in the console with EditCa, EditPeer, EditOrderer or EditMsp. Fields
+// the type does not have are rejected.
+func (component *Component) Edit(ctx context.Context, edit *ComponentEdit) (response *core.DetailedResponse, err error) {
+	err = core.ValidateNotNil(edit, "edit cannot be nil")
+	if err != nil {
+		return
+	}
+	blockchain := component.blockchain
+	componentType := component.Type()
+	err = component.checkFields("edit", componentType, []componentField{
+		{"DisplayName", edit.DisplayName != nil, "ca peer orderer msp"},
+		{"ApiURL", edit.ApiURL != nil, "ca peer orderer"},
+		{"OperationsURL", edit.OperationsURL != nil, "ca peer orderer"},
+		{"Location", edit.Location != nil, "ca peer orderer"},
+		{"Tags", edit.Tags != nil, "ca peer orderer"},
+		{"GrpcwpURL", edit.GrpcwpURL != nil, "peer orderer"},
+		{"MspID", edit.MspID != nil, "peer orderer msp"},
+		{"CaName", edit.CaName != nil, "ca"},
+		{"ClusterName", edit.ClusterName != nil, "orderer"},
+		{"ConsenterProposalFin", edit.ConsenterProposalFin != nil, "orderer"},
+		{"SystemChannelID", edit.SystemChannelID != nil, "orderer"},
+		{"RootCerts", edit.RootCerts != nil, "msp"},
+		{"IntermediateCerts", edit.IntermediateCerts != nil, "msp"},
+		{"Admins", edit.Admins != nil, "msp"},
+		{"TlsRootCerts", edit.TlsRootCerts != nil, "msp"},
+	})
+	if err != nil {
+		return
+	}
+
+	switch componentType {
+	case ComponentType_FabricCa:
+		options := blockchain.NewEditCaOptions(component.id)
+		options.DisplayName, options.ApiURL, options.OperationsURL = edit.DisplayName, edit.ApiURL, edit.OperationsURL
+		options.CaName, options.Location, options.Tags = edit.CaName, edit.Location, edit.Tags
+		_, response, err = blockchain.EditCaWithContext(ctx, options)
+	case ComponentType_FabricPeer:
+		options := blockchain.NewEditPeerOptions(component.id)
+		options.DisplayName, options.ApiURL, options.OperationsURL = edit.DisplayName, edit.ApiURL, edit.OperationsURL
+		options.GrpcwpURL, options.MspID, options.Location, options.Tags = edit.GrpcwpURL, edit.MspID, edit.Location, edit.Tags
+		_, response, err = blockchain.EditPeerWithContext(ctx, options)
+	case ComponentType_FabricOrderer:
+		options := blockchain.NewEditOrdererOptions(component.id)
+		options.DisplayName, options.ApiURL, options.OperationsURL = edit.DisplayName, edit.ApiURL, edit.OperationsURL
+		options.GrpcwpURL, options.MspID, options.Location, options.Tags = edit.GrpcwpURL, edit.MspID, edit.Location, edit.Tags
+		options.ClusterName, options.ConsenterProposalFin, options.SystemChannelID = edit.ClusterName, edit.ConsenterProposalFin, edit.SystemChannelID
+		_, response, err = blockchain.EditOrdererWithContext(ctx, options)
+	case ComponentType_Msp:
+		options := blockchain.NewEditMspOptions(component.id)
+		options.DisplayName, options.MspID = edit.DisplayName, edit.MspID
+		options.RootCerts, options.IntermediateCerts, options.Admins, options.TlsRootCerts = edit.RootCerts, edit.IntermediateCerts, edit.Admins, edit.TlsRootCerts
+		_, response, err = blockchain.EditMspWithContext(ctx, options)
+	default:
+		err = component.unsupported("edit")
+	}
+	return
+}
+
+// ComponentUpdate : The fields Component.Update changes. Fields that are nil are left unchanged. The types of
+// ConfigOverride, Resources and Crypto depend on the type of the component.
+type ComponentUpdate struct {
+	// All types.
+	Version  *string
+	Zone     *string
+	Replicas *float64
+
+	// *UpdateCaBodyConfigOverride, *ConfigPeerUpdate or *ConfigOrdererUpdate.
+	ConfigOverride interface{}
+
+	// *UpdateCaBodyResources, *PeerResources or *UpdateOrdererBodyResources.
+	Resources interface{}
+
+	// Peers and orderers: *UpdatePeerBodyCrypto or *UpdateOrdererBodyCrypto.
+	Crypto interface{}
+
+	// Peers and orderers.
+	AdminCerts []string
+	NodeOu     *NodeOu
+}
+
+// Update changes the deployment of a CA, peer or orderer with UpdateCa, UpdatePeer or UpdateOrderer. Fields the type
+// does not have, and fields of the wrong type, are rejected.
+func (component *Component) Update(ctx context.Context, update *ComponentUpdate) (response *core.DetailedResponse, err error) {
+	err = core.ValidateNotNil(update, "update cannot be nil")
+	if err != nil {
+		return
+	}
+	blockchain := component.blockchain
+	componentType := component.Type()
+	if componentType == ComponentType_Msp {
+		err = component.unsupported("update")
+		return
+	}
+	err = component.checkFields("update", componentType, []componentField{
+		{"Crypto", update.Crypto != nil, "peer orderer"},
+		{"AdminCerts", update.AdminCerts != nil, "peer orderer"},
+		{"NodeOu", update.NodeOu != nil, "peer orderer"},
+	})
+	if err != nil {
+		return
+	}
+
+	switch componentType {
+	case ComponentType_FabricCa:
+		options := blockchain.NewUpdateCaOptions(component.id)
+		options.Version, options.Zone, options.Replicas = update.Version, update.Zone, update.Replicas
+		if err = assignOverride(&options.ConfigOverride, update.ConfigOverride, "ConfigOverride", componentType); err != nil {
+			return
+		}
+		if err = assignOverride(&options.Resources, update.Resources, "Resources", componentType); err != nil {
+			return
+		}
+		_, response, err = blockchain.UpdateCaWithContext(ctx, options)
+	case ComponentType_FabricPeer:
+		options := blockchain.NewUpdatePeerOptions(component.id)
+		options.Version, options.Zone, options.Replicas = update.Version, update.Zone, update.Replicas
+		options.AdminCerts, options.NodeOu = update.AdminCerts, update.NodeOu
+		if err = assignOverride(&options.ConfigOverride, update.ConfigOverride, "ConfigOverride", componentType); err != nil {
+			return
+		}
+		if err = assignOverride(&options.Resources, update.Resources, "Resources", componentType); err != nil {
+			return
+		}
+		if err = assignOverride(&options.Crypto, update.Crypto, "Crypto", componentType); err != nil {
+			return
+		}
+		_, response, err = blockchain.UpdatePeerWithContext(ctx, options)
+	case ComponentType_FabricOrderer:
+		options := blockchain.NewUpdateOrdererOptions(component.id)
+		options.Version, options.Zone, options.Replicas = update.Version, update.Zone, update.Replicas
+		options.AdminCerts, options.NodeOu = update.AdminCerts, update.NodeOu
+		if err = assignOverride(&options.ConfigOverride, update.ConfigOverride, "ConfigOverride", componentType); err != nil {
+			return
+		}
+		if err = assignOverride(&options.Resources, update.Resources, "Resources", componentType); err != nil {
+			return
+		}
+		if err = assignOverride(&options.Crypto, update.Crypto, "Crypto", componentType); err != nil {
+			return
+		}
+		_, response, err = blockchain.UpdateOrdererWithContext(ctx, options)
+	default:
+		err = component.unsupported("update")
+	}
+	return
+}
+
+// Restart restarts the pods of a CA, peer or orderer with CaAction, PeerAction or OrdererAction.
+func (component *Component) Restart(ctx context.Context) (response *core.DetailedResponse, err error) {
+	blockchain := component.blockchain
+	switch component.Type() {
+	case ComponentType_FabricCa:
+		_, response, err = blockchain.CaActionWithContext(ctx, blockchain.NewCaActionOptions(component.id).SetRestart(true))
+	case ComponentType_FabricPeer:
+		_, response, err = blockchain.PeerActionWithContext(ctx, blockchain.NewPeerActionOptions(component.id).SetRestart(true))
+	case ComponentType_FabricOrderer:
+		_, response, err = blockchain.OrdererActionWithContext(ctx, blockchain.NewOrdererActionOptions(component.id).SetRestart(true))
+	default:
+		err = component.unsupported("restart")
+	}
+	return
+}
+
+// Reenroll reenrolls the TLS certificate and/or the enrollment certificate of a peer or orderer with PeerAction or
+// OrdererAction. CAs cannot be reenrolled.
+func (component *Component) Reenroll(ctx context.Context, tlsCert bool, ecert bool) (response *core.DetailedResponse, err error) {
+	if !tlsCert && !ecert {
+		err = fmt.Errorf("reenroll needs the TLS certificate, the enrollment certificate or both")
+		return
+	}
+	blockchain := component.blockchain
+	reenroll := &ActionReenroll{TlsCert: core.BoolPtr(tlsCert), Ecert: core.BoolPtr(ecert)}
+	switch component.Type() {
+	case ComponentType_FabricPeer:
+		_, response, err = blockchain.PeerActionWithContext(ctx, blockchain.NewPeerActionOptions(component.id).SetReenroll(reenroll))
+	case ComponentType_FabricOrderer:
+		_, response, err = blockchain.OrdererActionWithContext(ctx, blockchain.NewOrdererActionOptions(component.id).SetReenroll(reenroll))
+	default:
+		err = component.unsupported("reenroll")
+	}
+	return
+}
+
+// Remove removes the component from the console with RemoveComponent. A deployed component keeps running.
+func (component *Component) Remove(ctx context.Context) (response *core.DetailedResponse, err error) {
+	blockchain := component.blockchain
+	_, response, err = blockchain.RemoveComponentWithContext(ctx, blockchain.NewRemoveComponentOptions(component.id))
+	return
+}
+
+// Delete removes a CA, peer or orderer from the console and deletes its Kubernetes deployment with DeleteComponent.
+// MSPs have no deployment, use Remove.
+func (component *Component) Delete(ctx context.Context) (response *core.DetailedResponse, err error) {
+	if component.Type() == ComponentType_Msp {
+		err = component.unsupported("delete")
+		return
+	}
+	blockchain := component.blockchain
+	_, response, err = blockchain.DeleteComponentWithContext(ctx, blockchain.NewDeleteComponentOptions(component.id))
+	return
+}
+
+// componentField is a field of a ComponentEdit or ComponentUpdate and the component types that have it.
+type componentField struct {
+	name  string
+	set   bool
+	types string
+}
+
+// checkFields rejects the fields that are set but that componentType does not have.
+func (component *Component) checkFields(action string, componentType string, fields []componentField) error {
+	short := strings.TrimPrefix(componentType, "fabric-")
+	var rejected []string
+	for _, field := range fields {
+		if field.set && !containsString(strings.Fields(field.types), short) {
+			rejected = append(rejected, field.name)
+		}
+	}
+	if len(rejected) > 0 {
+		return &UnsupportedActionError{
+			ID:     component.id,
+			Type:   componentType,
+			Action: fmt.Sprintf("%s of %s", action, strings.Join(rejected, ", ")),
+		}
+	}
+	return nil
+}
+
+// assignOverride sets *target to value if value has the type of *target.
+func assignOverride(target interface{}, value interface{}, field string, componentType string) error {
+	if value == nil {
+		return nil
+	}
+	slot := reflect.ValueOf(target).Elem()
+	if reflect.TypeOf(value) != slot.Type() {
+		return fmt.Errorf("%s of a %s must be a %s, not %T", field, componentType, slot.Type(), value)
+	}
+	slot.Set(reflect.ValueOf(value))
+	return nil
+}
+
+// getRawComponent reads a component with GetComponent's request as a raw JSON object, for the fields that
+// GenericComponentResponse does not have, e.g. the status of a node or the admins of an MSP.
+func (blockchain *BlockchainV3) getRawComponent(ctx context.Context, id string, query map[string]string) (rawResponse map[string]json.RawMessage, err error) {
+	builder := core.NewRequestBuilder(core.GET)
+	builder = builder.WithContext(ctx)
+	builder.EnableGzipCompression = blockchain.GetEnableGzipCompression()
+	_, err = builder.ResolveRequestURL(blockchain.Service.Options.URL, `/ak/api/v3/components/{id}`, map[string]string{"id": id})
+	if err != nil {
+		return
+	}
+	sdkHeaders := common.GetSdkHeaders("blockchain", "V3", "GetComponent")
+	for headerName, headerValue := range sdkHeaders {
+		builder.AddHeader(headerName, headerValue)
+	}
+	builder.AddHeader("Accept", "application/json")
+	for name, value := range query {
+		builder.AddQuery(name, value)
+	}
+
+	request, err := builder.Build()
+	if err != nil {
+		return
+	}
+	_, err = blockchain.Service.Request(request, &rawResponse)
+	if err == nil && rawResponse == nil {
+		err = fmt.Errorf("component %s not found", id)
+	}
+	return
+}
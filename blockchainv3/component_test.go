@@ -0,0 +1,169 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+var _ = Describe(`BlockchainV3 component handles`, func() {
+	var testServer *httptest.Server
+	var blockchainService *blockchainv3.BlockchainV3
+	var calls []string
+	var displayName string
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		calls = nil
+		displayName = "first"
+		types := map[string]string{"ca1": "fabric-ca", "peer1": "fabric-peer", "os1": "fabric-orderer", "msp1": "msp"}
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			res.Header().Set("Content-type", "application/json")
+			op, ok := blockchainv3.LookupOperation(req.Method, req.URL.Path)
+			Expect(ok).To(BeTrue())
+			id := req.URL.Path[strings.LastIndex(strings.TrimSuffix(req.URL.Path, "/actions"), "/")+1:]
+			id = strings.TrimSuffix(id, "/actions")
+			if op.Name == "GetComponent" {
+				fmt.Fprintf(res, `{"id": "%s", "type": "%s", "display_name": "%s"}`, id, types[id], displayName)
+				return
+			}
+			body, _ := ioutil.ReadAll(req.Body)
+			calls = append(calls, strings.TrimSpace(fmt.Sprintf("%s %s", op.Name, body)))
+			fmt.Fprintf(res, `{"id": "%s"}`, id)
+		}))
+		var serviceErr error
+		blockchainService, serviceErr = blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(serviceErr).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	lookup := func(id string) *blockchainv3.Component {
+		component, err := blockchainService.LookupComponent(ctx, id)
+		Expect(err).To(BeNil())
+		return component
+	}
+	expectUnsupported := func(err error, action string) {
+		var unsupported *blockchainv3.UnsupportedActionError
+		Expect(errors.As(err, &unsupported)).To(BeTrue())
+		Expect(unsupported.Action).To(Equal(action))
+	}
+
+	It(`Knows its type and refreshes`, func() {
+		component := lookup("peer1")
+		Expect(component.ID()).To(Equal("peer1"))
+		Expect(component.Type()).To(Equal(blockchainv3.ComponentType_FabricPeer))
+		Expect(*component.Details().DisplayName).To(Equal("first"))
+		displayName = "second"
+		Expect(component.Refresh(ctx)).To(BeNil())
+		Expect(*component.Details().DisplayName).To(Equal("second"))
+	})
+	It(`Dispatches edits`, func() {
+		name := core.StringPtr("renamed")
+		for _, id := range []string{"ca1", "peer1", "os1", "msp1"} {
+			_, err := lookup(id).Edit(ctx, &blockchainv3.ComponentEdit{DisplayName: name})
+			Expect(err).To(BeNil())
+		}
+		_, err := lookup("os1").Edit(ctx, &blockchainv3.ComponentEdit{ClusterName: name, Tags: []string{"a"}})
+		Expect(err).To(BeNil())
+		Expect(calls).To(Equal([]string{
+			`EditCa {"display_name":"renamed"}`,
+			`EditPeer {"display_name":"renamed"}`,
+			`EditOrderer {"display_name":"renamed"}`,
+			`EditMsp {"display_name":"renamed"}`,
+			`EditOrderer {"cluster_name":"renamed","tags":["a"]}`,
+		}))
+
+		_, err = lookup("ca1").Edit(ctx, &blockchainv3.ComponentEdit{GrpcwpURL: name, MspID: name, DisplayName: name})
+		expectUnsupported(err, "edit of GrpcwpURL, MspID")
+		Expect(err.Error()).To(Equal(`edit of GrpcwpURL, MspID is not supported by component ca1 of type "fabric-ca"`))
+		_, err = lookup("msp1").Edit(ctx, &blockchainv3.ComponentEdit{Tags: []string{"a"}})
+		expectUnsupported(err, "edit of Tags")
+		Expect(calls).To(HaveLen(5))
+	})
+	It(`Dispatches updates with the types of the component`, func() {
+		_, err := lookup("os1").Update(ctx, &blockchainv3.ComponentUpdate{Version: core.StringPtr("2.2.1"), Resources: &blockchainv3.PeerResources{}})
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("Resources of a fabric-orderer must be a *blockchainv3.UpdateOrdererBodyResources, not *blockchainv3.PeerResources"))
+
+		_, err = lookup("os1").Update(ctx, &blockchainv3.ComponentUpdate{Version: core.StringPtr("2.2.1"), Resources: &blockchainv3.UpdateOrdererBodyResources{}})
+		Expect(err).To(BeNil())
+		_, err = lookup("peer1").Update(ctx, &blockchainv3.ComponentUpdate{Zone: core.StringPtr("dal10"), AdminCerts: []string{"cert"}})
+		Expect(err).To(BeNil())
+		_, err = lookup("ca1").Update(ctx, &blockchainv3.ComponentUpdate{Replicas: core.Float64Ptr(2)})
+		Expect(err).To(BeNil())
+		Expect(calls).To(Equal([]string{
+			`UpdateOrderer {"resources":{},"version":"2.2.1"}`,
+			`UpdatePeer {"admin_certs":["cert"],"zone":"dal10"}`,
+			`UpdateCa {"replicas":2}`,
+		}))
+
+		_, err = lookup("ca1").Update(ctx, &blockchainv3.ComponentUpdate{AdminCerts: []string{"cert"}})
+		expectUnsupported(err, "update of AdminCerts")
+		_, err = lookup("msp1").Update(ctx, &blockchainv3.ComponentUpdate{Version: core.StringPtr("2.2.1")})
+		expectUnsupported(err, "update")
+	})
+	It(`Restarts and reenrolls the types that support it`, func() {
+		for _, id := range []string{"ca1", "peer1", "os1"} {
+			_, err := lookup(id).Restart(ctx)
+			Expect(err).To(BeNil())
+		}
+		_, err := lookup("msp1").Restart(ctx)
+		expectUnsupported(err, "restart")
+
+		_, err = lookup("peer1").Reenroll(ctx, true, false)
+		Expect(err).To(BeNil())
+		_, err = lookup("os1").Reenroll(ctx, true, true)
+		Expect(err).To(BeNil())
+		_, err = lookup("ca1").Reenroll(ctx, true, true)
+		expectUnsupported(err, "reenroll")
+		_, err = lookup("peer1").Reenroll(ctx, false, false)
+		Expect(err).ToNot(BeNil())
+
+		Expect(calls).To(Equal([]string{
+			`CaAction {"restart":true}`,
+			`PeerAction {"restart":true}`,
+			`OrdererAction {"restart":true}`,
+			`PeerAction {"reenroll":{"tls_cert":true,"ecert":false}}`,
+			`OrdererAction {"reenroll":{"tls_cert":true,"ecert":true}}`,
+		}))
+	})
+	It(`Removes and deletes`, func() {
+		_, err := lookup("msp1").Remove(ctx)
+		Expect(err).To(BeNil())
+		_, err = lookup("msp1").Delete(ctx)
+		expectUnsupported(err, "delete")
+		_, err = lookup("peer1").Delete(ctx)
+		Expect(err).To(BeNil())
+		Expect(calls).To(Equal([]string{"RemoveComponent", "DeleteComponent"}))
+	})
+})
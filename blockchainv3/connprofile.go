@@ -0,0 +1,755 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"github.com/IBM/go-sdk-core/v4/core"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// connectionProfileDialTimeout bounds the connections FetchCerts makes.
+const connectionProfileDialTimeout = 10 * time.Second
+
+// ConnectionProfile : A Fabric common connection profile, as exchanged between organizations. Only the parts that
+// describe nodes are read.
+type ConnectionProfile struct {
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+
+	// Keyed by organization name.
+	Organizations map[string]*ConnectionProfileOrganization `json:"organizations,omitempty" yaml:"organizations,omitempty"`
+
+	// Keyed by node name.
+	Orderers map[string]*ConnectionProfileNode `json:"orderers,omitempty" yaml:"orderers,omitempty"`
+
+	// Keyed by node name.
+	Peers map[string]*ConnectionProfileNode `json:"peers,omitempty" yaml:"peers,omitempty"`
+
+	// Keyed by CA name.
+	CertificateAuthorities map[string]*ConnectionProfileCA `json:"certificateAuthorities,omitempty" yaml:"certificateAuthorities,omitempty"`
+
+	// The directory relative certificate paths are resolved from.
+	dir string
+}
+
+// ConnectionProfileOrganization : An organization of a connection profile.
+type ConnectionProfileOrganization struct {
+	MspID string `json:"mspid" yaml:"mspid"`
+
+	Peers []string `json:"peers,omitempty" yaml:"peers,omitempty"`
+
+	Orderers []string `json:"orderers,omitempty" yaml:"orderers,omitempty"`
+
+	CertificateAuthorities []string `json:"certificateAuthorities,omitempty" yaml:"certificateAuthorities,omitempty"`
+}
+
+// ConnectionProfileNode : A peer or orderer of a connection profile.
+type ConnectionProfileNode struct {
+	// The gRPC URL, e.g. grpcs://peer0.org1.example.com:7051.
+	URL string `json:"url" yaml:"url"`
+
+	// The gRPC web proxy URL. Profiles downloaded from a console have it.
+	GrpcwpURL string `json:"grpcwpURL,omitempty" yaml:"grpcwpURL,omitempty"`
+
+	TlsCACerts *ConnectionProfileCerts `json:"tlsCACerts,omitempty" yaml:"tlsCACerts,omitempty"`
+
+	GrpcOptions map[string]interface{} `json:"grpcOptions,omitempty" yaml:"grpcOptions,omitempty"`
+}
+
+// ConnectionProfileCA : A certificate authority of a connection profile.
+type ConnectionProfileCA struct {
+	URL string `json:"url" yaml:"url"`
+
+	CaName string `json:"caName,omitempty" yaml:"caName,omitempty"`
+
+	TlsCACerts *ConnectionProfileCerts `json:"tlsCACerts,omitempty" yaml:"tlsCACerts,omitempty"`
+}
+
+// ConnectionProfileCerts : PEM certificates, inline or in a file.
+type ConnectionProfileCerts struct {
+	// One or more PEM certificates. A profile may give a string or a list of strings.
+	Pem PemList `json:"pem,omitempty" yaml:"pem,omitempty"`
+
+	// Path of a PEM file, relative to the profile.
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+}
+
+// PemList : PEM text that a connection profile may give as a string or as a list of strings.
+type PemList []string
+
+// UnmarshalJSON accepts a string or a list of strings.
+func (list *PemList) UnmarshalJSON(data []byte) error {
+	var single string
+	if json.Unmarshal(data, &single) == nil {
+		*list = PemList{single}
+		return nil
+	}
+	var multiple []string
+	err := json.Unmarshal(data, &multiple)
+	*list = multiple
+	return err
+}
+
+// UnmarshalYAML accepts a string or a list of strings.
+func (list *PemList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var single string
+	if unmarshal(&single) == nil {
+		*list = PemList{single}
+		return nil
+	}
+	var multiple []string
+	err := unmarshal(&multiple)
+	*list = multiple
+	return err
+}
+
+// ParseConnectionProfile parses a connection profile in JSON or YAML. Relative certificate paths are resolved from
+// the working directory.
+func ParseConnectionProfile(data []byte) (profile *ConnectionProfile, err error) {
+	profile = new(ConnectionProfile)
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(data, profile)
+	} else {
+		err = yaml.Unmarshal(data, profile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connection profile: %s", err.Error())
+	}
+	return
+}
+
+// LoadConnectionProfile reads a connection profile in JSON or YAML. Relative certificate paths are resolved from the
+// directory of the profile.
+func LoadConnectionProfile(path string) (profile *ConnectionProfile, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	profile, err = ParseConnectionProfile(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err.Error())
+	}
+	profile.dir = filepath.Dir(path)
+	return
+}
+
+// ConnectionProfileImportOptions : The ImportConnectionProfile options.
+type ConnectionProfileImportOptions struct {
+	// Only list what would be imported.
+	Preview *bool
+
+	// The organizations to import, by name. Defaults to all of them.
+	Organizations []string
+
+	// gRPC web proxy URLs keyed by node name, for nodes the profile gives none for.
+	GrpcwpURLs map[string]string
+
+	// TLS certificates of nodes, as base 64 encoded PEM keyed by node name. Profiles only have the TLS CA
+	// certificates, and the console needs the certificate of the node too.
+	TlsCerts map[string]string
+
+	// Root certificates of MSPs, as base 64 encoded PEM keyed by MSP id. Profiles do not have them.
+	MspRootCerts map[string][]string
+
+	// Fetch the certificates the profile does not have from the nodes and CAs themselves: the TLS certificate of each
+	// node, and the CA chain of each organization from its first CA. Connections are verified with the TLS CA
+	// certificates of the profile.
+	FetchCerts *bool
+
+	// The ordering service name of imported orderers. Defaults to the name of the profile.
+	ClusterName *string
+
+	// The name of the TLS CA of imported CAs. Defaults to "tlsca".
+	TlscaName *string
+
+	// Tags of every imported component.
+	Tags []string
+}
+
+// NewConnectionProfileImportOptions : Instantiate ConnectionProfileImportOptions
+func (*BlockchainV3) NewConnectionProfileImportOptions() *ConnectionProfileImportOptions {
+	return &ConnectionProfileImportOptions{}
+}
+
+// SetPreview : Allow user to set Preview
+func (options *ConnectionProfileImportOptions) SetPreview(preview bool) *ConnectionProfileImportOptions {
+	options.Preview = core.BoolPtr(preview)
+	return options
+}
+
+// SetOrganizations : Allow user to set Organizations
+func (options *ConnectionProfileImportOptions) SetOrganizations(organizations []string) *ConnectionProfileImportOptions {
+	options.Organizations = organizations
+	return options
+}
+
+// SetGrpcwpURL : Allow user to set the gRPC web proxy URL of a node
+func (options *ConnectionProfileImportOptions) SetGrpcwpURL(node string, grpcwpURL string) *ConnectionProfileImportOptions {
+	if options.GrpcwpURLs == nil {
+		options.GrpcwpURLs = map[string]string{}
+	}
+	options.GrpcwpURLs[node] = grpcwpURL
+	return options
+}
+
+// SetTlsCert : Allow user to set the TLS certificate of a node
+func (options *ConnectionProfileImportOptions) SetTlsCert(node string, tlsCert string) *ConnectionProfileImportOptions {
+	if options.TlsCerts == nil {
+		options.TlsCerts = map[string]string{}
+	}
+	options.TlsCerts[node] = tlsCert
+	return options
+}
+
+// SetMspRootCerts : Allow user to set the root certificates of an MSP
+func (options *ConnectionProfileImportOptions) SetMspRootCerts(mspID string, rootCerts []string) *ConnectionProfileImportOptions {
+	if options.MspRootCerts == nil {
+		options.MspRootCerts = map[string][]string{}
+	}
+	options.MspRootCerts[mspID] = rootCerts
+	return options
+}
+
+// SetFetchCerts : Allow user to set FetchCerts
+func (options *ConnectionProfileImportOptions) SetFetchCerts(fetchCerts bool) *ConnectionProfileImportOptions {
+	options.FetchCerts = core.BoolPtr(fetchCerts)
+	return options
+}
+
+// SetClusterName : Allow user to set ClusterName
+func (options *ConnectionProfileImportOptions) SetClusterName(clusterName string) *ConnectionProfileImportOptions {
+	options.ClusterName = core.StringPtr(clusterName)
+	return options
+}
+
+// SetTlscaName : Allow user to set TlscaName
+func (options *ConnectionProfileImportOptions) SetTlscaName(tlscaName string) *ConnectionProfileImportOptions {
+	options.TlscaName = core.StringPtr(tlscaName)
+	return options
+}
+
+// SetTags : Allow user to set Tags
+func (options *ConnectionProfileImportOptions) SetTags(tags []string) *ConnectionProfileImportOptions {
+	options.Tags = tags
+	return options
+}
+
+// ConnectionProfileImportItem : A component of a connection profile and the request that imports it. Exactly one of
+// the Import* fields is set, unless Error says why the component cannot be imported.
+type ConnectionProfileImportItem struct {
+	// The name of the node or CA in the profile, or the MSP id of an MSP.
+	Name string
+
+	// One of ComponentType_*.
+	Type string
+
+	MspID string
+
+	ImportMsp     *ImportMspOptions
+	ImportCa      *ImportCaOptions
+	ImportPeer    *ImportPeerOptions
+	ImportOrderer *ImportOrdererOptions
+
+	Error error
+
+	// The id of the imported component, once imported.
+	ID string
+}
+
+// ConnectionProfileImport : The components of a connection profile, in the order they are imported: MSPs, CAs,
+// orderers, then peers.
+type ConnectionProfileImport struct {
+	Items []*ConnectionProfileImportItem
+}
+
+// Errors returns the items that cannot be imported.
+func (result *ConnectionProfileImport) Errors() (items []*ConnectionProfileImportItem) {
+	for _, item := range result.Items {
+		if item.Error != nil {
+			items = append(items, item)
+		}
+	}
+	return
+}
+
+// ImportConnectionProfile imports the MSPs, CAs, orderers and peers of a connection profile with ImportMsp, ImportCa,
+// ImportOrderer and ImportPeer.
+//
+// The gRPC URL, the gRPC web proxy URL and the TLS CA certificates of each node come from the profile. What profiles do
+// not have, the TLS certificates of nodes and the root certificates of MSPs, comes from the options. Nothing is
+// imported if a component misses something; the result then lists what is missing. In preview mode nothing is imported
+// either, and the result lists the requests that would be made. Otherwise components are imported one after the
+// other, stopping on the first failure; the IDs of the result show which were imported.
+func (blockchain *BlockchainV3) ImportConnectionProfile(ctx context.Context, profile *ConnectionProfile, options *ConnectionProfileImportOptions) (result *ConnectionProfileImport, err error) {
+	err = core.ValidateNotNil(profile, "profile cannot be nil")
+	if err != nil {
+		return
+	}
+	if options == nil {
+		options = blockchain.NewConnectionProfileImportOptions()
+	}
+	result, err = planConnectionProfileImport(ctx, profile, options)
+	if err != nil {
+		return
+	}
+	if options.Preview != nil && *options.Preview {
+		return
+	}
+	if failures := result.Errors(); len(failures) > 0 {
+		var messages []string
+		for _, item := range failures {
+			messages = append(messages, fmt.Sprintf("%s: %s", item.Name, item.Error.Error()))
+		}
+		return result, fmt.Errorf("%d components of the connection profile cannot be imported: %s", len(failures), strings.Join(messages, "; "))
+	}
+
+	for _, item := range result.Items {
+		var id *string
+		switch {
+		case item.ImportMsp != nil:
+			var msp *MspResponse
+			msp, _, err = blockchain.ImportMspWithContext(ctx, item.ImportMsp)
+			if msp != nil {
+				id = msp.ID
+			}
+		case item.ImportCa != nil:
+			var ca *CaResponse
+			ca, _, err = blockchain.ImportCaWithContext(ctx, item.ImportCa)
+			if ca != nil {
+				id = ca.ID
+			}
+		case item.ImportOrderer != nil:
+			var orderers *OrdererResponse
+			orderers, _, err = blockchain.ImportOrdererWithContext(ctx, item.ImportOrderer)
+			if orderers != nil {
+				id = orderers.ID
+			}
+		case item.ImportPeer != nil:
+			var peer *PeerResponse
+			peer, _, err = blockchain.ImportPeerWithContext(ctx, item.ImportPeer)
+			if peer != nil {
+				id = peer.ID
+			}
+		}
+		if err != nil {
+			item.Error = err
+			return result, fmt.Errorf("importing %s %s failed: %s", item.Type, item.Name, err.Error())
+		}
+		if id != nil {
+			item.ID = *id
+		}
+	}
+	return
+}
+
+// planConnectionProfileImport works out the import requests of a profile.
+func planConnectionProfileImport(ctx context.Context, profile *ConnectionProfile, options *ConnectionProfileImportOptions) (*ConnectionProfileImport, error) {
+	fetch := options.FetchCerts != nil && *options.FetchCerts
+	clusterName := profile.Name
+	if options.ClusterName != nil {
+		clusterName = *options.ClusterName
+	}
+	if clusterName == "" {
+		clusterName = "Ordering Service"
+	}
+	tlscaName := "tlsca"
+	if options.TlscaName != nil {
+		tlscaName = *options.TlscaName
+	}
+
+	var names []string
+	for name := range profile.Organizations {
+		if len(options.Organizations) == 0 || containsString(options.Organizations, name) {
+			names = append(names, name)
+		}
+	}
+	for _, name := range options.Organizations {
+		if profile.Organizations[name] == nil {
+			return nil, fmt.Errorf("the connection profile has no organization %s", name)
+		}
+	}
+	sort.Strings(names)
+
+	var msps, cas, orderers, peers []*ConnectionProfileImportItem
+	for _, name := range names {
+		organization := profile.Organizations[name]
+		mspID := organization.MspID
+		if mspID == "" {
+			return nil, fmt.Errorf("organization %s of the connection profile has no mspid", name)
+		}
+
+		// the MSP, with the TLS CA certificates of its nodes
+		msp := &ConnectionProfileImportItem{Name: mspID, Type: ComponentType_Msp, MspID: mspID}
+		msps = append(msps, msp)
+		rootCerts, intermediateCerts := options.MspRootCerts[mspID], []string(nil)
+		if len(rootCerts) == 0 && fetch && len(organization.CertificateAuthorities) > 0 {
+			caName := organization.CertificateAuthorities[0]
+			ca := profile.CertificateAuthorities[caName]
+			if ca == nil {
+				msp.Error = fmt.Errorf("the connection profile has no CA %s", caName)
+			} else {
+				rootCerts, intermediateCerts, msp.Error = profile.fetchCaChain(ctx, ca)
+			}
+		}
+		if len(rootCerts) == 0 && msp.Error == nil {
+			msp.Error = fmt.Errorf("the connection profile has no root certificates of MSP %s, set them with SetMspRootCerts or SetFetchCerts", mspID)
+		}
+		var tlsRootCerts []string
+		for _, nodeName := range append(append([]string{}, organization.Orderers...), organization.Peers...) {
+			node := profile.Peers[nodeName]
+			if node == nil {
+				node = profile.Orderers[nodeName]
+			}
+			if node != nil {
+				certs, _ := profile.certs(node.TlsCACerts)
+				for _, cert := range certs {
+					if !containsString(tlsRootCerts, cert) {
+						tlsRootCerts = append(tlsRootCerts, cert)
+					}
+				}
+			}
+		}
+		if msp.Error == nil {
+			msp.ImportMsp = &ImportMspOptions{
+				MspID:             core.StringPtr(mspID),
+				DisplayName:       core.StringPtr(name),
+				RootCerts:         rootCerts,
+				IntermediateCerts: intermediateCerts,
+				TlsRootCerts:      tlsRootCerts,
+			}
+		}
+
+		for _, caName := range organization.CertificateAuthorities {
+			item := &ConnectionProfileImportItem{Name: caName, Type: ComponentType_FabricCa, MspID: mspID}
+			cas = append(cas, item)
+			ca := profile.CertificateAuthorities[caName]
+			if ca == nil {
+				item.Error = fmt.Errorf("the connection profile has no CA %s", caName)
+				continue
+			}
+			item.ImportCa, item.Error = profile.importCa(ctx, caName, ca, tlscaName, options)
+		}
+
+		for _, group := range []struct {
+			componentType string
+			names         []string
+			nodes         map[string]*ConnectionProfileNode
+			items         *[]*ConnectionProfileImportItem
+		}{
+			{ComponentType_FabricOrderer, organization.Orderers, profile.Orderers, &orderers},
+			{ComponentType_FabricPeer, organization.Peers, profile.Peers, &peers},
+		} {
+			for _, nodeName := range group.names {
+				item := &ConnectionProfileImportItem{Name: nodeName, Type: group.componentType, MspID: mspID}
+				*group.items = append(*group.items, item)
+				node := group.nodes[nodeName]
+				if node == nil {
+					item.Error = fmt.Errorf("the connection profile has no %s %s", strings.TrimPrefix(group.componentType, "fabric-"), nodeName)
+					continue
+				}
+				var msp *MspCryptoField
+				var grpcwpURL string
+				grpcwpURL, msp, item.Error = profile.nodeCrypto(ctx, nodeName, node, rootCerts, options)
+				if item.Error != nil {
+					continue
+				}
+				if group.componentType == ComponentType_FabricPeer {
+					item.ImportPeer = &ImportPeerOptions{
+						DisplayName: core.StringPtr(nodeName),
+						GrpcwpURL:   core.StringPtr(grpcwpURL),
+						Msp:         msp,
+						MspID:       core.StringPtr(mspID),
+						ApiURL:      core.StringPtr(node.URL),
+						Tags:        options.Tags,
+					}
+				} else {
+					item.ImportOrderer = &ImportOrdererOptions{
+						ClusterName: core.StringPtr(clusterName),
+						DisplayName: core.StringPtr(nodeName),
+						GrpcwpURL:   core.StringPtr(grpcwpURL),
+						Msp:         msp,
+						MspID:       core.StringPtr(mspID),
+						ApiURL:      core.StringPtr(node.URL),
+						Tags:        options.Tags,
+					}
+				}
+			}
+		}
+	}
+
+	result := &ConnectionProfileImport{}
+	for _, items := range [][]*ConnectionProfileImportItem{msps, cas, orderers, peers} {
+		result.Items = append(result.Items, items...)
+	}
+	return result, nil
+}
+
+// importCa returns the ImportCa request of a CA. Its TLS certificate is the one of the options, or the TLS CA
+// certificate of the profile when that is a single self-signed certificate, as fabric-ca-server generates.
+func (profile *ConnectionProfile) importCa(ctx context.Context, name string, ca *ConnectionProfileCA, tlscaName string, options *ConnectionProfileImportOptions) (*ImportCaOptions, error) {
+	if ca.URL == "" {
+		return nil, fmt.Errorf("CA %s has no url", name)
+	}
+	tlsCACerts, err := profile.certs(ca.TlsCACerts)
+	if err != nil {
+		return nil, err
+	}
+	tlsCert := options.TlsCerts[name]
+	if tlsCert == "" && len(tlsCACerts) == 1 && isSelfSigned(tlsCACerts[0]) {
+		tlsCert = tlsCACerts[0]
+	}
+	if tlsCert == "" && options.FetchCerts != nil && *options.FetchCerts {
+		tlsCert, err = fetchTlsCert(ctx, ca.URL, tlsCACerts, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if tlsCert == "" {
+		return nil, fmt.Errorf("the connection profile has no TLS certificate of CA %s, set it with SetTlsCert or SetFetchCerts", name)
+	}
+	caName := ca.CaName
+	if caName == "" {
+		caName = name
+	}
+	return &ImportCaOptions{
+		DisplayName: core.StringPtr(name),
+		ApiURL:      core.StringPtr(ca.URL),
+		Msp: &ImportCaBodyMsp{
+			Ca:        &ImportCaBodyMspCa{Name: core.StringPtr(caName)},
+			Tlsca:     &ImportCaBodyMspTlsca{Name: core.StringPtr(tlscaName), RootCerts: tlsCACerts},
+			Component: &ImportCaBodyMspComponent{TlsCert: core.StringPtr(tlsCert)},
+		},
+		TlsCert: core.StringPtr(tlsCert),
+		Tags:    options.Tags,
+	}, nil
+}
+
+// nodeCrypto returns the gRPC web proxy URL and the MSP crypto of a peer or orderer.
+func (profile *ConnectionProfile) nodeCrypto(ctx context.Context, name string, node *ConnectionProfileNode, rootCerts []string, options *ConnectionProfileImportOptions) (grpcwpURL string, msp *MspCryptoField, err error) {
+	if node.URL == "" {
+		return "", nil, fmt.Errorf("%s has no url", name)
+	}
+	grpcwpURL = node.GrpcwpURL
+	if grpcwpURL == "" {
+		grpcwpURL = options.GrpcwpURLs[name]
+	}
+	if grpcwpURL == "" {
+		return "", nil, fmt.Errorf("the connection profile has no grpcwpURL of %s, set it with SetGrpcwpURL", name)
+	}
+	tlsCACerts, err := profile.certs(node.TlsCACerts)
+	if err != nil {
+		return
+	}
+	if len(tlsCACerts) == 0 {
+		return "", nil, fmt.Errorf("the connection profile has no tlsCACerts of %s", name)
+	}
+	tlsCert := options.TlsCerts[name]
+	if tlsCert == "" && options.FetchCerts != nil && *options.FetchCerts {
+		serverName, _ := node.GrpcOptions["ssl-target-name-override"].(string)
+		tlsCert, err = fetchTlsCert(ctx, node.URL, tlsCACerts, serverName)
+		if err != nil {
+			return
+		}
+	}
+	if tlsCert == "" {
+		return "", nil, fmt.Errorf("the connection profile has no TLS certificate of %s, set it with SetTlsCert or SetFetchCerts", name)
+	}
+	msp = &MspCryptoField{
+		Tlsca:     &MspCryptoFieldTlsca{RootCerts: tlsCACerts},
+		Component: &MspCryptoFieldComponent{TlsCert: core.StringPtr(tlsCert)},
+	}
+	if len(rootCerts) > 0 {
+		msp.Ca = &MspCryptoFieldCa{RootCerts: rootCerts}
+	}
+	return
+}
+
+// certs returns the certificates of a tlsCACerts entry, each as base 64 encoded PEM.
+func (profile *ConnectionProfile) certs(certs *ConnectionProfileCerts) (encoded []string, err error) {
+	if certs == nil {
+		return
+	}
+	var data []byte
+	for _, text := range certs.Pem {
+		data = append(append(data, text...), '\n')
+	}
+	if certs.Path != "" {
+		file := certs.Path
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(profile.dir, file)
+		}
+		var content []byte
+		content, err = ioutil.ReadFile(file)
+		if err != nil {
+			return
+		}
+		data = append(data, content...)
+	}
+	return splitPemCerts(data)
+}
+
+// fetchCaChain gets the CA chain of a fabric-ca-server from its cainfo API and returns its self-signed certificates
+// and the others.
+func (profile *ConnectionProfile) fetchCaChain(ctx context.Context, ca *ConnectionProfileCA) (rootCerts []string, intermediateCerts []string, err error) {
+	tlsCACerts, err := profile.certs(ca.TlsCACerts)
+	if err != nil {
+		return
+	}
+	config, err := tlsConfig(tlsCACerts, "")
+	if err != nil {
+		return
+	}
+	client := &http.Client{Timeout: connectionProfileDialTimeout, Transport: &http.Transport{TLSClientConfig: config}}
+	query := url.Values{}
+	if ca.CaName != "" {
+		query.Set("ca", ca.CaName)
+	}
+	request, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(ca.URL, "/")+"/api/v1/cainfo?"+query.Encode(), nil)
+	if err != nil {
+		return
+	}
+	response, err := client.Do(request.WithContext(ctx))
+	if err != nil {
+		return
+	}
+	defer response.Body.Close()
+	var info struct {
+		Success bool
+		Result  struct {
+			CAChain string
+		}
+	}
+	err = json.NewDecoder(response.Body).Decode(&info)
+	if err != nil || !info.Success || response.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("getting the CA chain of %s failed with status %d", ca.URL, response.StatusCode)
+	}
+	chain, err := base64.StdEncoding.DecodeString(info.Result.CAChain)
+	if err != nil {
+		return
+	}
+	certs, err := splitPemCerts(chain)
+	if err != nil {
+		return
+	}
+	for _, cert := range certs {
+		if isSelfSigned(cert) {
+			rootCerts = append(rootCerts, cert)
+		} else {
+			intermediateCerts = append(intermediateCerts, cert)
+		}
+	}
+	return
+}
+
+// fetchTlsCert connects to a node and returns its TLS certificate as base 64 encoded PEM. The connection is verified
+// with tlsCACerts.
+func fetchTlsCert(ctx context.Context, nodeURL string, tlsCACerts []string, serverName string) (string, error) {
+	parsed, err := url.Parse(nodeURL)
+	if err != nil {
+		return "", err
+	}
+	address := parsed.Host
+	if address == "" {
+		// a bare host:port
+		address = nodeURL
+	}
+	if _, _, splitErr := net.SplitHostPort(address); splitErr != nil {
+		address = net.JoinHostPort(address, "443")
+	}
+	config, err := tlsConfig(tlsCACerts, serverName)
+	if err != nil {
+		return "", err
+	}
+	if config.ServerName == "" {
+		config.ServerName, _, _ = net.SplitHostPort(address)
+	}
+	dialer := &net.Dialer{Timeout: connectionProfileDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(connectionProfileDialTimeout))
+	client := tls.Client(conn, config)
+	err = client.Handshake()
+	if err != nil {
+		return "", fmt.Errorf("TLS handshake with %s failed: %s", address, err.Error())
+	}
+	leaf := client.ConnectionState().PeerCertificates[0]
+	return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Raw})), nil
+}
+
+// tlsConfig returns a TLS configuration that trusts the base 64 encoded PEM certificates certs.
+func tlsConfig(certs []string, serverName string) (*tls.Config, error) {
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no TLS CA certificates to verify the connection with")
+	}
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		data, err := base64.StdEncoding.DecodeString(cert)
+		if err != nil {
+			return nil, err
+		}
+		pool.AppendCertsFromPEM(data)
+	}
+	return &tls.Config{RootCAs: pool, ServerName: serverName}, nil
+}
+
+// splitPemCerts returns the certificates of PEM data, each as base 64 encoded PEM.
+func splitPemCerts(data []byte) (certs []string, err error) {
+	for rest := bytes.TrimSpace(data); len(rest) > 0; rest = bytes.TrimSpace(rest) {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil || block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("not a PEM certificate")
+		}
+		certs = append(certs, base64.StdEncoding.EncodeToString(pem.EncodeToMemory(block)))
+	}
+	return
+}
+
+// isSelfSigned reports whether a base 64 encoded PEM certificate is self-signed.
+func isSelfSigned(cert string) bool {
+	data, err := base64.StdEncoding.DecodeString(cert)
+	if err != nil {
+		return false
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return false
+	}
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	return err == nil && bytes.Equal(parsed.RawIssuer, parsed.RawSubject) &&
+		parsed.CheckSignature(parsed.SignatureAlgorithm, parsed.RawTBSCertificate, parsed.Signature) == nil
+}
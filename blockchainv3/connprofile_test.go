@@ -0,0 +1,232 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var _ = Describe(`BlockchainV3 connection profile import`, func() {
+	var testServer *httptest.Server
+	var blockchainService *blockchainv3.BlockchainV3
+	var requests []string
+	var dir string
+	var rootPEM, tlsPEM, nodeTlsPEM string
+	encode := func(cert string) string { return base64.StdEncoding.EncodeToString([]byte(cert)) }
+	indent := func(text string, spaces int) string {
+		return strings.Replace(strings.TrimSpace(text), "\n", "\n"+strings.Repeat(" ", spaces), -1)
+	}
+	profileYAML := func() string {
+		return fmt.Sprintf(`name: partner-network
+version: 1.0.0
+organizations:
+  Org1:
+    mspid: Org1MSP
+    peers:
+      - peer0.org1.example.com
+    certificateAuthorities:
+      - ca.org1.example.com
+  OrdererOrg:
+    mspid: OrdererMSP
+    orderers:
+      - orderer0.example.com
+peers:
+  peer0.org1.example.com:
+    url: grpcs://peer0.org1.example.com:7051
+    grpcwpURL: https://peer0.org1.example.com:7443
+    tlsCACerts:
+      pem: |
+        %s
+orderers:
+  orderer0.example.com:
+    url: grpcs://orderer0.example.com:7050
+    tlsCACerts:
+      path: tls/orderer-ca.pem
+certificateAuthorities:
+  ca.org1.example.com:
+    url: https://ca.org1.example.com:7054
+    caName: ca-org1
+    tlsCACerts:
+      pem:
+        - |
+          %s
+`, indent(tlsPEM, 8), indent(rootPEM, 10))
+	}
+
+	BeforeEach(func() {
+		requests = nil
+		rootPEM, tlsPEM, nodeTlsPEM = testCertificate("ca-org1"), testCertificate("tlsca"), testCertificate("peer0")
+		var err error
+		dir, err = ioutil.TempDir("", "profile")
+		Expect(err).To(BeNil())
+		Expect(os.MkdirAll(filepath.Join(dir, "tls"), 0755)).To(BeNil())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "tls", "orderer-ca.pem"), []byte(tlsPEM), 0644)).To(BeNil())
+
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			res.Header().Set("Content-type", "application/json")
+			op, ok := blockchainv3.LookupOperation(req.Method, req.URL.Path)
+			Expect(ok).To(BeTrue())
+			var body map[string]interface{}
+			Expect(json.NewDecoder(req.Body).Decode(&body)).To(BeNil())
+			requests = append(requests, fmt.Sprintf("%s %v", op.Name, body["display_name"]))
+			fmt.Fprintf(res, `{"id": "id-%d"}`, len(requests))
+		}))
+		blockchainService, err = blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+		os.RemoveAll(dir)
+	})
+
+	load := func() *blockchainv3.ConnectionProfile {
+		file := filepath.Join(dir, "profile.yaml")
+		Expect(ioutil.WriteFile(file, []byte(profileYAML()), 0644)).To(BeNil())
+		profile, err := blockchainv3.LoadConnectionProfile(file)
+		Expect(err).To(BeNil())
+		return profile
+	}
+	complete := func() *blockchainv3.ConnectionProfileImportOptions {
+		return blockchainService.NewConnectionProfileImportOptions().
+			SetMspRootCerts("Org1MSP", []string{encode(rootPEM)}).
+			SetMspRootCerts("OrdererMSP", []string{encode(rootPEM)}).
+			SetTlsCert("peer0.org1.example.com", encode(nodeTlsPEM)).
+			SetTlsCert("orderer0.example.com", encode(nodeTlsPEM)).
+			SetGrpcwpURL("orderer0.example.com", "https://orderer0.example.com:7443").
+			SetClusterName("Partner OS")
+	}
+
+	It(`Previews the requests of a YAML profile`, func() {
+		result, err := blockchainService.ImportConnectionProfile(context.Background(), load(), complete().SetPreview(true))
+		Expect(err).To(BeNil())
+		Expect(requests).To(BeEmpty())
+		var names []string
+		for _, item := range result.Items {
+			Expect(item.Error).To(BeNil())
+			names = append(names, item.Type+" "+item.Name)
+		}
+		Expect(names).To(Equal([]string{"msp OrdererMSP", "msp Org1MSP", "fabric-ca ca.org1.example.com", "fabric-orderer orderer0.example.com", "fabric-peer peer0.org1.example.com"}))
+
+		msp := result.Items[1].ImportMsp
+		Expect(*msp.DisplayName).To(Equal("Org1"))
+		Expect(msp.TlsRootCerts).To(Equal([]string{encode(tlsPEM)}))
+
+		ca := result.Items[2].ImportCa
+		Expect(*ca.ApiURL).To(Equal("https://ca.org1.example.com:7054"))
+		Expect(*ca.Msp.Ca.Name).To(Equal("ca-org1"))
+		Expect(*ca.Msp.Tlsca.Name).To(Equal("tlsca"))
+		// the self-signed TLS CA certificate of the CA is its TLS certificate
+		Expect(*ca.TlsCert).To(Equal(encode(rootPEM)))
+
+		orderer := result.Items[3].ImportOrderer
+		Expect(*orderer.ClusterName).To(Equal("Partner OS"))
+		Expect(*orderer.GrpcwpURL).To(Equal("https://orderer0.example.com:7443"))
+		Expect(orderer.Msp.Tlsca.RootCerts).To(Equal([]string{encode(tlsPEM)}))
+
+		peer := result.Items[4].ImportPeer
+		Expect(*peer.ApiURL).To(Equal("grpcs://peer0.org1.example.com:7051"))
+		Expect(*peer.GrpcwpURL).To(Equal("https://peer0.org1.example.com:7443"))
+		Expect(*peer.MspID).To(Equal("Org1MSP"))
+		Expect(*peer.Msp.Component.TlsCert).To(Equal(encode(nodeTlsPEM)))
+		Expect(peer.Msp.Ca.RootCerts).To(Equal([]string{encode(rootPEM)}))
+	})
+	It(`Lists what is missing and imports nothing`, func() {
+		options := blockchainService.NewConnectionProfileImportOptions().SetOrganizations([]string{"Org1"})
+		result, err := blockchainService.ImportConnectionProfile(context.Background(), load(), options)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(HavePrefix("2 components of the connection profile cannot be imported: Org1MSP: the connection profile has no root certificates of MSP Org1MSP"))
+		Expect(result.Errors()).To(HaveLen(2))
+		Expect(result.Errors()[1].Error.Error()).To(Equal("the connection profile has no TLS certificate of peer0.org1.example.com, set it with SetTlsCert or SetFetchCerts"))
+		Expect(requests).To(BeEmpty())
+
+		_, err = blockchainService.ImportConnectionProfile(context.Background(), load(), options.SetOrganizations([]string{"Org2"}))
+		Expect(err).ToNot(BeNil())
+	})
+	It(`Imports a JSON profile`, func() {
+		data, err := json.Marshal(map[string]interface{}{
+			"name": "partner",
+			"organizations": map[string]interface{}{
+				"Org1": map[string]interface{}{"mspid": "Org1MSP", "peers": []string{"peer0.org1.example.com"}},
+			},
+			"peers": map[string]interface{}{
+				"peer0.org1.example.com": map[string]interface{}{
+					"url": "grpcs://peer0.org1.example.com:7051", "grpcwpURL": "https://peer0.org1.example.com:7443",
+					"tlsCACerts": map[string]interface{}{"pem": []string{tlsPEM}},
+				},
+			},
+		})
+		Expect(err).To(BeNil())
+		profile, err := blockchainv3.ParseConnectionProfile(data)
+		Expect(err).To(BeNil())
+		result, err := blockchainService.ImportConnectionProfile(context.Background(), profile, complete())
+		Expect(err).To(BeNil())
+		Expect(requests).To(Equal([]string{"ImportMsp Org1", "ImportPeer peer0.org1.example.com"}))
+		Expect(result.Items[0].ID).To(Equal("id-1"))
+		Expect(result.Items[1].ID).To(Equal("id-2"))
+	})
+	It(`Fetches the certificates profiles do not have`, func() {
+		var chain string
+		tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			Expect(req.URL.Path).To(Equal("/api/v1/cainfo"))
+			Expect(req.URL.Query().Get("ca")).To(Equal("ca-org1"))
+			fmt.Fprintf(res, `{"success": true, "result": {"CAName": "ca-org1", "CAChain": "%s"}}`, chain)
+		}))
+		defer tlsServer.Close()
+		serverPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw}))
+		chain = encode(rootPEM)
+
+		profile, err := blockchainv3.ParseConnectionProfile([]byte(fmt.Sprintf(`{
+			"organizations": {"Org1": {"mspid": "Org1MSP", "peers": ["peer0"], "certificateAuthorities": ["ca"]}},
+			"peers": {"peer0": {"url": "%s", "grpcwpURL": "https://proxy", "tlsCACerts": {"pem": %q}}},
+			"certificateAuthorities": {"ca": {"url": "%s", "caName": "ca-org1", "tlsCACerts": {"pem": %q}}}
+		}`, strings.Replace(tlsServer.URL, "https", "grpcs", 1), serverPEM, tlsServer.URL, serverPEM)))
+		Expect(err).To(BeNil())
+		options := blockchainService.NewConnectionProfileImportOptions().SetFetchCerts(true).SetPreview(true)
+		result, err := blockchainService.ImportConnectionProfile(context.Background(), profile, options)
+		Expect(err).To(BeNil())
+		Expect(result.Errors()).To(BeEmpty())
+		Expect(result.Items[0].ImportMsp.RootCerts).To(Equal([]string{encode(rootPEM)}))
+		Expect(*result.Items[1].ImportCa.TlsCert).To(Equal(encode(serverPEM)))
+		Expect(*result.Items[2].ImportPeer.Msp.Component.TlsCert).To(Equal(encode(serverPEM)))
+
+		// the connection is verified with the TLS CA certificates of the profile
+		profile.Peers["peer0"].TlsCACerts.Pem = blockchainv3.PemList{tlsPEM}
+		result, err = blockchainService.ImportConnectionProfile(context.Background(), profile, options)
+		Expect(err).To(BeNil())
+		Expect(result.Errors()).To(HaveLen(1))
+		Expect(result.Errors()[0].Error.Error()).To(HavePrefix("TLS handshake with 127.0.0.1:"))
+	})
+})
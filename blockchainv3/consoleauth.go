@@ -0,0 +1,171 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/IBM/go-sdk-core/v4/core"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// ConsoleAuthError : The console rejected a request with 401 Unauthorized or 403 Forbidden. A 401 means that the
+// credential is wrong: the API key or user does not exist, the secret or password is wrong, or the key was deleted. A
+// 403 means that the credential is valid but its console role does not permit the request.
+type ConsoleAuthError struct {
+	// 401 or 403.
+	StatusCode int
+
+	// The credential, without its secret, e.g. `username "abc"` for a core.BasicAuthenticator.
+	Credential string
+
+	// The method and path of the rejected request.
+	Method string
+
+	Path string
+
+	// The message of the console, if any.
+	Message string
+}
+
+// Error implements the error interface.
+func (authErr *ConsoleAuthError) Error() string {
+	var text string
+	if authErr.StatusCode == http.StatusUnauthorized {
+		text = fmt.Sprintf("the console did not accept the %s (401 Unauthorized); check that it exists and that its secret or password is correct", authErr.Credential)
+	} else {
+		text = fmt.Sprintf("the %s is not allowed to %s %s (403 Forbidden); it needs a console role that permits the request", authErr.Credential, authErr.Method, authErr.Path)
+	}
+	if authErr.Message != "" {
+		text += ": " + authErr.Message
+	}
+	return text
+}
+
+// GetConsoleAuthError returns the 401 or 403 response of the console behind err. The boolean is false if err was not
+// produced by a client with EnableConsoleAuthErrors, or not by a 401 or 403.
+func GetConsoleAuthError(err error) (authErr *ConsoleAuthError, ok bool) {
+	ok = errors.As(err, &authErr)
+	return
+}
+
+// EnableConsoleAuthErrors makes the operations of this client return a *ConsoleAuthError, see GetConsoleAuthError,
+// when the console responds with 401 or 403, instead of the console's message alone.
+//
+// Software (OpenShift or Kubernetes) consoles do not use IAM. They accept console API keys and the username and
+// password of console users, both with basic auth: use a core.BasicAuthenticator, with an API key as the username
+// and its secret as the password. NewBlockchainV3UsingExternalConfig loads it from the external configuration, e.g.
+//
+//	BLOCKCHAIN_AUTH_TYPE=basic
+//	BLOCKCHAIN_USERNAME=<API key or username>
+//	BLOCKCHAIN_PASSWORD=<API secret or password>
+func (blockchain *BlockchainV3) EnableConsoleAuthErrors() {
+	if layer := blockchain.findTransportLayer(isConsoleAuthTransport); layer != nil {
+		if layer.(*consoleAuthTransport).options == blockchain.Service.Options {
+			return
+		}
+		// installed by the client this one was cloned from, which has its own options
+		blockchain.removeTransportLayer(isConsoleAuthTransport)
+	}
+	blockchain.addTransportLayer(&consoleAuthTransport{options: blockchain.Service.Options})
+}
+
+// DisableConsoleAuthErrors undoes EnableConsoleAuthErrors.
+func (blockchain *BlockchainV3) DisableConsoleAuthErrors() {
+	blockchain.removeTransportLayer(isConsoleAuthTransport)
+}
+
+func isConsoleAuthTransport(layer transportLayer) bool {
+	_, ok := layer.(*consoleAuthTransport)
+	return ok
+}
+
+// consoleAuthTransport is the transport layer that turns 401 and 403 responses into a *ConsoleAuthError.
+type consoleAuthTransport struct {
+	transport http.RoundTripper
+	options   *core.ServiceOptions
+}
+
+func (layer *consoleAuthTransport) rank() int {
+	return transportRankConsoleAuth
+}
+
+func (layer *consoleAuthTransport) next() http.RoundTripper {
+	return layer.transport
+}
+
+func (layer *consoleAuthTransport) setNext(next http.RoundTripper) {
+	layer.transport = next
+}
+
+func (layer *consoleAuthTransport) clone() transportLayer {
+	copied := *layer
+	return &copied
+}
+
+// RoundTrip forwards req and returns a *ConsoleAuthError in place of a 401 or 403 response.
+func (layer *consoleAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := layer.transport.RoundTrip(req)
+	if err != nil || (resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden) {
+		return resp, err
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	return nil, &ConsoleAuthError{
+		StatusCode: resp.StatusCode,
+		Credential: layer.credential(),
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		Message:    consoleErrorMessage(body),
+	}
+}
+
+// credential describes the credential of the authenticator of the client, which may have been replaced since the layer
+// was installed. Basic auth credentials, console API keys or users, are described by their username.
+func (layer *consoleAuthTransport) credential() string {
+	authenticator := layer.options.Authenticator
+	if skipping, ok := authenticator.(*skippingAuthenticator); ok {
+		authenticator = skipping.Authenticator
+	}
+	if basic, ok := authenticator.(*core.BasicAuthenticator); ok {
+		return fmt.Sprintf("username %q", basic.Username)
+	}
+	return "credentials"
+}
+
+// consoleErrorMessage returns the message of an error response of the console, which is either a JSON object with a
+// message or a plain text.
+func consoleErrorMessage(body []byte) string {
+	var response map[string]interface{}
+	if json.Unmarshal(body, &response) != nil {
+		return strings.TrimSpace(string(body))
+	}
+	for _, key := range []string{"message", "msg", "error", "reason"} {
+		if message, ok := response[key].(string); ok && message != "" {
+			return message
+		}
+	}
+	if messages, ok := response["msgs"].([]interface{}); ok && len(messages) > 0 {
+		if message, ok := messages[0].(string); ok {
+			return message
+		}
+	}
+	return ""
+}
@@ -0,0 +1,116 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"os"
+)
+
+var _ = Describe(`BlockchainV3 console authenticator`, func() {
+	var testServer *httptest.Server
+
+	BeforeEach(func() {
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			res.Header().Set("Content-type", "application/json")
+			user, password, ok := req.BasicAuth()
+			switch {
+			case !ok || (user == "key1" && password != "secret1") || (user == "admin" && password != "passw0rd"):
+				res.WriteHeader(401)
+				fmt.Fprint(res, `{"statusCode": 401, "msg": "invalid credentials"}`)
+			case req.Method != http.MethodGet && user != "admin":
+				res.WriteHeader(403)
+				fmt.Fprint(res, `{"statusCode": 403, "msgs": ["missing the \"blockchain.components.delete\" permission"]}`)
+			case req.Method == http.MethodGet:
+				fmt.Fprint(res, `{"status": "ok"}`)
+			default:
+				fmt.Fprint(res, `{"message": "ok", "deleted": []}`)
+			}
+		}))
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Loads basic auth credentials from the external configuration`, func() {
+		for name, value := range map[string]string{
+			"CONSOLE_AUTH_TEST_AUTH_TYPE": "basic",
+			"CONSOLE_AUTH_TEST_URL":       testServer.URL,
+			"CONSOLE_AUTH_TEST_USERNAME":  "key1",
+			"CONSOLE_AUTH_TEST_PASSWORD":  "secret1",
+		} {
+			os.Setenv(name, value)
+			defer os.Unsetenv(name)
+		}
+		service, err := blockchainv3.NewBlockchainV3UsingExternalConfig(&blockchainv3.BlockchainV3Options{ServiceName: "console_auth_test"})
+		Expect(err).To(BeNil())
+		service.EnableConsoleAuthErrors()
+		Expect(service.GetServiceURL()).To(Equal(testServer.URL))
+		Expect(service.Service.Options.Authenticator).To(Equal(&core.BasicAuthenticator{Username: "key1", Password: "secret1"}))
+		_, _, err = service.GetHealth(service.NewGetHealthOptions())
+		Expect(err).To(BeNil())
+	})
+	It(`Tells 401 from 403`, func() {
+		authenticator, err := core.NewBasicAuthenticator("key1", "wrong")
+		Expect(err).To(BeNil())
+		service, err := blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{URL: testServer.URL, Authenticator: authenticator})
+		Expect(err).To(BeNil())
+		service.EnableConsoleAuthErrors()
+
+		_, _, err = service.GetHealth(service.NewGetHealthOptions())
+		authErr, ok := blockchainv3.GetConsoleAuthError(err)
+		Expect(ok).To(BeTrue())
+		Expect(authErr.StatusCode).To(Equal(401))
+		Expect(authErr.Error()).To(Equal(`the console did not accept the username "key1" (401 Unauthorized); check that it exists and that its secret or password is correct: invalid credentials`))
+
+		authenticator.Password = "secret1"
+		service.EnableDryRun()
+		service.DisableDryRun()
+		_, _, err = service.DeleteComponentsByTagWithContext(context.Background(), service.NewDeleteComponentsByTagOptions("dev"))
+		authErr, ok = blockchainv3.GetConsoleAuthError(err)
+		Expect(ok).To(BeTrue())
+		Expect(authErr.StatusCode).To(Equal(403))
+		Expect(authErr.Path).To(Equal("/ak/api/v3/kubernetes/components/tags/dev"))
+		Expect(authErr.Error()).To(Equal(`the username "key1" is not allowed to DELETE /ak/api/v3/kubernetes/components/tags/dev (403 Forbidden); it needs a console role that permits the request: missing the "blockchain.components.delete" permission`))
+
+		admin, err := core.NewBasicAuthenticator("admin", "passw0rd")
+		Expect(err).To(BeNil())
+		service.Service.Options.Authenticator = admin
+		_, _, err = service.DeleteComponentsByTag(service.NewDeleteComponentsByTagOptions("dev"))
+		Expect(err).To(BeNil())
+		admin.Password = "wrong"
+		_, _, err = service.GetHealth(service.NewGetHealthOptions())
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring(`the console did not accept the username "admin" (401 Unauthorized)`))
+
+		service.DisableConsoleAuthErrors()
+		_, _, err = service.GetHealth(service.NewGetHealthOptions())
+		Expect(err).ToNot(BeNil())
+		_, ok = blockchainv3.GetConsoleAuthError(err)
+		Expect(ok).To(BeFalse())
+		// the core only reads the console's "message" field
+		Expect(err.Error()).To(Equal("Unauthorized"))
+	})
+})
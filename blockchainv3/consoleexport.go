@@ -0,0 +1,478 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/IBM/go-sdk-core/v4/core"
+	"io"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ConsoleExport : The components of an export of the IBP console. The console exports single components as JSON
+// files, ordering services as JSON arrays of their nodes and "export all" as zip bundles of such files. Each component
+// is held as the options that import it into a console.
+type ConsoleExport struct {
+	Cas []*ImportCaOptions
+
+	Msps []*ImportMspOptions
+
+	Orderers []*ImportOrdererOptions
+
+	Peers []*ImportPeerOptions
+
+	// The files of a bundle that hold no components, such as exported identities.
+	Skipped []string
+}
+
+// consoleExportZip is how zip files start.
+var consoleExportZip = []byte("PK\x03\x04")
+
+// ParseConsoleExport : Parse a JSON file or a zip bundle exported by the IBP console
+func ParseConsoleExport(data []byte) (export *ConsoleExport, err error) {
+	export = &ConsoleExport{}
+	if bytes.HasPrefix(data, consoleExportZip) {
+		err = export.addBundle(data)
+	} else {
+		var skipped bool
+		skipped, err = export.addFile("", data)
+		if err == nil && skipped {
+			err = fmt.Errorf("the export has no components")
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// ReadConsoleExport : Read a JSON file or a zip bundle exported by the IBP console
+func ReadConsoleExport(file string) (*ConsoleExport, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConsoleExport(data)
+}
+
+// addBundle adds the components of every JSON file of a zip bundle.
+func (export *ConsoleExport) addBundle(data []byte) error {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		if !strings.EqualFold(path.Ext(file.Name), ".json") {
+			export.Skipped = append(export.Skipped, file.Name)
+			continue
+		}
+		content, err := file.Open()
+		if err != nil {
+			return err
+		}
+		fileData, err := ioutil.ReadAll(content)
+		content.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %s", file.Name, err.Error())
+		}
+		skipped, err := export.addFile(file.Name, fileData)
+		if err != nil {
+			return err
+		}
+		if skipped {
+			export.Skipped = append(export.Skipped, file.Name)
+		}
+	}
+	return nil
+}
+
+// addFile adds the components of an exported JSON file, which holds a component or an array of components. It
+// reports files without components, such as exported identities.
+func (export *ConsoleExport) addFile(name string, data []byte) (skipped bool, err error) {
+	prefix := ""
+	if name != "" {
+		prefix = name + ": "
+	}
+	var components []map[string]json.RawMessage
+	if data = bytes.TrimSpace(data); bytes.HasPrefix(data, []byte("[")) {
+		err = json.Unmarshal(data, &components)
+	} else {
+		var component map[string]json.RawMessage
+		err = json.Unmarshal(data, &component)
+		components = append(components, component)
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s%s", prefix, err.Error())
+	}
+	skipped = true
+	for i, component := range components {
+		var componentType string
+		if component["type"] != nil {
+			json.Unmarshal(component["type"], &componentType)
+		}
+		label := prefix
+		if len(components) > 1 {
+			label = fmt.Sprintf("%scomponent %d: ", prefix, i)
+		}
+		var options interface{}
+		switch componentType {
+		case ComponentType_FabricCa:
+			ca := &ImportCaOptions{}
+			export.Cas = append(export.Cas, ca)
+			options = ca
+		case ComponentType_FabricOrderer:
+			orderer := &ImportOrdererOptions{}
+			export.Orderers = append(export.Orderers, orderer)
+			options = orderer
+		case ComponentType_FabricPeer:
+			peer := &ImportPeerOptions{}
+			export.Peers = append(export.Peers, peer)
+			options = peer
+		case ComponentType_Msp:
+			msp := &ImportMspOptions{}
+			export.Msps = append(export.Msps, msp)
+			options = msp
+		default:
+			continue
+		}
+		skipped = false
+		// the options hold no ids, timestamps and other fields of the console
+		delete(component, "Headers")
+		componentData, _ := json.Marshal(component)
+		err = json.Unmarshal(componentData, options)
+		if err != nil {
+			return false, fmt.Errorf("%s%s", label, err.Error())
+		}
+		if ca, ok := options.(*ImportCaOptions); ok && ca.TlsCert == nil && ca.Msp != nil && ca.Msp.Component != nil {
+			ca.TlsCert = ca.Msp.Component.TlsCert
+		}
+		err = core.ValidateStruct(options, label+componentType)
+		if err != nil {
+			return false, err
+		}
+	}
+	return
+}
+
+// NewConsoleExport : Instantiate ConsoleExport with the components of GetComponent responses. GetComponent responses
+// of MSPs have no certificates, add those with AddMsp.
+func NewConsoleExport(components []*GenericComponentResponse) (export *ConsoleExport, err error) {
+	export = &ConsoleExport{}
+	for _, component := range components {
+		err = export.AddComponent(component)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// AddComponent : Add the peer, orderer or CA of a GetComponent response
+func (export *ConsoleExport) AddComponent(component *GenericComponentResponse) error {
+	componentType, id := "", ""
+	if component.Type != nil {
+		componentType = *component.Type
+	}
+	if component.ID != nil {
+		id = *component.ID
+	}
+	msp := component.Msp
+	if msp == nil {
+		msp = &GenericComponentResponseMsp{}
+	}
+	var tlsCert *string
+	if msp.Component != nil {
+		tlsCert = msp.Component.TlsCert
+	}
+	var options interface{}
+	switch componentType {
+	case ComponentType_FabricCa:
+		ca := &ImportCaOptions{
+			DisplayName:   component.DisplayName,
+			ApiURL:        component.ApiURL,
+			Location:      component.Location,
+			OperationsURL: component.OperationsURL,
+			Tags:          component.Tags,
+			TlsCert:       tlsCert,
+			Msp: &ImportCaBodyMsp{
+				Ca:        &ImportCaBodyMspCa{},
+				Tlsca:     &ImportCaBodyMspTlsca{},
+				Component: &ImportCaBodyMspComponent{TlsCert: tlsCert},
+			},
+		}
+		if msp.Ca != nil {
+			ca.Msp.Ca.Name, ca.Msp.Ca.RootCerts = msp.Ca.Name, msp.Ca.RootCerts
+		}
+		if msp.Tlsca != nil {
+			ca.Msp.Tlsca.Name, ca.Msp.Tlsca.RootCerts = msp.Tlsca.Name, msp.Tlsca.RootCerts
+		}
+		options = ca
+	case ComponentType_FabricOrderer:
+		orderer := &ImportOrdererOptions{
+			ClusterName:   component.ClusterName,
+			DisplayName:   component.DisplayName,
+			GrpcwpURL:     component.GrpcwpURL,
+			Msp:           newMspCryptoField(msp),
+			MspID:         component.MspID,
+			ApiURL:        component.ApiURL,
+			ClusterID:     component.ClusterID,
+			Location:      component.Location,
+			OperationsURL: component.OperationsURL,
+			Tags:          component.Tags,
+		}
+		options = orderer
+	case ComponentType_FabricPeer:
+		peer := &ImportPeerOptions{
+			DisplayName:   component.DisplayName,
+			GrpcwpURL:     component.GrpcwpURL,
+			Msp:           newMspCryptoField(msp),
+			MspID:         component.MspID,
+			ApiURL:        component.ApiURL,
+			Location:      component.Location,
+			OperationsURL: component.OperationsURL,
+			Tags:          component.Tags,
+		}
+		options = peer
+	case ComponentType_Msp:
+		return fmt.Errorf("GetComponent responses of MSPs have no certificates, add MSP %s with AddMsp", id)
+	default:
+		return fmt.Errorf("component %s is of type %q, which the console does not export", id, componentType)
+	}
+	err := core.ValidateStruct(options, "component "+id)
+	if err != nil {
+		return err
+	}
+	switch options := options.(type) {
+	case *ImportCaOptions:
+		export.Cas = append(export.Cas, options)
+	case *ImportOrdererOptions:
+		export.Orderers = append(export.Orderers, options)
+	case *ImportPeerOptions:
+		export.Peers = append(export.Peers, options)
+	}
+	return nil
+}
+
+// newMspCryptoField returns the crypto of a node of a GetComponent response the way imports take it.
+func newMspCryptoField(msp *GenericComponentResponseMsp) *MspCryptoField {
+	crypto := &MspCryptoField{Tlsca: &MspCryptoFieldTlsca{}, Component: &MspCryptoFieldComponent{}}
+	if msp.Ca != nil {
+		crypto.Ca = &MspCryptoFieldCa{Name: msp.Ca.Name, RootCerts: msp.Ca.RootCerts}
+	}
+	if msp.Tlsca != nil {
+		crypto.Tlsca.Name, crypto.Tlsca.RootCerts = msp.Tlsca.Name, msp.Tlsca.RootCerts
+	}
+	if msp.Component != nil {
+		crypto.Component.TlsCert = msp.Component.TlsCert
+		crypto.Component.Ecert = msp.Component.Ecert
+		crypto.Component.AdminCerts = msp.Component.AdminCerts
+	}
+	return crypto
+}
+
+// AddMsp : Add an MSP definition
+func (export *ConsoleExport) AddMsp(msp *MspResponse) error {
+	options := &ImportMspOptions{
+		MspID:             msp.MspID,
+		DisplayName:       msp.DisplayName,
+		RootCerts:         msp.RootCerts,
+		IntermediateCerts: msp.IntermediateCerts,
+		Admins:            msp.Admins,
+		TlsRootCerts:      msp.TlsRootCerts,
+	}
+	id := ""
+	if msp.ID != nil {
+		id = *msp.ID
+	}
+	err := core.ValidateStruct(options, "MSP "+id)
+	if err != nil {
+		return err
+	}
+	export.Msps = append(export.Msps, options)
+	return nil
+}
+
+// ExportComponents : Export components of the console
+// Get the components with the ids and add them to a ConsoleExport.
+func (blockchain *BlockchainV3) ExportComponents(ctx context.Context, ids []string) (export *ConsoleExport, err error) {
+	export = &ConsoleExport{}
+	for _, id := range ids {
+		// GenericComponentResponse has no certificates of MSP definitions
+		var rawComponent map[string]json.RawMessage
+		rawComponent, err = blockchain.getRawComponent(ctx, id, nil)
+		if err != nil {
+			return nil, err
+		}
+		var component *GenericComponentResponse
+		err = UnmarshalGenericComponentResponse(rawComponent, &component)
+		if err != nil {
+			return nil, err
+		}
+		if component.Type != nil && *component.Type == ComponentType_Msp {
+			var msp *MspResponse
+			err = UnmarshalMspResponse(rawComponent, &msp)
+			if err == nil {
+				err = export.AddMsp(msp)
+			}
+		} else {
+			err = export.AddComponent(component)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// consoleExportFile is a file of an exported bundle.
+type consoleExportFile struct {
+	name       string
+	components []map[string]interface{}
+	// whether the file holds an array, as files of ordering services do
+	array bool
+}
+
+// files returns the files of the export the way the console writes them: a file for every component, except the
+// nodes of an ordering service, which share a file.
+func (export *ConsoleExport) files() (files []*consoleExportFile, err error) {
+	used := map[string]bool{}
+	add := func(name string, component map[string]interface{}) *consoleExportFile {
+		name = consoleExportFileName.ReplaceAllString(name, "_")
+		// a suffixed name may be the name of another component, e.g. "a_1"
+		for base, i := name, 1; used[name]; i++ {
+			name = fmt.Sprintf("%s_%d", base, i)
+		}
+		used[name] = true
+		file := &consoleExportFile{name: name + ".json", components: []map[string]interface{}{component}}
+		files = append(files, file)
+		return file
+	}
+	clusters := map[string]*consoleExportFile{}
+	for _, msp := range export.Msps {
+		component, err := consoleExportComponent(msp, ComponentType_Msp)
+		if err != nil {
+			return nil, err
+		}
+		add(core.StringNilMapper(msp.DisplayName)+"_msp", component)
+	}
+	for _, ca := range export.Cas {
+		component, err := consoleExportComponent(ca, ComponentType_FabricCa)
+		if err != nil {
+			return nil, err
+		}
+		add(core.StringNilMapper(ca.DisplayName)+"_ca", component)
+	}
+	for _, orderer := range export.Orderers {
+		component, err := consoleExportComponent(orderer, ComponentType_FabricOrderer)
+		if err != nil {
+			return nil, err
+		}
+		cluster := core.StringNilMapper(orderer.ClusterID)
+		if cluster == "" {
+			cluster = core.StringNilMapper(orderer.ClusterName)
+		}
+		if file := clusters[cluster]; file != nil {
+			file.components = append(file.components, component)
+			continue
+		}
+		clusters[cluster] = add(core.StringNilMapper(orderer.ClusterName)+"_orderer", component)
+		clusters[cluster].array = true
+	}
+	for _, peer := range export.Peers {
+		component, err := consoleExportComponent(peer, ComponentType_FabricPeer)
+		if err != nil {
+			return nil, err
+		}
+		add(core.StringNilMapper(peer.DisplayName)+"_peer", component)
+	}
+	return
+}
+
+// consoleExportFileName matches what file names of bundles do not hold.
+var consoleExportFileName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// consoleExportComponent returns the import options of a component as the console exports it.
+func consoleExportComponent(options interface{}, componentType string) (component map[string]interface{}, err error) {
+	data, err := json.Marshal(options)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &component)
+	if err != nil {
+		return
+	}
+	delete(component, "Headers")
+	component["type"] = componentType
+	return
+}
+
+// WriteJSON : Write the export as a JSON file
+// A single component is written as an object, several components as an array.
+func (export *ConsoleExport) WriteJSON(writer io.Writer) error {
+	files, err := export.files()
+	if err != nil {
+		return err
+	}
+	var components []map[string]interface{}
+	for _, file := range files {
+		components = append(components, file.components...)
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "    ")
+	if len(components) == 1 {
+		return encoder.Encode(components[0])
+	}
+	return encoder.Encode(components)
+}
+
+// WriteBundle : Write the export as a zip bundle
+// Every component has its own file, except the nodes of an ordering service, which share a file like the console
+// exports them.
+func (export *ConsoleExport) WriteBundle(writer io.Writer) error {
+	files, err := export.files()
+	if err != nil {
+		return err
+	}
+	bundle := zip.NewWriter(writer)
+	for _, file := range files {
+		fileWriter, err := bundle.Create(file.name)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(fileWriter)
+		encoder.SetIndent("", "    ")
+		if !file.array {
+			err = encoder.Encode(file.components[0])
+		} else {
+			err = encoder.Encode(file.components)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return bundle.Close()
+}
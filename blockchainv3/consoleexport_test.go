@@ -0,0 +1,216 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+const consoleExportTestPeer = `{
+	"id": "org1peer1",
+	"display_name": "Org1 Peer",
+	"grpcwp_url": "https://org1peer1-proxy.example.com:443",
+	"api_url": "grpcs://org1peer1.example.com:443",
+	"operations_url": "https://org1peer1-operations.example.com:443",
+	"msp_id": "org1",
+	"type": "fabric-peer",
+	"location": "ibm_saas",
+	"timestamp": 1537262855753,
+	"msp": {
+		"ca": {"name": "ca", "root_certs": ["cm9vdA=="]},
+		"tlsca": {"name": "tlsca", "root_certs": ["dGxz"]},
+		"component": {"tls_cert": "Y2VydA==", "ecert": "ZWNlcnQ=", "admin_certs": ["YWRtaW4="]}
+	}
+}`
+
+const consoleExportTestOrderers = `[
+	{"display_name": "OS 1", "cluster_id": "os", "cluster_name": "OS", "grpcwp_url": "https://os1-proxy:443", "msp_id": "osmsp",
+	 "type": "fabric-orderer", "msp": {"tlsca": {"root_certs": ["dGxz"]}, "component": {"tls_cert": "Y2VydDE="}}},
+	{"display_name": "OS 2", "cluster_id": "os", "cluster_name": "OS", "grpcwp_url": "https://os2-proxy:443", "msp_id": "osmsp",
+	 "type": "fabric-orderer", "msp": {"tlsca": {"root_certs": ["dGxz"]}, "component": {"tls_cert": "Y2VydDI="}}}
+]`
+
+const consoleExportTestMsp = `{"display_name": "Org 1", "msp_id": "org1", "type": "msp", "root_certs": ["cm9vdA=="], "admins": ["YWRtaW4="],
+	"tls_root_certs": ["dGxz"], "fabric_node_ous": {"enable": true}}`
+
+const consoleExportTestCa = `{"display_name": "Org1 CA", "api_url": "https://org1ca.example.com:443", "type": "fabric-ca",
+	"msp": {"ca": {"name": "ca"}, "tlsca": {"name": "tlsca"}, "component": {"tls_cert": "Y2VydA=="}}}`
+
+var _ = Describe(`BlockchainV3 console exports`, func() {
+	bundle := func(files map[string]string) []byte {
+		var buffer bytes.Buffer
+		writer := zip.NewWriter(&buffer)
+		for name, content := range files {
+			file, err := writer.Create(name)
+			Expect(err).To(BeNil())
+			_, err = file.Write([]byte(content))
+			Expect(err).To(BeNil())
+		}
+		Expect(writer.Close()).To(BeNil())
+		return buffer.Bytes()
+	}
+
+	It(`Parses exported components`, func() {
+		export, err := blockchainv3.ParseConsoleExport([]byte(consoleExportTestPeer))
+		Expect(err).To(BeNil())
+		Expect(export.Peers).To(HaveLen(1))
+		peer := export.Peers[0]
+		Expect(*peer.DisplayName).To(Equal("Org1 Peer"))
+		Expect(*peer.MspID).To(Equal("org1"))
+		Expect(*peer.Location).To(Equal("ibm_saas"))
+		Expect(*peer.Msp.Component.Ecert).To(Equal("ZWNlcnQ="))
+		Expect(peer.Msp.Ca.RootCerts).To(Equal([]string{"cm9vdA=="}))
+
+		export, err = blockchainv3.ParseConsoleExport([]byte(consoleExportTestOrderers))
+		Expect(err).To(BeNil())
+		Expect(export.Orderers).To(HaveLen(2))
+		Expect(*export.Orderers[1].ClusterID).To(Equal("os"))
+		Expect(*export.Orderers[1].Msp.Component.TlsCert).To(Equal("Y2VydDI="))
+
+		export, err = blockchainv3.ParseConsoleExport([]byte(consoleExportTestCa))
+		Expect(err).To(BeNil())
+		Expect(*export.Cas[0].TlsCert).To(Equal("Y2VydA=="))
+
+		_, err = blockchainv3.ParseConsoleExport([]byte(`{"name": "admin", "private_key": "a2V5", "cert": "Y2VydA=="}`))
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("the export has no components"))
+
+		_, err = blockchainv3.ParseConsoleExport([]byte(strings.Replace(consoleExportTestPeer, `"msp_id": "org1",`, "", 1)))
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("'MspID' failed on the 'required' tag"))
+	})
+	It(`Parses export all bundles`, func() {
+		export, err := blockchainv3.ParseConsoleExport(bundle(map[string]string{
+			"Nodes/Org1_Peer_peer.json":    consoleExportTestPeer,
+			"Nodes/OS_orderer.json":        consoleExportTestOrderers,
+			"Nodes/Org1_CA_ca.json":        consoleExportTestCa,
+			"Organizations/Org_1_msp.json": consoleExportTestMsp,
+			"Wallet/admin_identity.json":   `{"name": "admin", "private_key": "a2V5", "cert": "Y2VydA=="}`,
+			"README.txt":                   "exported by the console",
+		}))
+		Expect(err).To(BeNil())
+		Expect(export.Peers).To(HaveLen(1))
+		Expect(export.Orderers).To(HaveLen(2))
+		Expect(export.Cas).To(HaveLen(1))
+		Expect(export.Msps).To(HaveLen(1))
+		Expect(export.Msps[0].Admins).To(Equal([]string{"YWRtaW4="}))
+		Expect(export.Skipped).To(ConsistOf("Wallet/admin_identity.json", "README.txt"))
+
+		_, err = blockchainv3.ParseConsoleExport(bundle(map[string]string{"Nodes/broken.json": "{"}))
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(HavePrefix("Nodes/broken.json: "))
+	})
+	It(`Writes what it parses`, func() {
+		var peer, orderer1, orderer2 *blockchainv3.GenericComponentResponse
+		Expect(json.Unmarshal([]byte(consoleExportTestPeer), &peer)).To(BeNil())
+		var orderers []*blockchainv3.GenericComponentResponse
+		Expect(json.Unmarshal([]byte(consoleExportTestOrderers), &orderers)).To(BeNil())
+		orderer1, orderer2 = orderers[0], orderers[1]
+		export, err := blockchainv3.NewConsoleExport([]*blockchainv3.GenericComponentResponse{peer, orderer1, orderer2})
+		Expect(err).To(BeNil())
+		var msp *blockchainv3.MspResponse
+		Expect(json.Unmarshal([]byte(consoleExportTestMsp), &msp)).To(BeNil())
+		Expect(export.AddMsp(msp)).To(BeNil())
+
+		var buffer bytes.Buffer
+		Expect(export.WriteBundle(&buffer)).To(BeNil())
+		reader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+		Expect(err).To(BeNil())
+		var names []string
+		for _, file := range reader.File {
+			names = append(names, file.Name)
+		}
+		Expect(names).To(Equal([]string{"Org_1_msp.json", "OS_orderer.json", "Org1_Peer_peer.json"}))
+
+		read, err := blockchainv3.ParseConsoleExport(buffer.Bytes())
+		Expect(err).To(BeNil())
+		Expect(read.Peers).To(Equal(export.Peers))
+		Expect(read.Orderers).To(Equal(export.Orderers))
+		Expect(read.Msps).To(Equal(export.Msps))
+
+		buffer.Reset()
+		single := &blockchainv3.ConsoleExport{Peers: export.Peers}
+		Expect(single.WriteJSON(&buffer)).To(BeNil())
+		Expect(buffer.String()).To(HavePrefix("{"))
+		Expect(buffer.String()).ToNot(ContainSubstring("Headers"))
+		read, err = blockchainv3.ParseConsoleExport(buffer.Bytes())
+		Expect(err).To(BeNil())
+		Expect(read.Peers).To(Equal(export.Peers))
+
+		Expect(export.AddComponent(&blockchainv3.GenericComponentResponse{ID: core.StringPtr("msp1"), Type: core.StringPtr("msp")})).ToNot(BeNil())
+		peer.GrpcwpURL = nil
+		Expect(export.AddComponent(peer)).ToNot(BeNil())
+		Expect(export.Peers).To(HaveLen(1))
+	})
+	It(`Gives every component its own file`, func() {
+		export := &blockchainv3.ConsoleExport{}
+		for _, name := range []string{"Org 1", "Org 1", "Org_1", "Org 1_msp 1"} {
+			var msp *blockchainv3.MspResponse
+			Expect(json.Unmarshal([]byte(consoleExportTestMsp), &msp)).To(BeNil())
+			msp.DisplayName = core.StringPtr(name)
+			Expect(export.AddMsp(msp)).To(BeNil())
+		}
+		var buffer bytes.Buffer
+		Expect(export.WriteBundle(&buffer)).To(BeNil())
+		reader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+		Expect(err).To(BeNil())
+		var names []string
+		for _, file := range reader.File {
+			names = append(names, file.Name)
+		}
+		Expect(names).To(Equal([]string{"Org_1_msp.json", "Org_1_msp_1.json", "Org_1_msp_2.json", "Org_1_msp_1_msp.json"}))
+	})
+	It(`Exports components of the console`, func() {
+		testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			res.Header().Set("Content-type", "application/json")
+			switch req.URL.Path {
+			case "/ak/api/v3/components/org1peer1":
+				fmt.Fprint(res, consoleExportTestPeer)
+			case "/ak/api/v3/components/org1msp":
+				fmt.Fprint(res, consoleExportTestMsp)
+			default:
+				res.WriteHeader(404)
+			}
+		}))
+		defer testServer.Close()
+		blockchainService, err := blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+
+		export, err := blockchainService.ExportComponents(context.Background(), []string{"org1peer1", "org1msp"})
+		Expect(err).To(BeNil())
+		Expect(*export.Peers[0].GrpcwpURL).To(Equal("https://org1peer1-proxy.example.com:443"))
+		Expect(export.Msps[0].RootCerts).To(Equal([]string{"cm9vdA=="}))
+
+		_, err = blockchainService.ExportComponents(context.Background(), []string{"missing"})
+		Expect(err).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,355 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"context"
+	"fmt"
+	"github.com/IBM/go-sdk-core/v4/core"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ConsoleSet : Named consoles, such as the consoles of the organizations of a network, and operations across them.
+// Operations run on the consoles concurrently and report the outcome on every console.
+type ConsoleSet struct {
+	names    []string
+	consoles map[string]*BlockchainV3
+}
+
+// NewConsoleSet : Instantiate an empty ConsoleSet
+func NewConsoleSet() *ConsoleSet {
+	return &ConsoleSet{consoles: map[string]*BlockchainV3{}}
+}
+
+// Add : Add a console by name
+func (set *ConsoleSet) Add(name string, console *BlockchainV3) error {
+	if name == "" {
+		return fmt.Errorf("consoles need a name")
+	}
+	if console == nil {
+		return fmt.Errorf("console %s cannot be nil", name)
+	}
+	if set.consoles[name] != nil {
+		return fmt.Errorf("the set already has a console %s", name)
+	}
+	set.names = append(set.names, name)
+	set.consoles[name] = console
+	return nil
+}
+
+// Console : Get a console by name, or nil if the set has no such console
+func (set *ConsoleSet) Console(name string) *BlockchainV3 {
+	return set.consoles[name]
+}
+
+// Names : The names of the consoles, in the order they were added
+func (set *ConsoleSet) Names() []string {
+	return append([]string{}, set.names...)
+}
+
+// ConsoleResult : The outcome of an operation on one console.
+type ConsoleResult struct {
+	// The name of the console.
+	Console string
+
+	// The ids of the components the operation created on the console.
+	IDs []string
+
+	// Why the operation failed on the console. The IDs were created before it failed.
+	Error error
+}
+
+// ConsoleSetResult : The outcome of an operation on every console, in the order of the consoles.
+type ConsoleSetResult struct {
+	Results []*ConsoleResult
+}
+
+// Failed returns the results of the consoles the operation failed on.
+func (result *ConsoleSetResult) Failed() (failed []*ConsoleResult) {
+	for _, consoleResult := range result.Results {
+		if consoleResult.Error != nil {
+			failed = append(failed, consoleResult)
+		}
+	}
+	return
+}
+
+// Err returns an error that lists the consoles the operation failed on, or nil if it succeeded on every console.
+func (result *ConsoleSetResult) Err() error {
+	failed := result.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	messages := make([]string, len(failed))
+	for i, consoleResult := range failed {
+		messages[i] = consoleResult.Console + ": " + consoleResult.Error.Error()
+	}
+	return fmt.Errorf("the operation failed on %d of %d consoles: %s", len(failed), len(result.Results), strings.Join(messages, "; "))
+}
+
+// Each : Run an operation on consoles concurrently
+// Runs operation on the named consoles, or on every console if names is empty, and waits for it to finish on all of
+// them. The operation returns the ids of the components it created.
+func (set *ConsoleSet) Each(ctx context.Context, names []string, operation func(ctx context.Context, name string, console *BlockchainV3) ([]string, error)) (*ConsoleSetResult, error) {
+	if len(names) == 0 {
+		names = set.names
+	}
+	for _, name := range names {
+		if set.consoles[name] == nil {
+			return nil, fmt.Errorf("the set has no console %s", name)
+		}
+	}
+	result := &ConsoleSetResult{Results: make([]*ConsoleResult, len(names))}
+	var wait sync.WaitGroup
+	for i, name := range names {
+		result.Results[i] = &ConsoleResult{Console: name}
+		wait.Add(1)
+		go func(consoleResult *ConsoleResult) {
+			defer wait.Done()
+			consoleResult.IDs, consoleResult.Error = operation(ctx, consoleResult.Console, set.consoles[consoleResult.Console])
+		}(result.Results[i])
+	}
+	wait.Wait()
+	return result, nil
+}
+
+// others returns the named consoles, or every console but source if names is empty.
+func (set *ConsoleSet) others(source string, names []string) ([]string, error) {
+	if set.consoles[source] == nil {
+		return nil, fmt.Errorf("the set has no console %s", source)
+	}
+	if len(names) > 0 {
+		return names, nil
+	}
+	for _, name := range set.names {
+		if name != source {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("the set has no consoles besides %s", source)
+	}
+	return names, nil
+}
+
+// CopyMsp : Copy an MSP to other consoles
+// Gets the public data of the MSP with mspID from the source console and imports it on the targets, or on every other
+// console if targets is empty. The imported MSPs are named displayName, which defaults to the MSP id.
+func (set *ConsoleSet) CopyMsp(ctx context.Context, source string, mspID string, displayName string, targets []string) (result *ConsoleSetResult, err error) {
+	targets, err = set.others(source, targets)
+	if err != nil {
+		return
+	}
+	console := set.consoles[source]
+	certificates, _, err := console.GetMspCertificateWithContext(ctx, console.NewGetMspCertificateOptions(mspID))
+	if err != nil {
+		return nil, fmt.Errorf("getting MSP %s of console %s failed: %s", mspID, source, err.Error())
+	}
+	if len(certificates.Msps) == 0 {
+		return nil, fmt.Errorf("console %s has no MSP %s", source, mspID)
+	}
+	msp := certificates.Msps[0]
+	if displayName == "" {
+		displayName = mspID
+	}
+
+	return set.Each(ctx, targets, func(ctx context.Context, name string, console *BlockchainV3) ([]string, error) {
+		options := console.NewImportMspOptions(mspID, displayName, msp.RootCerts)
+		options.Admins = msp.Admins
+		options.TlsRootCerts = msp.TlsRootCerts
+		imported, _, err := console.ImportMspWithContext(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		return []string{core.StringNilMapper(imported.ID)}, nil
+	})
+}
+
+// ShareOrderer : Import an ordering service into other consoles
+// Gets every node of the ordering service of the orderer with the id ordererID from the source console and imports
+// them on the targets, or on every other console if targets is empty. The import on a console stops at the first node
+// that fails.
+func (set *ConsoleSet) ShareOrderer(ctx context.Context, source string, ordererID string, targets []string) (result *ConsoleSetResult, err error) {
+	targets, err = set.others(source, targets)
+	if err != nil {
+		return
+	}
+	console := set.consoles[source]
+	orderer, _, err := console.GetComponentWithContext(ctx, console.NewGetComponentOptions(ordererID))
+	if err != nil {
+		return nil, fmt.Errorf("getting orderer %s of console %s failed: %s", ordererID, source, err.Error())
+	}
+	if orderer == nil || core.StringNilMapper(orderer.Type) != ComponentType_FabricOrderer {
+		return nil, fmt.Errorf("component %s of console %s is not an orderer", ordererID, source)
+	}
+
+	// the other nodes of the ordering service
+	ids := []string{ordererID}
+	if clusterID := core.StringNilMapper(orderer.ClusterID); clusterID != "" {
+		orderers, _, err := console.GetComponentsByTypeWithContext(ctx, console.NewGetComponentsByTypeOptions(ComponentType_FabricOrderer))
+		if err != nil {
+			return nil, fmt.Errorf("listing the orderers of console %s failed: %s", source, err.Error())
+		}
+		for _, node := range orderers.Components {
+			if id := core.StringNilMapper(node.ID); id != ordererID && core.StringNilMapper(node.ClusterID) == clusterID {
+				ids = append(ids, id)
+			}
+		}
+	}
+	export := &ConsoleExport{}
+	for i, id := range ids {
+		node := orderer
+		if i > 0 {
+			node, _, err = console.GetComponentWithContext(ctx, console.NewGetComponentOptions(id))
+			if err != nil {
+				return nil, fmt.Errorf("getting orderer %s of console %s failed: %s", id, source, err.Error())
+			}
+		}
+		err = export.AddComponent(node)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return set.Each(ctx, targets, func(ctx context.Context, name string, console *BlockchainV3) (ids []string, err error) {
+		for _, options := range export.Orderers {
+			imported, _, err := console.ImportOrdererWithContext(ctx, options)
+			if err != nil {
+				return ids, fmt.Errorf("importing %s failed: %s", core.StringNilMapper(options.DisplayName), err.Error())
+			}
+			ids = append(ids, core.StringNilMapper(imported.ID))
+		}
+		return
+	})
+}
+
+// InventoryComparison : Which consoles have which components.
+type InventoryComparison struct {
+	// The consoles whose components were listed, in the order of the set.
+	Consoles []string
+
+	// The components, sorted by type and key.
+	Components []*ComparedComponent
+
+	// The outcome of listing the components of every console. Consoles whose components could not be listed are not
+	// compared.
+	Result *ConsoleSetResult
+}
+
+// ComparedComponent : A component and the consoles that have it. Components are the same on different consoles if
+// they have the same type and key: the MSP id of MSPs and the API URL of nodes and CAs.
+type ComparedComponent struct {
+	Type string
+
+	Key string
+
+	DisplayName string
+
+	MspID string
+
+	// The id of the component on each console that has it.
+	IDs map[string]string
+
+	// The consoles that do not have the component.
+	Missing []string
+}
+
+// comparisonKey returns the key that identifies a component on every console.
+func comparisonKey(component *GenericComponentResponse) string {
+	if core.StringNilMapper(component.Type) == ComponentType_Msp {
+		return core.StringNilMapper(component.MspID)
+	}
+	if key := core.StringNilMapper(component.ApiURL); key != "" {
+		return key
+	}
+	return core.StringNilMapper(component.DisplayName)
+}
+
+// CompareInventories : Compare the components of consoles
+// Lists the components of the named consoles, or of every console if names is empty, and reports which consoles
+// have each of them.
+func (set *ConsoleSet) CompareInventories(ctx context.Context, names []string) (comparison *InventoryComparison, err error) {
+	var mutex sync.Mutex
+	inventories := map[string][]GenericComponentResponse{}
+	result, err := set.Each(ctx, names, func(ctx context.Context, name string, console *BlockchainV3) ([]string, error) {
+		components, _, err := console.ListComponentsWithContext(ctx, console.NewListComponentsOptions())
+		if err != nil {
+			return nil, err
+		}
+		mutex.Lock()
+		inventories[name] = components.Components
+		mutex.Unlock()
+		return nil, nil
+	})
+	if err != nil {
+		return
+	}
+
+	comparison = &InventoryComparison{Result: result}
+	byKey := map[string]*ComparedComponent{}
+	for _, consoleResult := range result.Results {
+		if consoleResult.Error != nil {
+			continue
+		}
+		name := consoleResult.Console
+		comparison.Consoles = append(comparison.Consoles, name)
+		for i := range inventories[name] {
+			component := &inventories[name][i]
+			componentType, key := core.StringNilMapper(component.Type), comparisonKey(component)
+			compared := byKey[componentType+" "+key]
+			if compared == nil {
+				compared = &ComparedComponent{
+					Type:        componentType,
+					Key:         key,
+					DisplayName: core.StringNilMapper(component.DisplayName),
+					MspID:       core.StringNilMapper(component.MspID),
+					IDs:         map[string]string{},
+				}
+				byKey[componentType+" "+key] = compared
+				comparison.Components = append(comparison.Components, compared)
+			}
+			compared.IDs[name] = core.StringNilMapper(component.ID)
+		}
+	}
+	for _, compared := range comparison.Components {
+		for _, name := range comparison.Consoles {
+			if _, ok := compared.IDs[name]; !ok {
+				compared.Missing = append(compared.Missing, name)
+			}
+		}
+	}
+	sort.SliceStable(comparison.Components, func(i, j int) bool {
+		a, b := comparison.Components[i], comparison.Components[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Key < b.Key
+	})
+	return
+}
+
+// Differences returns the components that some of the compared consoles do not have.
+func (comparison *InventoryComparison) Differences() (components []*ComparedComponent) {
+	for _, compared := range comparison.Components {
+		if len(compared.Missing) > 0 {
+			components = append(components, compared)
+		}
+	}
+	return
+}
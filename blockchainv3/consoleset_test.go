@@ -0,0 +1,193 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// fakeConsole is a console with components that records the components imported into it.
+type fakeConsole struct {
+	server     *httptest.Server
+	mutex      sync.Mutex
+	components []map[string]interface{}
+	imported   []map[string]interface{}
+	// the import that fails, counted from 1
+	failImport int
+}
+
+func newFakeConsole(components ...map[string]interface{}) *fakeConsole {
+	console := &fakeConsole{components: components}
+	console.server = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		defer GinkgoRecover()
+		console.mutex.Lock()
+		defer console.mutex.Unlock()
+		res.Header().Set("Content-type", "application/json")
+		op, ok := blockchainv3.LookupOperation(req.Method, req.URL.Path)
+		Expect(ok).To(BeTrue())
+		last := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+		switch op.Name {
+		case "ListComponents":
+			json.NewEncoder(res).Encode(map[string]interface{}{"components": console.components})
+		case "GetComponentsByType":
+			var list []map[string]interface{}
+			for _, component := range console.components {
+				if component["type"] == last {
+					list = append(list, component)
+				}
+			}
+			json.NewEncoder(res).Encode(map[string]interface{}{"components": list})
+		case "GetComponent":
+			for _, component := range console.components {
+				if component["id"] == last {
+					json.NewEncoder(res).Encode(component)
+					return
+				}
+			}
+			res.WriteHeader(404)
+		case "GetMspCertificate":
+			var msps []map[string]interface{}
+			for _, component := range console.components {
+				if component["type"] == "msp" && component["msp_id"] == last {
+					msps = append(msps, map[string]interface{}{"msp_id": last, "root_certs": component["root_certs"], "admins": component["admins"]})
+				}
+			}
+			json.NewEncoder(res).Encode(map[string]interface{}{"msps": msps})
+		case "ImportMsp", "ImportOrderer":
+			var body map[string]interface{}
+			Expect(json.NewDecoder(req.Body).Decode(&body)).To(BeNil())
+			console.imported = append(console.imported, body)
+			if len(console.imported) == console.failImport {
+				res.WriteHeader(400)
+				fmt.Fprint(res, `{"statusCode": 400, "msg": "invalid"}`)
+				return
+			}
+			fmt.Fprintf(res, `{"id": "imported%d"}`, len(console.imported))
+		default:
+			res.WriteHeader(404)
+		}
+	}))
+	return console
+}
+
+var _ = Describe(`BlockchainV3 console sets`, func() {
+	var org1, org2, org3 *fakeConsole
+	var set *blockchainv3.ConsoleSet
+	orderer := func(id string, name string) map[string]interface{} {
+		return map[string]interface{}{
+			"id": id, "type": "fabric-orderer", "display_name": name, "cluster_id": "os", "cluster_name": "OS", "msp_id": "osmsp",
+			"api_url": "grpcs://" + id + ":7050", "grpcwp_url": "https://" + id + ":7443",
+			"msp": map[string]interface{}{"tlsca": map[string]interface{}{"root_certs": []string{"dGxz"}}, "component": map[string]interface{}{"tls_cert": "Y2VydA=="}},
+		}
+	}
+
+	BeforeEach(func() {
+		org1 = newFakeConsole(
+			map[string]interface{}{"id": "org1msp", "type": "msp", "msp_id": "org1", "display_name": "Org 1", "root_certs": []string{"cm9vdA=="}, "admins": []string{"YWRtaW4="}},
+			orderer("os1", "OS 1"), orderer("os2", "OS 2"),
+			map[string]interface{}{"id": "peer1", "type": "fabric-peer", "display_name": "Peer 1", "msp_id": "org1", "api_url": "grpcs://peer1:7051"},
+		)
+		org2 = newFakeConsole(map[string]interface{}{"id": "org1", "type": "msp", "msp_id": "org1", "display_name": "Org 1"})
+		org3 = newFakeConsole()
+		set = blockchainv3.NewConsoleSet()
+		for name, console := range map[string]*fakeConsole{"org1": org1, "org2": org2, "org3": org3} {
+			service, err := blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+				URL:           console.server.URL,
+				Authenticator: &core.NoAuthAuthenticator{},
+			})
+			Expect(err).To(BeNil())
+			Expect(set.Add(name, service)).To(BeNil())
+		}
+	})
+	AfterEach(func() {
+		org1.server.Close()
+		org2.server.Close()
+		org3.server.Close()
+	})
+
+	It(`Copies an MSP to the other consoles`, func() {
+		org3.failImport = 1
+		result, err := set.CopyMsp(context.Background(), "org1", "org1", "", nil)
+		Expect(err).To(BeNil())
+		Expect(result.Results).To(HaveLen(2))
+		Expect(result.Failed()).To(HaveLen(1))
+		Expect(result.Failed()[0].Console).To(Equal("org3"))
+		Expect(result.Err().Error()).To(HavePrefix("the operation failed on 1 of 2 consoles: org3: "))
+
+		var imported *blockchainv3.ConsoleResult
+		for _, consoleResult := range result.Results {
+			if consoleResult.Console == "org2" {
+				imported = consoleResult
+			}
+		}
+		Expect(imported.Error).To(BeNil())
+		Expect(imported.IDs).To(Equal([]string{"imported1"}))
+		Expect(org2.imported[0]["display_name"]).To(Equal("org1"))
+		Expect(org2.imported[0]["root_certs"]).To(Equal([]interface{}{"cm9vdA=="}))
+		Expect(org2.imported[0]["admins"]).To(Equal([]interface{}{"YWRtaW4="}))
+
+		_, err = set.CopyMsp(context.Background(), "org1", "org9", "Org 9", nil)
+		Expect(err).ToNot(BeNil())
+		_, err = set.CopyMsp(context.Background(), "org1", "org1", "Org 1", []string{"org4"})
+		Expect(err).ToNot(BeNil())
+		Expect(set.Add("org1", set.Console("org2"))).ToNot(BeNil())
+	})
+	It(`Shares every node of an ordering service`, func() {
+		org3.failImport = 2
+		result, err := set.ShareOrderer(context.Background(), "org1", "os2", []string{"org2", "org3"})
+		Expect(err).To(BeNil())
+		Expect(result.Results[0].Error).To(BeNil())
+		Expect(result.Results[0].IDs).To(Equal([]string{"imported1", "imported2"}))
+		Expect(org2.imported[0]["display_name"]).To(Equal("OS 2"))
+		Expect(org2.imported[1]["display_name"]).To(Equal("OS 1"))
+		Expect(org2.imported[1]["cluster_id"]).To(Equal("os"))
+		Expect(org2.imported[1]["msp"]).To(Equal(map[string]interface{}{
+			"tlsca": map[string]interface{}{"root_certs": []interface{}{"dGxz"}}, "component": map[string]interface{}{"tls_cert": "Y2VydA=="},
+		}))
+		Expect(result.Results[1].IDs).To(Equal([]string{"imported1"}))
+		Expect(result.Results[1].Error.Error()).To(HavePrefix("importing OS 1 failed: "))
+
+		_, err = set.ShareOrderer(context.Background(), "org1", "peer1", nil)
+		Expect(err).ToNot(BeNil())
+	})
+	It(`Compares inventories`, func() {
+		org3.server.Close()
+		comparison, err := set.CompareInventories(context.Background(), []string{"org1", "org2", "org3"})
+		Expect(err).To(BeNil())
+		Expect(comparison.Consoles).To(Equal([]string{"org1", "org2"}))
+		Expect(comparison.Result.Failed()[0].Console).To(Equal("org3"))
+		Expect(comparison.Components).To(HaveLen(4))
+		msp := comparison.Components[3]
+		Expect(msp.Key).To(Equal("org1"))
+		Expect(msp.IDs).To(Equal(map[string]string{"org1": "org1msp", "org2": "org1"}))
+		Expect(msp.Missing).To(BeEmpty())
+		differences := comparison.Differences()
+		Expect(differences).To(HaveLen(3))
+		Expect(differences[0].Key).To(Equal("grpcs://os1:7050"))
+		Expect(differences[0].Missing).To(Equal([]string{"org2"}))
+	})
+})
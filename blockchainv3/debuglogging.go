@@ -0,0 +1,87 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"context"
+	"fmt"
+	"github.com/IBM/go-sdk-core/v4/core"
+	"time"
+)
+
+// DebugLoggingRestoreTimeout is how long WithDebugLogging waits for the console to accept the original logging
+// settings.
+const DebugLoggingRestoreTimeout = 2 * time.Minute
+
+// WithDebugLogging turns on the file logging of the console at debug level, for the server and the client, while fn
+// runs, or for duration if fn is nil. If fn is not nil and duration is positive, the context of fn is cancelled after
+// duration.
+//
+// The file logging settings are read with GetSettings first, and are restored when fn returns, panics, or the context
+// is cancelled. The restore does not use the context, so that it is sent after a cancel; it is given
+// DebugLoggingRestoreTimeout instead. Both changes restart the console server.
+func (blockchain *BlockchainV3) WithDebugLogging(ctx context.Context, duration time.Duration, fn func(ctx context.Context) error) (err error) {
+	current, _, err := blockchain.GetSettingsWithContext(ctx, blockchain.NewGetSettingsOptions())
+	if err != nil {
+		return fmt.Errorf("reading the logging settings failed: %s", err.Error())
+	}
+	original := EditableSettings(current).FileLogging
+	if original == nil || original.Server == nil || original.Client == nil {
+		return fmt.Errorf("the console does not report its file logging settings, they could not be restored")
+	}
+
+	// The outcome of a failed change is unknown, so the settings are restored either way.
+	defer func() {
+		restoreCtx, cancel := context.WithTimeout(context.Background(), DebugLoggingRestoreTimeout)
+		defer cancel()
+		_, _, restoreErr := blockchain.EditSettingsWithContext(restoreCtx, blockchain.NewEditSettingsOptions().SetFileLogging(original))
+		if restoreErr == nil {
+			return
+		}
+		if err != nil {
+			err = fmt.Errorf("%s; restoring the logging settings failed: %s", err.Error(), restoreErr.Error())
+		} else {
+			err = fmt.Errorf("restoring the logging settings failed: %s", restoreErr.Error())
+		}
+	}()
+
+	debug := &EditLogSettingsBody{
+		Client: &LoggingSettingsClient{Enabled: core.BoolPtr(true), Level: core.StringPtr(LoggingSettingsClient_Level_Debug)},
+		Server: &LoggingSettingsServer{Enabled: core.BoolPtr(true), Level: core.StringPtr(LoggingSettingsServer_Level_Debug)},
+	}
+	_, _, err = blockchain.EditSettingsWithContext(ctx, blockchain.NewEditSettingsOptions().SetFileLogging(debug))
+	if err != nil {
+		return fmt.Errorf("turning on debug logging failed: %s", err.Error())
+	}
+
+	if fn == nil {
+		timer := time.NewTimer(duration)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, duration)
+		defer cancel()
+	}
+	return fn(ctx)
+}
@@ -0,0 +1,137 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+var _ = Describe(`BlockchainV3 temporary debug logging`, func() {
+	var testServer *httptest.Server
+	var blockchainService *blockchainv3.BlockchainV3
+	var mutex sync.Mutex
+	var levels map[string]string
+	var edits []string
+	var failRestore bool
+
+	serverLevel := func() string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return levels["server"]
+	}
+
+	BeforeEach(func() {
+		levels = map[string]string{"server": "info", "client": "warn"}
+		edits, failRestore = nil, false
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			mutex.Lock()
+			defer mutex.Unlock()
+			Expect(req.URL.Path).To(Equal("/ak/api/v3/settings"))
+			res.Header().Set("Content-type", "application/json")
+			if req.Method == http.MethodPut {
+				var body struct {
+					FileLogging map[string]struct {
+						Level string `json:"level"`
+					} `json:"file_logging"`
+				}
+				Expect(json.NewDecoder(req.Body).Decode(&body)).To(BeNil())
+				edits = append(edits, body.FileLogging["server"].Level+"/"+body.FileLogging["client"].Level)
+				if failRestore && len(edits) == 2 {
+					res.WriteHeader(503)
+					fmt.Fprint(res, `{"message": "restarting"}`)
+					return
+				}
+				for side, settings := range body.FileLogging {
+					levels[side] = settings.Level
+				}
+			}
+			fmt.Fprintf(res, `{"FILE_LOGGING": {
+				"server": {"server": {"enabled": true, "level": %q, "unique_name": false}},
+				"client": {"client": {"enabled": false, "level": %q, "unique_name": false}}
+			}}`, levels["server"], levels["client"])
+		}))
+		var err error
+		blockchainService, err = blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Raises the log levels while the function runs`, func() {
+		var during string
+		err := blockchainService.WithDebugLogging(context.Background(), time.Minute, func(ctx context.Context) error {
+			during = serverLevel()
+			deadline, ok := ctx.Deadline()
+			Expect(ok).To(BeTrue())
+			Expect(deadline).To(BeTemporally("~", time.Now().Add(time.Minute), time.Second))
+			return fmt.Errorf("collected")
+		})
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("collected"))
+		Expect(during).To(Equal("debug"))
+		Expect(edits).To(Equal([]string{"debug/debug", "info/warn"}))
+	})
+	It(`Restores the settings after a panic`, func() {
+		Expect(func() {
+			blockchainService.WithDebugLogging(context.Background(), 0, func(ctx context.Context) error {
+				panic("boom")
+			})
+		}).To(PanicWith("boom"))
+		Expect(edits).To(Equal([]string{"debug/debug", "info/warn"}))
+		Expect(serverLevel()).To(Equal("info"))
+	})
+	It(`Waits for the duration or the cancel of the context`, func() {
+		start := time.Now()
+		Expect(blockchainService.WithDebugLogging(context.Background(), 50*time.Millisecond, nil)).To(BeNil())
+		Expect(time.Since(start)).To(BeNumerically(">=", 50*time.Millisecond))
+		Expect(serverLevel()).To(Equal("info"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			defer GinkgoRecover()
+			Eventually(serverLevel).Should(Equal("debug"))
+			cancel()
+		}()
+		err := blockchainService.WithDebugLogging(ctx, time.Hour, nil)
+		Expect(err).To(Equal(context.Canceled))
+		Expect(edits).To(Equal([]string{"debug/debug", "info/warn", "debug/debug", "info/warn"}))
+	})
+	It(`Reports a failed restore`, func() {
+		failRestore = true
+		err := blockchainService.WithDebugLogging(context.Background(), 0, func(ctx context.Context) error {
+			return nil
+		})
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("restoring the logging settings failed: restarting"))
+		Expect(serverLevel()).To(Equal("debug"))
+	})
+})
@@ -0,0 +1,591 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"context"
+	"fmt"
+	"github.com/IBM/go-sdk-core/v4/core"
+	"gopkg.in/yaml.v2"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DriftReport : Differences between the OpenAPI document of a console and the operations and models implemented by
+// this SDK. "Missing" and "new" entries exist only in the console's document, "removed" entries exist only in the SDK.
+type DriftReport struct {
+	// Operations of the console that the SDK cannot call. Name holds the operationId of the document.
+	MissingOperations []Operation `json:"missing_operations,omitempty"`
+
+	// Operations of the SDK that the console no longer documents.
+	RemovedOperations []Operation `json:"removed_operations,omitempty"`
+
+	// Schemas of the console without an SDK model.
+	MissingSchemas []string `json:"missing_schemas,omitempty"`
+
+	// Query, path and body parameters of an operation that the SDK options do not have.
+	NewParameters []DriftItem `json:"new_parameters,omitempty"`
+
+	// Parameters of the SDK options that the console no longer documents.
+	RemovedParameters []DriftItem `json:"removed_parameters,omitempty"`
+
+	// Schema properties that the SDK model does not have.
+	NewFields []DriftItem `json:"new_fields,omitempty"`
+
+	// SDK model fields that the console schema no longer documents.
+	RemovedFields []DriftItem `json:"removed_fields,omitempty"`
+
+	// Enum values of the console that the SDK does not define.
+	NewEnumValues []DriftItem `json:"new_enum_values,omitempty"`
+
+	// Enum values defined by the SDK that the console no longer accepts.
+	RemovedEnumValues []DriftItem `json:"removed_enum_values,omitempty"`
+}
+
+// DriftItem : A single difference. Operation is set for parameters, Schema for model fields. Value is set for enum
+// values.
+type DriftItem struct {
+	Operation string `json:"operation,omitempty"`
+
+	Schema string `json:"schema,omitempty"`
+
+	Name string `json:"name"`
+
+	Value string `json:"value,omitempty"`
+}
+
+// String renders the item as `<operation or schema>.<name>[=<value>]`.
+func (item DriftItem) String() string {
+	owner := item.Operation
+	if owner == "" {
+		owner = item.Schema
+	}
+	if item.Value != "" {
+		return fmt.Sprintf("%s.%s=%s", owner, item.Name, item.Value)
+	}
+	return fmt.Sprintf("%s.%s", owner, item.Name)
+}
+
+// HasDrift reports whether the report contains any difference.
+func (report *DriftReport) HasDrift() bool {
+	return len(report.MissingOperations)+len(report.RemovedOperations)+len(report.MissingSchemas)+
+		len(report.NewParameters)+len(report.RemovedParameters)+len(report.NewFields)+len(report.RemovedFields)+
+		len(report.NewEnumValues)+len(report.RemovedEnumValues) > 0
+}
+
+// String renders the report as text, one section per kind of difference.
+func (report *DriftReport) String() string {
+	var sb strings.Builder
+	section := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "%s (%d):\n", title, len(lines))
+		for _, line := range lines {
+			fmt.Fprintf(&sb, "  %s\n", line)
+		}
+	}
+	operations := func(list []Operation) (lines []string) {
+		for _, op := range list {
+			lines = append(lines, fmt.Sprintf("%-6s %s (%s)", op.Method, op.Path, op.Name))
+		}
+		return
+	}
+	items := func(list []DriftItem) (lines []string) {
+		for _, item := range list {
+			lines = append(lines, item.String())
+		}
+		return
+	}
+	section("Missing operations", operations(report.MissingOperations))
+	section("Removed operations", operations(report.RemovedOperations))
+	section("Missing schemas", report.MissingSchemas)
+	section("New parameters", items(report.NewParameters))
+	section("Removed parameters", items(report.RemovedParameters))
+	section("New fields", items(report.NewFields))
+	section("Removed fields", items(report.RemovedFields))
+	section("New enum values", items(report.NewEnumValues))
+	section("Removed enum values", items(report.RemovedEnumValues))
+	if sb.Len() == 0 {
+		return "No drift detected\n"
+	}
+	return sb.String()
+}
+
+// DetectDrift : Compare the console's OpenAPI document with the SDK
+// Retrieves the document with GetSwagger and compares it with the operations and models implemented by this SDK. See
+// CompareOpenAPI.
+func (blockchain *BlockchainV3) DetectDrift() (report *DriftReport, response *core.DetailedResponse, err error) {
+	return blockchain.DetectDriftWithContext(context.Background())
+}
+
+// DetectDriftWithContext is an alternate form of the DetectDrift method which supports a Context parameter
+func (blockchain *BlockchainV3) DetectDriftWithContext(ctx context.Context) (report *DriftReport, response *core.DetailedResponse, err error) {
+	spec, response, err := blockchain.GetSwaggerWithContext(ctx, blockchain.NewGetSwaggerOptions())
+	if err != nil {
+		return
+	}
+	if spec == nil {
+		err = fmt.Errorf("the console returned an empty OpenAPI document")
+		return
+	}
+	report, err = CompareOpenAPI([]byte(*spec))
+	return
+}
+
+// CompareOpenAPI compares an OpenAPI document (OpenAPI 3 or Swagger 2, as JSON or YAML) with the operations and models
+// implemented by this SDK. Only operations under `/ak/api/v3` are considered. Path parameters are compared by position,
+// so `{id}` and `{component_id}` are the same path. Schemas are paired with SDK models by name, ignoring case and
+// punctuation.
+func CompareOpenAPI(document []byte) (report *DriftReport, err error) {
+	spec, err := parseOpenAPI(document)
+	if err != nil {
+		return
+	}
+	report = &DriftReport{}
+	sdk := describeSdk()
+
+	// operations and their parameters
+	matched := map[string]bool{}
+	for _, specOp := range spec.operations {
+		op, ok := sdk.lookupOperation(specOp.method, specOp.path)
+		if !ok {
+			report.MissingOperations = append(report.MissingOperations, Operation{Name: specOp.id, Method: specOp.method, Path: specOp.path})
+			continue
+		}
+		matched[op.Name] = true
+		options := sdk.options[op.Name]
+		compareNames(specOp.parameters, fieldsOf(options), func(name string) {
+			report.NewParameters = append(report.NewParameters, DriftItem{Operation: op.Name, Name: name})
+		}, func(name string) {
+			report.RemovedParameters = append(report.RemovedParameters, DriftItem{Operation: op.Name, Name: name})
+		})
+		for name, values := range specOp.enums {
+			compareEnum(report, DriftItem{Operation: op.Name, Name: name}, values, enumOf(options, name))
+		}
+	}
+	for _, op := range operations {
+		if !matched[op.Name] {
+			report.RemovedOperations = append(report.RemovedOperations, op)
+		}
+	}
+
+	// schemas and their fields
+	for _, name := range spec.schemaNames() {
+		schema := spec.schemas[name]
+		model, ok := sdk.models[normalizeSchemaName(name)]
+		if !ok {
+			report.MissingSchemas = append(report.MissingSchemas, name)
+			continue
+		}
+		compareNames(schema.properties, fieldsOf(model), func(field string) {
+			report.NewFields = append(report.NewFields, DriftItem{Schema: name, Name: field})
+		}, func(field string) {
+			report.RemovedFields = append(report.RemovedFields, DriftItem{Schema: name, Name: field})
+		})
+		for field, values := range schema.enums {
+			compareEnum(report, DriftItem{Schema: name, Name: field}, values, enumOf(model, field))
+		}
+	}
+	report.sort()
+	return
+}
+
+func compareNames(spec map[string]bool, sdk map[string]string, added func(string), removed func(string)) {
+	for _, name := range sortedKeys(spec) {
+		if _, ok := sdk[name]; !ok {
+			added(name)
+		}
+	}
+	for _, name := range sortedKeys(sdk) {
+		if !spec[name] {
+			removed(name)
+		}
+	}
+}
+
+func compareEnum(report *DriftReport, item DriftItem, spec []string, sdk []string) {
+	if sdk == nil {
+		// the SDK treats the property as a free-form value
+		return
+	}
+	known := map[string]bool{}
+	for _, value := range sdk {
+		known[value] = true
+	}
+	documented := map[string]bool{}
+	for _, value := range spec {
+		documented[value] = true
+		if !known[value] {
+			item.Value = value
+			report.NewEnumValues = append(report.NewEnumValues, item)
+		}
+	}
+	for _, value := range sdk {
+		if !documented[value] {
+			item.Value = value
+			report.RemovedEnumValues = append(report.RemovedEnumValues, item)
+		}
+	}
+}
+
+func (report *DriftReport) sort() {
+	byPath := func(list []Operation) {
+		sort.SliceStable(list, func(i, j int) bool {
+			if list[i].Path != list[j].Path {
+				return list[i].Path < list[j].Path
+			}
+			return list[i].Method < list[j].Method
+		})
+	}
+	byName := func(list []DriftItem) {
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].String() < list[j].String()
+		})
+	}
+	byPath(report.MissingOperations)
+	byPath(report.RemovedOperations)
+	sort.Strings(report.MissingSchemas)
+	byName(report.NewParameters)
+	byName(report.RemovedParameters)
+	byName(report.NewFields)
+	byName(report.RemovedFields)
+	byName(report.NewEnumValues)
+	byName(report.RemovedEnumValues)
+}
+
+// sdkDescription holds what the SDK implements, discovered by reflection on the *WithContext methods.
+type sdkDescription struct {
+	// options struct of each operation, by operation name
+	options map[string]reflect.Type
+
+	// models reachable from the options and results, by normalized name
+	models map[string]reflect.Type
+}
+
+func describeSdk() *sdkDescription {
+	sdk := &sdkDescription{
+		options: map[string]reflect.Type{},
+		models:  map[string]reflect.Type{},
+	}
+	service := reflect.TypeOf(&BlockchainV3{})
+	for _, op := range operations {
+		method, ok := service.MethodByName(op.Name + "WithContext")
+		if !ok {
+			continue
+		}
+		// receiver, context, options
+		options := method.Type.In(2).Elem()
+		sdk.options[op.Name] = options
+		sdk.collectModels(options)
+		for i := 0; i < method.Type.NumOut(); i++ {
+			sdk.collectModels(method.Type.Out(i))
+		}
+	}
+	return sdk
+}
+
+func (sdk *sdkDescription) collectModels(t reflect.Type) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.PkgPath() != sdkPackagePath {
+		return
+	}
+	name := normalizeSchemaName(t.Name())
+	if _, seen := sdk.models[name]; seen {
+		return
+	}
+	if !strings.HasSuffix(t.Name(), "Options") {
+		sdk.models[name] = t
+	}
+	for i := 0; i < t.NumField(); i++ {
+		sdk.collectModels(t.Field(i).Type)
+	}
+}
+
+var sdkPackagePath = reflect.TypeOf(BlockchainV3{}).PkgPath()
+
+func (sdk *sdkDescription) lookupOperation(method string, path string) (Operation, bool) {
+	for _, op := range operations {
+		if op.Method == method && templatePath(op.Path) == templatePath(path) {
+			return op, true
+		}
+	}
+	return Operation{}, false
+}
+
+var pathParameter = regexp.MustCompile(`\{[^}]*\}`)
+
+// templatePath replaces the names of the path parameters so that paths can be compared by shape.
+func templatePath(path string) string {
+	return pathParameter.ReplaceAllString(strings.TrimSuffix(path, "/"), "{}")
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]`)
+
+func normalizeSchemaName(name string) string {
+	return nonAlphanumeric.ReplaceAllString(strings.ToLower(name), "")
+}
+
+// fieldsOf returns the JSON names of the fields of a struct, mapped to the Go field names.
+func fieldsOf(t reflect.Type) map[string]string {
+	fields := map[string]string{}
+	if t == nil {
+		return fields
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = field.Name
+	}
+	return fields
+}
+
+// enumOf returns the SDK enum values of the struct field with the given JSON name.
+func enumOf(t reflect.Type, jsonName string) []string {
+	if t == nil {
+		return nil
+	}
+	field, ok := fieldsOf(t)[jsonName]
+	if !ok {
+		return nil
+	}
+	return GetEnumValues(t.Name(), field)
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	for _, key := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// openAPIDocument is the part of an OpenAPI document that CompareOpenAPI looks at.
+type openAPIDocument struct {
+	operations []openAPIOperation
+	schemas    map[string]*openAPISchema
+}
+
+type openAPIOperation struct {
+	id         string
+	method     string
+	path       string
+	parameters map[string]bool
+	enums      map[string][]string
+}
+
+type openAPISchema struct {
+	properties map[string]bool
+	enums      map[string][]string
+}
+
+func (doc *openAPIDocument) schemaNames() []string {
+	names := make([]string, 0, len(doc.schemas))
+	for name := range doc.schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+const driftPathPrefix = "/ak/api/v3"
+
+var openAPIMethods = []string{"get", "put", "post", "delete", "patch", "head", "options"}
+
+// parseOpenAPI reads an OpenAPI 3 or Swagger 2 document, as JSON or YAML.
+func parseOpenAPI(document []byte) (doc *openAPIDocument, err error) {
+	var raw interface{}
+	err = yaml.Unmarshal(document, &raw)
+	if err != nil {
+		err = fmt.Errorf("cannot parse the OpenAPI document: %s", err.Error())
+		return
+	}
+	root, ok := normalizeYAML(raw).(map[string]interface{})
+	if !ok || (root["openapi"] == nil && root["swagger"] == nil) {
+		err = fmt.Errorf("the document is not an OpenAPI document")
+		return
+	}
+
+	doc = &openAPIDocument{schemas: map[string]*openAPISchema{}}
+	resolver := &openAPIResolver{root: root}
+
+	definitions := asMap(root["definitions"])
+	if components := asMap(root["components"]); components != nil {
+		definitions = asMap(components["schemas"])
+	}
+	for name, schema := range definitions {
+		doc.schemas[name] = resolver.describeSchema(schema)
+	}
+
+	basePath := ""
+	if base, ok := root["basePath"].(string); ok {
+		basePath = strings.TrimSuffix(base, "/")
+	}
+	for path, item := range asMap(root["paths"]) {
+		fullPath := basePath + path
+		if !strings.HasPrefix(fullPath, driftPathPrefix+"/") && fullPath != driftPathPrefix {
+			continue
+		}
+		pathItem := asMap(item)
+		for _, method := range openAPIMethods {
+			operation := asMap(pathItem[method])
+			if operation == nil {
+				continue
+			}
+			op := openAPIOperation{
+				method:     strings.ToUpper(method),
+				path:       fullPath,
+				parameters: map[string]bool{},
+				enums:      map[string][]string{},
+			}
+			op.id, _ = operation["operationId"].(string)
+			parameters := append(asList(pathItem["parameters"]), asList(operation["parameters"])...)
+			for _, p := range parameters {
+				parameter := asMap(resolver.resolve(p))
+				name, _ := parameter["name"].(string)
+				switch parameter["in"] {
+				case "query", "path":
+					op.parameters[name] = true
+					enum := asList(parameter["enum"])
+					if schema := asMap(resolver.resolve(parameter["schema"])); schema != nil && enum == nil {
+						enum = asList(schema["enum"])
+					}
+					if enum != nil {
+						op.enums[name] = asStrings(enum)
+					}
+				case "body":
+					resolver.addBodyParameters(&op, parameter["schema"])
+				}
+			}
+			if body := asMap(resolver.resolve(operation["requestBody"])); body != nil {
+				for mediaType, content := range asMap(body["content"]) {
+					if strings.Contains(mediaType, "json") {
+						resolver.addBodyParameters(&op, asMap(content)["schema"])
+					}
+				}
+			}
+			doc.operations = append(doc.operations, op)
+		}
+	}
+	sort.SliceStable(doc.operations, func(i, j int) bool {
+		return doc.operations[i].path+doc.operations[i].method < doc.operations[j].path+doc.operations[j].method
+	})
+	return
+}
+
+// openAPIResolver follows local `$ref` pointers of a document.
+type openAPIResolver struct {
+	root map[string]interface{}
+}
+
+func (resolver *openAPIResolver) resolve(node interface{}) interface{} {
+	for i := 0; i < 32; i++ {
+		ref, ok := asMap(node)["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return node
+		}
+		var target interface{} = resolver.root
+		for _, segment := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			segment = strings.Replace(strings.Replace(segment, "~1", "/", -1), "~0", "~", -1)
+			target = asMap(target)[segment]
+		}
+		node = target
+	}
+	return node
+}
+
+// describeSchema collects the properties of an object schema, including those inherited through allOf.
+func (resolver *openAPIResolver) describeSchema(node interface{}) *openAPISchema {
+	schema := &openAPISchema{properties: map[string]bool{}, enums: map[string][]string{}}
+	var visit func(node interface{}, depth int)
+	visit = func(node interface{}, depth int) {
+		object := asMap(resolver.resolve(node))
+		if object == nil || depth > 16 {
+			return
+		}
+		for name, property := range asMap(object["properties"]) {
+			schema.properties[name] = true
+			prop := asMap(resolver.resolve(property))
+			if enum := asList(prop["enum"]); enum != nil {
+				schema.enums[name] = asStrings(enum)
+			} else if items := asMap(resolver.resolve(prop["items"])); items != nil && items["enum"] != nil {
+				schema.enums[name] = asStrings(asList(items["enum"]))
+			}
+		}
+		for _, part := range asList(object["allOf"]) {
+			visit(part, depth+1)
+		}
+	}
+	visit(node, 0)
+	return schema
+}
+
+func (resolver *openAPIResolver) addBodyParameters(op *openAPIOperation, node interface{}) {
+	body := resolver.describeSchema(node)
+	for name := range body.properties {
+		op.parameters[name] = true
+	}
+	for name, enum := range body.enums {
+		op.enums[name] = enum
+	}
+}
+
+// normalizeYAML converts the map[interface{}]interface{} values produced by the YAML decoder to
+// map[string]interface{}.
+func normalizeYAML(node interface{}) interface{} {
+	switch v := node.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = normalizeYAML(value)
+		}
+		return m
+	case []interface{}:
+		for i, value := range v {
+			v[i] = normalizeYAML(value)
+		}
+	}
+	return node
+}
+
+func asMap(node interface{}) map[string]interface{} {
+	m, _ := node.(map[string]interface{})
+	return m
+}
+
+func asList(node interface{}) []interface{} {
+	l, _ := node.([]interface{})
+	return l
+}
+
+func asStrings(list []interface{}) []string {
+	values := make([]string, 0, len(list))
+	for _, value := range list {
+		if value != nil {
+			values = append(values, fmt.Sprint(value))
+		}
+	}
+	return values
+}
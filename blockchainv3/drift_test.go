@@ -0,0 +1,194 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+)
+
+const driftTestSpec = `
+openapi: 3.0.0
+info:
+  title: IBP console
+  version: 3.1.0
+paths:
+  /ak/api/v3/components/{component_id}:
+    get:
+      operationId: getComponent
+      parameters:
+        - name: component_id
+          in: path
+          required: true
+          schema:
+            type: string
+        - $ref: '#/components/parameters/DeploymentAttrs'
+        - name: parsed_certs
+          in: query
+          schema:
+            type: string
+            enum: [included, omitted]
+        - name: cache
+          in: query
+          schema:
+            type: string
+            enum: [skip]
+        - name: fields
+          in: query
+          schema:
+            type: string
+  /ak/api/v3/components/fabric-console:
+    post:
+      operationId: createConsole
+  /ak/api/v1/components:
+    get:
+      operationId: listComponentsV1
+components:
+  parameters:
+    DeploymentAttrs:
+      name: deployment_attrs
+      in: query
+      schema:
+        type: string
+        enum: [included, omitted, summary]
+  schemas:
+    Bccsp:
+      type: object
+      properties:
+        Default:
+          type: string
+          enum: [PKCS11, SW, IDEMIX]
+        SW:
+          $ref: '#/components/schemas/BccspSW'
+        PKCS11:
+          type: object
+        Idemix:
+          type: object
+    BccspSW:
+      allOf:
+        - type: object
+          properties:
+            Hash:
+              type: string
+        - type: object
+          properties:
+            Security:
+              type: number
+    ConsoleResponse:
+      type: object
+`
+
+var _ = Describe(`BlockchainV3 OpenAPI drift detection`, func() {
+	It(`Reports missing operations, new parameters, fields and enum changes`, func() {
+		report, err := blockchainv3.CompareOpenAPI([]byte(driftTestSpec))
+		Expect(err).To(BeNil())
+		Expect(report.HasDrift()).To(BeTrue())
+
+		Expect(report.MissingOperations).To(Equal([]blockchainv3.Operation{
+			{Name: "createConsole", Method: "POST", Path: "/ak/api/v3/components/fabric-console"},
+		}))
+		Expect(report.RemovedOperations).To(ContainElement(blockchainv3.Operation{Name: "ListComponents", Method: "GET", Path: "/ak/api/v3/components"}))
+		Expect(report.RemovedOperations).ToNot(ContainElement(blockchainv3.Operation{Name: "GetComponent", Method: "GET", Path: "/ak/api/v3/components/{component_id}"}))
+		Expect(report.MissingSchemas).To(Equal([]string{"ConsoleResponse"}))
+
+		Expect(report.NewParameters).To(Equal([]blockchainv3.DriftItem{{Operation: "GetComponent", Name: "component_id"}, {Operation: "GetComponent", Name: "fields"}}))
+		Expect(report.RemovedParameters).To(Equal([]blockchainv3.DriftItem{{Operation: "GetComponent", Name: "ca_attrs"}, {Operation: "GetComponent", Name: "id"}}))
+		Expect(report.NewFields).To(Equal([]blockchainv3.DriftItem{{Schema: "Bccsp", Name: "Idemix"}}))
+		Expect(report.RemovedFields).To(BeEmpty())
+		Expect(report.NewEnumValues).To(ConsistOf(
+			blockchainv3.DriftItem{Operation: "GetComponent", Name: "deployment_attrs", Value: "summary"},
+			blockchainv3.DriftItem{Schema: "Bccsp", Name: "Default", Value: "IDEMIX"},
+		))
+		Expect(report.RemovedEnumValues).To(Equal([]blockchainv3.DriftItem{{Operation: "GetComponent", Name: "cache", Value: "use"}}))
+
+		text := report.String()
+		Expect(text).To(ContainSubstring("Missing operations (1):\n  POST   /ak/api/v3/components/fabric-console (createConsole)\n"))
+		Expect(text).To(ContainSubstring("Bccsp.Default=IDEMIX"))
+	})
+	It(`Reads Swagger 2 documents in JSON`, func() {
+		spec := `{"swagger": "2.0", "basePath": "/ak/api/v3", "paths": {"/settings": {"put": {"operationId": "editSettings",
+			"parameters": [{"name": "body", "in": "body", "schema": {"$ref": "#/definitions/EditSettingsBody"}}]}}},
+			"definitions": {"EditSettingsBody": {"properties": {"max_req_per_min": {"type": "number"}, "theme": {"type": "string"}}}}}`
+		report, err := blockchainv3.CompareOpenAPI([]byte(spec))
+		Expect(err).To(BeNil())
+		Expect(report.MissingOperations).To(BeEmpty())
+		Expect(report.NewParameters).To(Equal([]blockchainv3.DriftItem{{Operation: "EditSettings", Name: "theme"}}))
+		Expect(report.RemovedParameters).To(ContainElement(blockchainv3.DriftItem{Operation: "EditSettings", Name: "file_logging"}))
+		Expect(report.MissingSchemas).To(Equal([]string{"EditSettingsBody"}))
+	})
+	It(`Rejects documents that are not OpenAPI`, func() {
+		_, err := blockchainv3.CompareOpenAPI([]byte(`{"components": []}`))
+		Expect(err).ToNot(BeNil())
+		_, err = blockchainv3.CompareOpenAPI([]byte(`: not yaml [`))
+		Expect(err).ToNot(BeNil())
+	})
+	It(`Fetches the console's document`, func() {
+		testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			Expect(req.URL.Path).To(Equal("/ak/api/v3/openapi"))
+			res.Header().Set("Content-type", "text/plain")
+			res.WriteHeader(200)
+			fmt.Fprint(res, driftTestSpec)
+		}))
+		defer testServer.Close()
+		blockchainService, serviceErr := blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(serviceErr).To(BeNil())
+		report, response, err := blockchainService.DetectDrift()
+		Expect(err).To(BeNil())
+		Expect(response.StatusCode).To(Equal(200))
+		Expect(report.MissingOperations).To(HaveLen(1))
+	})
+	It(`Lists every enum of the generated code`, func() {
+		file, err := parser.ParseFile(token.NewFileSet(), "blockchain_v3.go", nil, parser.ParseComments)
+		Expect(err).To(BeNil())
+		property := regexp.MustCompile(`^Constants associated with the (\w+)\.(\w+) property\.`)
+		count := 0
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST || gen.Doc == nil {
+				continue
+			}
+			match := property.FindStringSubmatch(gen.Doc.Text())
+			if match == nil {
+				continue
+			}
+			var values []string
+			for _, spec := range gen.Specs {
+				value, err := strconv.Unquote(spec.(*ast.ValueSpec).Values[0].(*ast.BasicLit).Value)
+				Expect(err).To(BeNil())
+				values = append(values, value)
+			}
+			Expect(blockchainv3.GetEnumValues(match[1], match[2])).To(Equal(values), match[0])
+			count++
+		}
+		Expect(count).To(BeNumerically(">", 0))
+		Expect(blockchainv3.GetEnumValues("Bccsp", "SW")).To(BeNil())
+	})
+})
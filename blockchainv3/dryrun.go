@@ -0,0 +1,166 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// DryRunResult : The request that an operation would have sent to the console. In dry-run mode every operation still
+// validates its options and builds its request, then returns a *DryRunResult as its error instead of sending it. Use
+// GetDryRunResult() to retrieve it.
+type DryRunResult struct {
+	// The name of the BlockchainV3 method that built the request, e.g. `UpdatePeer`. Empty if unknown.
+	Operation string `json:"operation,omitempty"`
+
+	// The HTTP method.
+	Method string `json:"method"`
+
+	// The full request URL, including query parameters.
+	URL string `json:"url"`
+
+	// The request headers. Credentials are redacted, and no authentication is performed in dry-run mode.
+	Headers http.Header `json:"headers"`
+
+	// The JSON request body with secrets (enroll secrets, private keys, passwords, pins) redacted. Nil if the request
+	// has no body.
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// Error implements the error interface.
+func (result *DryRunResult) Error() string {
+	return fmt.Sprintf("dry run: %s %s was not sent", result.Method, result.URL)
+}
+
+// GetDryRunResult returns the request rendered by an operation in dry-run mode. The boolean is false if err was not
+// produced by dry-run mode.
+func GetDryRunResult(err error) (result *DryRunResult, ok bool) {
+	ok = errors.As(err, &result)
+	return
+}
+
+// EnableDryRun turns on dry-run mode for every operation of this client. See DryRunResult.
+func (blockchain *BlockchainV3) EnableDryRun() {
+	atomic.StoreInt32(&blockchain.dryRunTransport().always, 1)
+}
+
+// DisableDryRun turns off client-wide dry-run mode. Contexts returned by DryRunContext() stay in dry-run mode.
+func (blockchain *BlockchainV3) DisableDryRun() {
+	if layer := blockchain.findTransportLayer(isDryRunTransport); layer != nil {
+		atomic.StoreInt32(&layer.(*dryRunTransport).always, 0)
+	}
+}
+
+// IsDryRun reports whether client-wide dry-run mode is on.
+func (blockchain *BlockchainV3) IsDryRun() bool {
+	layer := blockchain.findTransportLayer(isDryRunTransport)
+	return layer != nil && atomic.LoadInt32(&layer.(*dryRunTransport).always) == 1
+}
+
+// DryRunContext returns a copy of ctx that puts the operations of this client invoked with it (the *WithContext
+// methods) in dry-run mode.
+func (blockchain *BlockchainV3) DryRunContext(ctx context.Context) context.Context {
+	blockchain.dryRunTransport()
+	return context.WithValue(ctx, dryRunContextKey{}, true)
+}
+
+type dryRunContextKey struct{}
+
+// dryRunTransport returns the dry-run layer of the client, installing it when missing. The layer also wraps the
+// authenticator so that no token is requested for a request that will not be sent.
+func (blockchain *BlockchainV3) dryRunTransport() *dryRunTransport {
+	if layer := blockchain.findTransportLayer(isDryRunTransport); layer != nil {
+		return layer.(*dryRunTransport)
+	}
+	layer := &dryRunTransport{dryRunState: &dryRunState{}}
+	blockchain.addTransportLayer(layer)
+	blockchain.Service.Options.Authenticator = &skippingAuthenticator{
+		Authenticator: blockchain.Service.Options.Authenticator,
+		skip:          layer.active,
+	}
+	return layer
+}
+
+func isDryRunTransport(layer transportLayer) bool {
+	_, ok := layer.(*dryRunTransport)
+	return ok
+}
+
+// dryRunTransport is the transport layer that renders requests instead of sending them.
+type dryRunTransport struct {
+	transport http.RoundTripper
+
+	*dryRunState
+}
+
+// dryRunState is the client-wide mode of a dryRunTransport, shared by its clones.
+type dryRunState struct {
+	always int32
+}
+
+func (layer *dryRunTransport) rank() int {
+	return transportRankDryRun
+}
+
+func (layer *dryRunTransport) next() http.RoundTripper {
+	return layer.transport
+}
+
+func (layer *dryRunTransport) setNext(next http.RoundTripper) {
+	layer.transport = next
+}
+
+func (layer *dryRunTransport) clone() transportLayer {
+	copied := *layer
+	return &copied
+}
+
+func (layer *dryRunTransport) active(req *http.Request) bool {
+	if atomic.LoadInt32(&layer.always) == 1 {
+		return true
+	}
+	enabled, _ := req.Context().Value(dryRunContextKey{}).(bool)
+	return enabled
+}
+
+// RoundTrip returns a *DryRunResult error for requests in dry-run mode and forwards all others.
+func (layer *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !layer.active(req) {
+		return layer.transport.RoundTrip(req)
+	}
+	result := &DryRunResult{
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Headers: redactHeaders(req.Header),
+	}
+	if op, ok := LookupOperation(req.Method, req.URL.Path); ok {
+		result.Operation = op.Name
+	}
+	_, body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > 0 {
+		result.Body = redactJSON(body)
+	}
+	return nil, result
+}
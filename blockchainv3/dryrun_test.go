@@ -0,0 +1,136 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe(`BlockchainV3 dry-run mode`, func() {
+	var testServer *httptest.Server
+	var requestCount int
+	var blockchainService *blockchainv3.BlockchainV3
+
+	BeforeEach(func() {
+		requestCount = 0
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			requestCount++
+			res.Header().Set("Content-type", "application/json")
+			res.WriteHeader(200)
+			fmt.Fprintf(res, `{"id": "mypeer", "type": "fabric-peer"}`)
+		}))
+		var serviceErr error
+		blockchainService, serviceErr = blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL: testServer.URL,
+			Authenticator: &core.BearerTokenAuthenticator{
+				BearerToken: "my-token",
+			},
+		})
+		Expect(serviceErr).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Renders the request instead of sending it`, func() {
+		blockchainService.EnableDryRun()
+		Expect(blockchainService.IsDryRun()).To(BeTrue())
+
+		opts := blockchainService.NewUpdatePeerOptions("mypeer")
+		opts.SetVersion("2.2.1")
+		opts.SetCrypto(&blockchainv3.UpdatePeerBodyCrypto{
+			Msp: &blockchainv3.UpdateMspCryptoField{
+				Component: &blockchainv3.UpdateMspCryptoFieldComponent{
+					Ekey:  core.StringPtr("c2VjcmV0"),
+					Ecert: core.StringPtr("Y2VydA=="),
+				},
+			},
+		})
+		result, response, err := blockchainService.UpdatePeer(opts)
+		Expect(result).To(BeNil())
+		Expect(response).To(BeNil())
+		Expect(requestCount).To(Equal(0))
+
+		dryRun, ok := blockchainv3.GetDryRunResult(err)
+		Expect(ok).To(BeTrue())
+		Expect(dryRun.Operation).To(Equal("UpdatePeer"))
+		Expect(dryRun.Method).To(Equal("PUT"))
+		Expect(dryRun.URL).To(Equal(testServer.URL + "/ak/api/v3/kubernetes/components/fabric-peer/mypeer"))
+		Expect(dryRun.Headers.Get("Content-Type")).To(Equal("application/json"))
+		Expect(dryRun.Headers.Get("Authorization")).To(BeEmpty())
+
+		var body map[string]interface{}
+		Expect(json.Unmarshal(dryRun.Body, &body)).To(BeNil())
+		Expect(body["version"]).To(Equal("2.2.1"))
+		component := body["crypto"].(map[string]interface{})["msp"].(map[string]interface{})["component"].(map[string]interface{})
+		Expect(component["ekey"]).To(Equal(blockchainv3.RedactedValue))
+		Expect(component["ecert"]).To(Equal("Y2VydA=="))
+	})
+	It(`Still validates the options`, func() {
+		blockchainService.EnableDryRun()
+		_, _, err := blockchainService.DeleteComponentsByTag(&blockchainv3.DeleteComponentsByTagOptions{})
+		Expect(err).ToNot(BeNil())
+		_, ok := blockchainv3.GetDryRunResult(err)
+		Expect(ok).To(BeFalse())
+	})
+	It(`Renders gzip compressed bodies`, func() {
+		blockchainService.SetEnableGzipCompression(true)
+		blockchainService.EnableDryRun()
+		_, _, err := blockchainService.CreateOrderer(blockchainService.NewCreateOrdererOptions("raft", "osmsp", "ordering service", []blockchainv3.CryptoObject{}))
+		dryRun, ok := blockchainv3.GetDryRunResult(err)
+		Expect(ok).To(BeTrue())
+		Expect(dryRun.Operation).To(Equal("CreateOrderer"))
+		Expect(string(dryRun.Body)).To(ContainSubstring(`"display_name":"ordering service"`))
+	})
+	It(`Applies to a single context`, func() {
+		ctx := blockchainService.DryRunContext(context.Background())
+		_, _, err := blockchainService.DeleteComponentsByTagWithContext(ctx, blockchainService.NewDeleteComponentsByTagOptions("fabric-peer"))
+		dryRun, ok := blockchainv3.GetDryRunResult(err)
+		Expect(ok).To(BeTrue())
+		Expect(dryRun.Method).To(Equal("DELETE"))
+		Expect(dryRun.Body).To(BeNil())
+		Expect(requestCount).To(Equal(0))
+
+		_, _, err = blockchainService.DeleteComponentsByTag(blockchainService.NewDeleteComponentsByTagOptions("fabric-peer"))
+		Expect(err).To(BeNil())
+		Expect(requestCount).To(Equal(1))
+	})
+	It(`Sends requests again once disabled`, func() {
+		blockchainService.EnableDryRun()
+		blockchainService.DisableDryRun()
+		Expect(blockchainService.IsDryRun()).To(BeFalse())
+		_, _, err := blockchainService.GetComponent(blockchainService.NewGetComponentOptions("mypeer"))
+		Expect(err).To(BeNil())
+		Expect(requestCount).To(Equal(1))
+	})
+	It(`Is not answered by the response cache`, func() {
+		blockchainService.EnableDryRun()
+		blockchainService.EnableCache(nil)
+		_, _, err := blockchainService.ListComponents(blockchainService.NewListComponentsOptions())
+		_, ok := blockchainv3.GetDryRunResult(err)
+		Expect(ok).To(BeTrue())
+		Expect(blockchainService.GetCacheStats().Misses).To(BeZero())
+	})
+})
@@ -0,0 +1,70 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+// enumValues lists the values of every enum property of the models and options, keyed by "<Type>.<Field>" (Go names).
+// It mirrors the "Constants associated with" blocks of blockchain_v3.go and must be updated when the SDK is regenerated.
+var enumValues = map[string][]string{
+	"Bccsp.Default":                              {Bccsp_Default_Pkcs11, Bccsp_Default_Sw},
+	"CacheFlushResponse.Flushed":                 {CacheFlushResponse_Flushed_CouchCache, CacheFlushResponse_Flushed_IamCache, CacheFlushResponse_Flushed_ProxyCache, CacheFlushResponse_Flushed_SessionCache},
+	"ConfigCARegistryIdentitiesItem.Type":        {ConfigCARegistryIdentitiesItem_Type_Admin, ConfigCARegistryIdentitiesItem_Type_Client, ConfigCARegistryIdentitiesItem_Type_Orderer, ConfigCARegistryIdentitiesItem_Type_Peer, ConfigCARegistryIdentitiesItem_Type_User},
+	"ConfigCADb.Type":                            {ConfigCADb_Type_Mysql, ConfigCADb_Type_Postgres, ConfigCADb_Type_Sqlite3},
+	"ConfigOrdererMetricsStatsd.Network":         {ConfigOrdererMetricsStatsd_Network_Tcp, ConfigOrdererMetricsStatsd_Network_Udp},
+	"ConfigOrdererMetrics.Provider":              {ConfigOrdererMetrics_Provider_Disabled, ConfigOrdererMetrics_Provider_Prometheus, ConfigOrdererMetrics_Provider_Statsd},
+	"ConfigPeerChaincodeLogging.Level":           {ConfigPeerChaincodeLogging_Level_Debug, ConfigPeerChaincodeLogging_Level_Error, ConfigPeerChaincodeLogging_Level_Fatal, ConfigPeerChaincodeLogging_Level_Info, ConfigPeerChaincodeLogging_Level_Panic, ConfigPeerChaincodeLogging_Level_Warning},
+	"ConfigPeerChaincodeLogging.Shim":            {ConfigPeerChaincodeLogging_Shim_Debug, ConfigPeerChaincodeLogging_Shim_Error, ConfigPeerChaincodeLogging_Shim_Fatal, ConfigPeerChaincodeLogging_Shim_Info, ConfigPeerChaincodeLogging_Shim_Panic, ConfigPeerChaincodeLogging_Shim_Warning},
+	"CreateOrdererOptions.OrdererType":           {CreateOrdererOptions_OrdererType_Raft},
+	"CreatePeerOptions.StateDb":                  {CreatePeerOptions_StateDb_Couchdb, CreatePeerOptions_StateDb_Leveldb},
+	"GenericComponentResponse.Type":              {GenericComponentResponse_Type_FabricCa, GenericComponentResponse_Type_FabricOrderer, GenericComponentResponse_Type_FabricPeer},
+	"GetComponentOptions.DeploymentAttrs":        {GetComponentOptions_DeploymentAttrs_Included, GetComponentOptions_DeploymentAttrs_Omitted},
+	"GetComponentOptions.ParsedCerts":            {GetComponentOptions_ParsedCerts_Included, GetComponentOptions_ParsedCerts_Omitted},
+	"GetComponentOptions.Cache":                  {GetComponentOptions_Cache_Skip, GetComponentOptions_Cache_Use},
+	"GetComponentOptions.CaAttrs":                {GetComponentOptions_CaAttrs_Included, GetComponentOptions_CaAttrs_Omitted},
+	"GetComponentsByTagOptions.DeploymentAttrs":  {GetComponentsByTagOptions_DeploymentAttrs_Included, GetComponentsByTagOptions_DeploymentAttrs_Omitted},
+	"GetComponentsByTagOptions.ParsedCerts":      {GetComponentsByTagOptions_ParsedCerts_Included, GetComponentsByTagOptions_ParsedCerts_Omitted},
+	"GetComponentsByTagOptions.Cache":            {GetComponentsByTagOptions_Cache_Skip, GetComponentsByTagOptions_Cache_Use},
+	"GetComponentsByTypeOptions.Type":            {GetComponentsByTypeOptions_Type_FabricCa, GetComponentsByTypeOptions_Type_FabricOrderer, GetComponentsByTypeOptions_Type_FabricPeer, GetComponentsByTypeOptions_Type_Msp},
+	"GetComponentsByTypeOptions.DeploymentAttrs": {GetComponentsByTypeOptions_DeploymentAttrs_Included, GetComponentsByTypeOptions_DeploymentAttrs_Omitted},
+	"GetComponentsByTypeOptions.ParsedCerts":     {GetComponentsByTypeOptions_ParsedCerts_Included, GetComponentsByTypeOptions_ParsedCerts_Omitted},
+	"GetComponentsByTypeOptions.Cache":           {GetComponentsByTypeOptions_Cache_Skip, GetComponentsByTypeOptions_Cache_Use},
+	"GetFabVersionsOptions.Cache":                {GetFabVersionsOptions_Cache_Skip, GetFabVersionsOptions_Cache_Use},
+	"GetMspCertificateOptions.Cache":             {GetMspCertificateOptions_Cache_Skip, GetMspCertificateOptions_Cache_Use},
+	"GetPostmanOptions.AuthType":                 {GetPostmanOptions_AuthType_ApiKey, GetPostmanOptions_AuthType_Basic, GetPostmanOptions_AuthType_Bearer},
+	"ListComponentsOptions.DeploymentAttrs":      {ListComponentsOptions_DeploymentAttrs_Included, ListComponentsOptions_DeploymentAttrs_Omitted},
+	"ListComponentsOptions.ParsedCerts":          {ListComponentsOptions_ParsedCerts_Included, ListComponentsOptions_ParsedCerts_Omitted},
+	"ListComponentsOptions.Cache":                {ListComponentsOptions_Cache_Skip, ListComponentsOptions_Cache_Use},
+	"ListComponentsOptions.CaAttrs":              {ListComponentsOptions_CaAttrs_Included, ListComponentsOptions_CaAttrs_Omitted},
+	"LoggingSettingsClient.Level":                {LoggingSettingsClient_Level_Debug, LoggingSettingsClient_Level_Error, LoggingSettingsClient_Level_Info, LoggingSettingsClient_Level_Silly, LoggingSettingsClient_Level_Verbose, LoggingSettingsClient_Level_Warn},
+	"LoggingSettingsServer.Level":                {LoggingSettingsServer_Level_Debug, LoggingSettingsServer_Level_Error, LoggingSettingsServer_Level_Info, LoggingSettingsServer_Level_Silly, LoggingSettingsServer_Level_Verbose, LoggingSettingsServer_Level_Warn},
+	"Metrics.Provider":                           {Metrics_Provider_Disabled, Metrics_Provider_Prometheus, Metrics_Provider_Statsd},
+	"MetricsStatsd.Network":                      {MetricsStatsd_Network_Tcp, MetricsStatsd_Network_Udp},
+	"OrdererResponse.OrdererType":                {OrdererResponse_OrdererType_Raft},
+	"PeerResponse.StateDb":                       {PeerResponse_StateDb_Couchdb, PeerResponse_StateDb_Leveldb},
+	"SignatureCollection.Status":                 {SignatureCollection_Status_Closed, SignatureCollection_Status_Open},
+}
+
+// GetEnumValues returns the values the SDK defines for an enum property of a model or options struct, for example
+// GetEnumValues("Bccsp", "Default"). It returns nil if the property is not an enum.
+func GetEnumValues(typeName string, fieldName string) []string {
+	values, ok := enumValues[typeName+"."+fieldName]
+	if !ok {
+		return nil
+	}
+	list := make([]string, len(values))
+	copy(list, values)
+	return list
+}
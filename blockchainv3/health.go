@@ -0,0 +1,408 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultHealthMonitorInterval is the default time between two polls of a HealthMonitor.
+const DefaultHealthMonitorInterval = 30 * time.Second
+
+// Health metrics a HealthMonitor can watch. Utilisations are fractions between 0 and 1.
+const (
+	// Busy time of all CPUs of the console's host since the previous poll.
+	HealthMetric_CpuUtilization = "cpu_utilization"
+
+	// Used memory of the console's host, (total - free) / total.
+	HealthMetric_MemoryUtilization = "memory_utilization"
+
+	// 1 minute load average of the console's host, divided by the number of CPUs.
+	HealthMetric_LoadAverage = "load_average"
+
+	// Resident set size of the console process, in bytes.
+	HealthMetric_ProcessMemory = "process_memory"
+
+	// Used heap of the console process, heapUsed / heapTotal.
+	HealthMetric_HeapUtilization = "heap_utilization"
+)
+
+var healthMetrics = []string{
+	HealthMetric_CpuUtilization,
+	HealthMetric_MemoryUtilization,
+	HealthMetric_LoadAverage,
+	HealthMetric_ProcessMemory,
+	HealthMetric_HeapUtilization,
+}
+
+// memorySize matches the memory strings of the console, e.g. `56.1 MB` or `4.19 KiB`.
+var memorySize = regexp.MustCompile(`^\s*([0-9]+(?:\.[0-9]+)?)\s*([KMGTP]?i?B|bytes?)?\s*$`)
+
+// ParseMemorySize converts a memory string reported by the console, e.g. `31.7 GB` or `369.3 KB`, into bytes. The
+// console formats sizes in powers of 1024 whether the unit is spelled `KB` or `KiB`, so both are read as such.
+func ParseMemorySize(size string) (bytes int64, err error) {
+	match := memorySize.FindStringSubmatch(size)
+	if match == nil {
+		err = fmt.Errorf("invalid memory size %q", size)
+		return
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return
+	}
+	if unit := match[2]; unit != "" && !strings.HasPrefix(unit, "byte") {
+		value *= math.Pow(1024, float64(strings.Index("BKMGTP", unit[:1])))
+	}
+	bytes = int64(math.Round(value))
+	return
+}
+
+// HealthSample : One poll of the console's health, with the metrics derived from it.
+type HealthSample struct {
+	// When the sample was taken.
+	Time time.Time
+
+	// The response of GetHealth.
+	Stats *GetAthenaHealthStatsResponse
+
+	// The value of each metric of the sample, keyed by HealthMetric_*. Metrics that cannot be computed are missing,
+	// e.g. the CPU utilisation of the first sample or the load average on Windows.
+	Metrics map[string]float64
+
+	// Busy fraction of each CPU since the previous sample. Nil for the first sample and after the console restarted.
+	CpuUtilizations []float64
+
+	// Memory of the console's host, in bytes.
+	TotalMemory int64
+	FreeMemory  int64
+
+	// Memory of the console process, in bytes.
+	ProcessMemory int64
+	HeapTotal     int64
+	HeapUsed      int64
+}
+
+// HealthThresholdEvent : A metric crossed its threshold.
+type HealthThresholdEvent struct {
+	// The metric, one of HealthMetric_*.
+	Metric string
+
+	// The value of the metric in Sample.
+	Value float64
+
+	// The configured threshold.
+	Threshold float64
+
+	// True when the value went above the threshold, false when it went back to or below it.
+	Exceeded bool
+
+	Sample *HealthSample
+}
+
+// String describes the event.
+func (event *HealthThresholdEvent) String() string {
+	if event.Exceeded {
+		return fmt.Sprintf("%s %g exceeds %g", event.Metric, event.Value, event.Threshold)
+	}
+	return fmt.Sprintf("%s %g is back within %g", event.Metric, event.Value, event.Threshold)
+}
+
+// HealthMonitorOptions : The NewHealthMonitor options.
+type HealthMonitorOptions struct {
+	// Time between two polls. Defaults to DefaultHealthMonitorInterval.
+	Interval *time.Duration
+
+	// Thresholds keyed by HealthMetric_*.
+	Thresholds map[string]float64
+
+	// Called when a metric goes above its threshold, and again when it returns to or below it.
+	OnThreshold func(event *HealthThresholdEvent)
+
+	// Called with every sample.
+	OnSample func(sample *HealthSample)
+
+	// Called when a poll fails. Run keeps polling.
+	OnError func(err error)
+}
+
+// NewHealthMonitorOptions : Instantiate HealthMonitorOptions
+func (*BlockchainV3) NewHealthMonitorOptions() *HealthMonitorOptions {
+	return &HealthMonitorOptions{}
+}
+
+// SetInterval : Allow user to set Interval
+func (options *HealthMonitorOptions) SetInterval(interval time.Duration) *HealthMonitorOptions {
+	options.Interval = &interval
+	return options
+}
+
+// SetThreshold : Allow user to set the threshold of a metric
+func (options *HealthMonitorOptions) SetThreshold(metric string, threshold float64) *HealthMonitorOptions {
+	if options.Thresholds == nil {
+		options.Thresholds = map[string]float64{}
+	}
+	options.Thresholds[metric] = threshold
+	return options
+}
+
+// SetOnThreshold : Allow user to set OnThreshold
+func (options *HealthMonitorOptions) SetOnThreshold(onThreshold func(event *HealthThresholdEvent)) *HealthMonitorOptions {
+	options.OnThreshold = onThreshold
+	return options
+}
+
+// SetOnSample : Allow user to set OnSample
+func (options *HealthMonitorOptions) SetOnSample(onSample func(sample *HealthSample)) *HealthMonitorOptions {
+	options.OnSample = onSample
+	return options
+}
+
+// SetOnError : Allow user to set OnError
+func (options *HealthMonitorOptions) SetOnError(onError func(err error)) *HealthMonitorOptions {
+	options.OnError = onError
+	return options
+}
+
+// HealthMonitor polls GetHealth and reports when the console or its host run short of CPU or memory.
+type HealthMonitor struct {
+	blockchain *BlockchainV3
+	options    HealthMonitorOptions
+	interval   time.Duration
+
+	mutex    sync.Mutex
+	last     *HealthSample
+	exceeded map[string]bool
+}
+
+// NewHealthMonitor creates a monitor of the console of this client. Call Run to start polling, or Poll to take
+// samples on your own schedule.
+func (blockchain *BlockchainV3) NewHealthMonitor(options *HealthMonitorOptions) (monitor *HealthMonitor, err error) {
+	if options == nil {
+		options = blockchain.NewHealthMonitorOptions()
+	}
+	monitor = &HealthMonitor{
+		blockchain: blockchain,
+		options:    *options,
+		interval:   DefaultHealthMonitorInterval,
+		exceeded:   map[string]bool{},
+	}
+	if options.Interval != nil {
+		if *options.Interval <= 0 {
+			return nil, fmt.Errorf("the health monitor interval must be positive")
+		}
+		monitor.interval = *options.Interval
+	}
+	monitor.options.Thresholds = map[string]float64{}
+	for metric, threshold := range options.Thresholds {
+		if !isHealthMetric(metric) {
+			return nil, fmt.Errorf("unknown health metric %q, expected one of %s", metric, strings.Join(healthMetrics, ", "))
+		}
+		monitor.options.Thresholds[metric] = threshold
+	}
+	return
+}
+
+func isHealthMetric(metric string) bool {
+	for _, known := range healthMetrics {
+		if metric == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Run polls the console until ctx is done, then returns ctx.Err(). The first poll happens immediately. Failed polls
+// are passed to OnError.
+func (monitor *HealthMonitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(monitor.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := monitor.Poll(ctx); err != nil && ctx.Err() == nil && monitor.options.OnError != nil {
+			monitor.options.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll takes a sample, compares it with the thresholds and calls the callbacks.
+func (monitor *HealthMonitor) Poll(ctx context.Context) (sample *HealthSample, err error) {
+	stats, _, err := monitor.blockchain.GetHealthWithContext(ctx, monitor.blockchain.NewGetHealthOptions())
+	if err != nil {
+		return
+	}
+
+	monitor.mutex.Lock()
+	sample, err = newHealthSample(stats, monitor.last)
+	if err != nil {
+		monitor.mutex.Unlock()
+		return
+	}
+	monitor.last = sample
+	events := monitor.check(sample)
+	monitor.mutex.Unlock()
+
+	if monitor.options.OnSample != nil {
+		monitor.options.OnSample(sample)
+	}
+	if monitor.options.OnThreshold != nil {
+		for _, event := range events {
+			monitor.options.OnThreshold(event)
+		}
+	}
+	return
+}
+
+// Last returns the latest sample, or nil before the first successful poll.
+func (monitor *HealthMonitor) Last() *HealthSample {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+	return monitor.last
+}
+
+// check returns the metrics of sample that crossed their threshold since the previous sample, sorted by metric.
+func (monitor *HealthMonitor) check(sample *HealthSample) (events []*HealthThresholdEvent) {
+	for metric, threshold := range monitor.options.Thresholds {
+		value, ok := sample.Metrics[metric]
+		if !ok {
+			continue
+		}
+		exceeded := value > threshold
+		if exceeded == monitor.exceeded[metric] {
+			continue
+		}
+		monitor.exceeded[metric] = exceeded
+		events = append(events, &HealthThresholdEvent{
+			Metric:    metric,
+			Value:     value,
+			Threshold: threshold,
+			Exceeded:  exceeded,
+			Sample:    sample,
+		})
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Metric < events[j].Metric })
+	return
+}
+
+// newHealthSample derives the metrics of stats. The CPU utilisation is computed from the CPU times of previous.
+func newHealthSample(stats *GetAthenaHealthStatsResponse, previous *HealthSample) (sample *HealthSample, err error) {
+	sample = &HealthSample{
+		Time:    time.Now(),
+		Stats:   stats,
+		Metrics: map[string]float64{},
+	}
+	parse := func(size *string, bytes *int64) bool {
+		if size == nil || err != nil {
+			return false
+		}
+		*bytes, err = ParseMemorySize(*size)
+		return err == nil
+	}
+
+	if host := stats.OS; host != nil {
+		if parse(host.TotalMemory, &sample.TotalMemory) && parse(host.FreeMemory, &sample.FreeMemory) && sample.TotalMemory > 0 {
+			sample.Metrics[HealthMetric_MemoryUtilization] = float64(sample.TotalMemory-sample.FreeMemory) / float64(sample.TotalMemory)
+		}
+		// the console reports [0, 0, 0] on Windows
+		if len(host.Loadavg) > 0 && len(host.Cpus) > 0 && (host.Type == nil || *host.Type != "Windows_NT") {
+			sample.Metrics[HealthMetric_LoadAverage] = host.Loadavg[0] / float64(len(host.Cpus))
+		}
+		if previous != nil && sameConsoleProcess(stats, previous.Stats) {
+			sample.CpuUtilizations, sample.Metrics[HealthMetric_CpuUtilization] = cpuUtilization(previous.Stats.OS, host)
+			if sample.CpuUtilizations == nil {
+				delete(sample.Metrics, HealthMetric_CpuUtilization)
+			}
+		}
+	}
+	if optools := stats.OPTOOLS; optools != nil && optools.MemoryUsage != nil {
+		if parse(optools.MemoryUsage.Rss, &sample.ProcessMemory) {
+			sample.Metrics[HealthMetric_ProcessMemory] = float64(sample.ProcessMemory)
+		}
+		if parse(optools.MemoryUsage.HeapTotal, &sample.HeapTotal) && parse(optools.MemoryUsage.HeapUsed, &sample.HeapUsed) && sample.HeapTotal > 0 {
+			sample.Metrics[HealthMetric_HeapUtilization] = float64(sample.HeapUsed) / float64(sample.HeapTotal)
+		}
+	}
+	if err != nil {
+		sample = nil
+	}
+	return
+}
+
+// sameConsoleProcess reports whether two responses come from the same console process, so that their CPU times can be
+// compared.
+func sameConsoleProcess(stats *GetAthenaHealthStatsResponse, previous *GetAthenaHealthStatsResponse) bool {
+	if previous == nil || previous.OS == nil {
+		return false
+	}
+	if stats.OPTOOLS == nil || previous.OPTOOLS == nil || stats.OPTOOLS.InstanceID == nil || previous.OPTOOLS.InstanceID == nil {
+		return true
+	}
+	return *stats.OPTOOLS.InstanceID == *previous.OPTOOLS.InstanceID
+}
+
+// cpuUtilization computes the busy fraction of each CPU, and of all CPUs, between two samples of CPU times. It returns
+// nil if the samples cannot be compared, e.g. because the host rebooted or the number of CPUs changed.
+func cpuUtilization(previous *GetAthenaHealthStatsResponseOS, current *GetAthenaHealthStatsResponseOS) (perCpu []float64, total float64) {
+	if len(previous.Cpus) != len(current.Cpus) || len(current.Cpus) == 0 {
+		return nil, 0
+	}
+	var busyTotal, allTotal float64
+	perCpu = make([]float64, len(current.Cpus))
+	for i := range current.Cpus {
+		busyBefore, idleBefore := cpuTimes(previous.Cpus[i].Times)
+		busyAfter, idleAfter := cpuTimes(current.Cpus[i].Times)
+		busy, idle := busyAfter-busyBefore, idleAfter-idleBefore
+		if busy < 0 || idle < 0 {
+			return nil, 0
+		}
+		if busy+idle > 0 {
+			perCpu[i] = busy / (busy + idle)
+		}
+		busyTotal += busy
+		allTotal += busy + idle
+	}
+	if allTotal > 0 {
+		total = busyTotal / allTotal
+	}
+	return
+}
+
+func cpuTimes(times *CpuHealthStatsTimes) (busy float64, idle float64) {
+	if times == nil {
+		return
+	}
+	for _, value := range []*float64{times.User, times.Nice, times.Sys, times.Irq} {
+		if value != nil {
+			busy += *value
+		}
+	}
+	if times.Idle != nil {
+		idle = *times.Idle
+	}
+	return
+}
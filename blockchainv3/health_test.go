@@ -0,0 +1,175 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe(`BlockchainV3 health monitor`, func() {
+	var testServer *httptest.Server
+	var samples []string
+	var blockchainService *blockchainv3.BlockchainV3
+
+	// healthStats renders a GetHealth response with two CPUs that spent busy and idle ms since boot
+	healthStats := func(instance string, busy, idle float64, free string, rss string) string {
+		return fmt.Sprintf(`{"OPTOOLS": {"instance_id": "%s", "memory_usage": {"rss": "%s", "heapTotal": "100 MB", "heapUsed": "50 MB"}},
+			"OS": {"type": "Linux", "loadavg": [3, 2, 1], "total_memory": "8 GB", "free_memory": "%s",
+			"cpus": [{"times": {"idle": %g, "irq": 0, "nice": 0, "sys": 0, "user": %g}}, {"times": {"idle": %g, "irq": 0, "nice": 0, "sys": %g, "user": 0}}]}}`,
+			instance, rss, free, idle, busy, idle, busy)
+	}
+
+	BeforeEach(func() {
+		samples = nil
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			Expect(req.URL.Path).To(Equal("/ak/api/v3/health"))
+			if len(samples) == 0 {
+				res.WriteHeader(503)
+				return
+			}
+			res.Header().Set("Content-type", "application/json")
+			res.WriteHeader(200)
+			fmt.Fprint(res, samples[0])
+			samples = samples[1:]
+		}))
+		var serviceErr error
+		blockchainService, serviceErr = blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(serviceErr).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Parses memory sizes`, func() {
+		for size, bytes := range map[string]int64{
+			"512":      512,
+			"12 bytes": 12,
+			"369.3 KB": 378163,
+			"4.19 KiB": 4291,
+			"56.1 MB":  58825114,
+			"31.7 GB":  34037615821,
+			"1.5TB":    1649267441664,
+			" 0 B ":    0,
+		} {
+			parsed, err := blockchainv3.ParseMemorySize(size)
+			Expect(err).To(BeNil())
+			Expect(parsed).To(Equal(bytes), size)
+		}
+		_, err := blockchainv3.ParseMemorySize("lots")
+		Expect(err).ToNot(BeNil())
+		_, err = blockchainv3.ParseMemorySize("3 XB")
+		Expect(err).ToNot(BeNil())
+	})
+	It(`Computes the metrics of successive samples`, func() {
+		samples = []string{
+			healthStats("a", 1000, 9000, "6 GB", "200 MB"),
+			healthStats("a", 1750, 9250, "2 GB", "200 MB"),
+			healthStats("b", 10, 90, "2 GB", "200 MB"),
+		}
+		monitor, err := blockchainService.NewHealthMonitor(nil)
+		Expect(err).To(BeNil())
+
+		first, err := monitor.Poll(context.Background())
+		Expect(err).To(BeNil())
+		Expect(first.TotalMemory).To(Equal(int64(8 << 30)))
+		Expect(first.Metrics).To(Equal(map[string]float64{
+			blockchainv3.HealthMetric_MemoryUtilization: 0.25,
+			blockchainv3.HealthMetric_LoadAverage:       1.5,
+			blockchainv3.HealthMetric_ProcessMemory:     200 << 20,
+			blockchainv3.HealthMetric_HeapUtilization:   0.5,
+		}))
+		Expect(first.CpuUtilizations).To(BeNil())
+
+		second, err := monitor.Poll(context.Background())
+		Expect(err).To(BeNil())
+		Expect(second.CpuUtilizations).To(Equal([]float64{0.75, 0.75}))
+		Expect(second.Metrics[blockchainv3.HealthMetric_CpuUtilization]).To(Equal(0.75))
+		Expect(second.Metrics[blockchainv3.HealthMetric_MemoryUtilization]).To(Equal(0.75))
+		Expect(monitor.Last()).To(Equal(second))
+
+		// the console restarted, its CPU times cannot be compared
+		third, err := monitor.Poll(context.Background())
+		Expect(err).To(BeNil())
+		Expect(third.CpuUtilizations).To(BeNil())
+		Expect(third.Metrics).ToNot(HaveKey(blockchainv3.HealthMetric_CpuUtilization))
+	})
+	It(`Fires callbacks when thresholds are crossed`, func() {
+		samples = []string{
+			healthStats("a", 0, 1000, "6 GB", "200 MB"),
+			healthStats("a", 900, 1100, "1 GB", "600 MB"),
+			healthStats("a", 1800, 1200, "1 GB", "600 MB"),
+			healthStats("a", 1850, 2150, "6 GB", "200 MB"),
+		}
+		var events []string
+		options := blockchainService.NewHealthMonitorOptions().
+			SetThreshold(blockchainv3.HealthMetric_CpuUtilization, 0.8).
+			SetThreshold(blockchainv3.HealthMetric_MemoryUtilization, 0.8).
+			SetThreshold(blockchainv3.HealthMetric_ProcessMemory, 512<<20).
+			SetOnThreshold(func(event *blockchainv3.HealthThresholdEvent) {
+				events = append(events, event.String())
+			})
+		monitor, err := blockchainService.NewHealthMonitor(options)
+		Expect(err).To(BeNil())
+
+		for i := 0; i < 4; i++ {
+			_, err = monitor.Poll(context.Background())
+			Expect(err).To(BeNil())
+		}
+		Expect(events).To(Equal([]string{
+			"cpu_utilization 0.9 exceeds 0.8",
+			"memory_utilization 0.875 exceeds 0.8",
+			"process_memory 6.291456e+08 exceeds 5.36870912e+08",
+			"cpu_utilization 0.05 is back within 0.8",
+			"memory_utilization 0.25 is back within 0.8",
+			"process_memory 2.097152e+08 is back within 5.36870912e+08",
+		}))
+	})
+	It(`Polls until the context is done`, func() {
+		samples = []string{healthStats("a", 0, 1000, "6 GB", "200 MB")}
+		var sampled, failed int
+		options := blockchainService.NewHealthMonitorOptions().
+			SetInterval(10 * time.Millisecond).
+			SetOnSample(func(*blockchainv3.HealthSample) { sampled++ }).
+			SetOnError(func(error) { failed++ })
+		monitor, err := blockchainService.NewHealthMonitor(options)
+		Expect(err).To(BeNil())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		Expect(monitor.Run(ctx)).To(Equal(context.DeadlineExceeded))
+		Expect(sampled).To(Equal(1))
+		Expect(failed).To(BeNumerically(">", 0))
+	})
+	It(`Validates the options`, func() {
+		_, err := blockchainService.NewHealthMonitor(blockchainService.NewHealthMonitorOptions().SetInterval(0))
+		Expect(err).ToNot(BeNil())
+		_, err = blockchainService.NewHealthMonitor(blockchainService.NewHealthMonitorOptions().SetThreshold("disk", 1))
+		Expect(err).ToNot(BeNil())
+	})
+})
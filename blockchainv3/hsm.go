@@ -0,0 +1,248 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"fmt"
+	"github.com/IBM/go-sdk-core/v4/core"
+	"net/url"
+)
+
+// Constants associated with the HsmProfile.Hash property.
+const (
+	HsmProfile_Hash_Sha2 = "SHA2"
+	HsmProfile_Hash_Sha3 = "SHA3"
+)
+
+// Defaults of the HsmProfile.Hash and HsmProfile.Security properties, the defaults of Fabric.
+const (
+	HsmProfile_Hash_Default     = HsmProfile_Hash_Sha2
+	HsmProfile_Security_Default = 256
+)
+
+// HsmProfile : The settings of a PKCS #11 HSM (Hardware Security Module) that peers, orderers and CAs keep their keys
+// in. A node is only set up for an HSM if it has both the `hsm` connection details and a PKCS11 BCCSP in its config
+// override, the profile sets both consistently.
+//
+// The PKCS #11 library is not part of the profile: the library of the HSM is configured on the cluster the console
+// deploys to, the config overrides of the console have no library setting.
+type HsmProfile struct {
+	// The url to the HSM. Include the protocol, hostname, and port.
+	Pkcs11endpoint *string `validate:"required"`
+
+	// The label of the token (slot) of the HSM.
+	Label *string `validate:"required"`
+
+	// The user PIN of the token.
+	Pin *string `validate:"required"`
+
+	// The hash family, one of HsmProfile_Hash_*. Defaults to HsmProfile_Hash_Default.
+	Hash *string
+
+	// The length of hash, 256 or 384. Defaults to HsmProfile_Security_Default.
+	Security *float64
+}
+
+// NewHsmProfile : Instantiate HsmProfile
+func (*BlockchainV3) NewHsmProfile(pkcs11endpoint string, label string, pin string) *HsmProfile {
+	return &HsmProfile{
+		Pkcs11endpoint: core.StringPtr(pkcs11endpoint),
+		Label:          core.StringPtr(label),
+		Pin:            core.StringPtr(pin),
+	}
+}
+
+// SetPkcs11endpoint : Allow user to set Pkcs11endpoint
+func (profile *HsmProfile) SetPkcs11endpoint(pkcs11endpoint string) *HsmProfile {
+	profile.Pkcs11endpoint = core.StringPtr(pkcs11endpoint)
+	return profile
+}
+
+// SetLabel : Allow user to set Label
+func (profile *HsmProfile) SetLabel(label string) *HsmProfile {
+	profile.Label = core.StringPtr(label)
+	return profile
+}
+
+// SetPin : Allow user to set Pin
+func (profile *HsmProfile) SetPin(pin string) *HsmProfile {
+	profile.Pin = core.StringPtr(pin)
+	return profile
+}
+
+// SetHash : Allow user to set Hash
+func (profile *HsmProfile) SetHash(hash string) *HsmProfile {
+	profile.Hash = core.StringPtr(hash)
+	return profile
+}
+
+// SetSecurity : Allow user to set Security
+func (profile *HsmProfile) SetSecurity(security float64) *HsmProfile {
+	profile.Security = core.Float64Ptr(security)
+	return profile
+}
+
+// Validate checks that the profile has an endpoint url, a label and a PIN, and that its hash settings are settings
+// Fabric supports.
+func (profile *HsmProfile) Validate() error {
+	err := core.ValidateStruct(profile, "hsmProfile")
+	if err != nil {
+		return err
+	}
+	if *profile.Label == "" {
+		return fmt.Errorf("the HSM profile needs a token label")
+	}
+	if *profile.Pin == "" {
+		return fmt.Errorf("the HSM profile needs a PIN")
+	}
+	endpoint, err := url.Parse(*profile.Pkcs11endpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return fmt.Errorf("the HSM endpoint %q is not a url with a protocol, hostname and port", *profile.Pkcs11endpoint)
+	}
+	if profile.Hash != nil && *profile.Hash != HsmProfile_Hash_Sha2 && *profile.Hash != HsmProfile_Hash_Sha3 {
+		return fmt.Errorf("the HSM hash family %q is not %s or %s", *profile.Hash, HsmProfile_Hash_Sha2, HsmProfile_Hash_Sha3)
+	}
+	if profile.Security != nil && *profile.Security != 256 && *profile.Security != 384 {
+		return fmt.Errorf("the HSM security level %v is not 256 or 384", *profile.Security)
+	}
+	return nil
+}
+
+// Hsm returns the connection details of the HSM of the profile.
+func (profile *HsmProfile) Hsm() (*Hsm, error) {
+	err := profile.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &Hsm{Pkcs11endpoint: core.StringPtr(*profile.Pkcs11endpoint)}, nil
+}
+
+// Bccsp returns the PKCS11 BCCSP of the profile, the default BCCSP of the nodes it is applied to.
+func (profile *HsmProfile) Bccsp() (*Bccsp, error) {
+	err := profile.Validate()
+	if err != nil {
+		return nil, err
+	}
+	hash := HsmProfile_Hash_Default
+	if profile.Hash != nil {
+		hash = *profile.Hash
+	}
+	security := float64(HsmProfile_Security_Default)
+	if profile.Security != nil {
+		security = *profile.Security
+	}
+	return &Bccsp{
+		Default: core.StringPtr(Bccsp_Default_Pkcs11),
+		PKCS11: &BccspPKCS11{
+			Label:    core.StringPtr(*profile.Label),
+			Pin:      core.StringPtr(*profile.Pin),
+			Hash:     core.StringPtr(hash),
+			Security: core.Float64Ptr(security),
+		},
+	}, nil
+}
+
+// ApplyToCreatePeerOptions sets the HSM of the profile on the options of a new peer, and the BCCSP of the profile in
+// their config override. The rest of the config override is kept.
+func (profile *HsmProfile) ApplyToCreatePeerOptions(options *CreatePeerOptions) error {
+	hsm, bccsp, err := profile.settings()
+	if err != nil {
+		return err
+	}
+	if options.ConfigOverride == nil {
+		options.ConfigOverride = &ConfigPeerCreate{}
+	}
+	if options.ConfigOverride.Peer == nil {
+		options.ConfigOverride.Peer = &ConfigPeerCreatePeer{}
+	}
+	options.ConfigOverride.Peer.BCCSP = bccsp
+	options.Hsm = hsm
+	return nil
+}
+
+// ApplyToCreateOrdererOptions sets the HSM of the profile on the options of new orderers, and the BCCSP of the
+// profile in the config override of every node. Options without config overrides get one per node. The rest of the
+// config overrides is kept.
+func (profile *HsmProfile) ApplyToCreateOrdererOptions(options *CreateOrdererOptions) error {
+	hsm, bccsp, err := profile.settings()
+	if err != nil {
+		return err
+	}
+	if len(options.Crypto) == 0 {
+		return fmt.Errorf("the options have no orderer nodes, set their crypto first")
+	}
+	if len(options.ConfigOverride) == 0 {
+		options.ConfigOverride = make([]ConfigOrdererCreate, len(options.Crypto))
+	}
+	if len(options.ConfigOverride) != len(options.Crypto) {
+		return fmt.Errorf("the options have %d config overrides for %d orderer nodes", len(options.ConfigOverride), len(options.Crypto))
+	}
+	for i := range options.ConfigOverride {
+		override := &options.ConfigOverride[i]
+		if override.General == nil {
+			override.General = &ConfigOrdererGeneral{}
+		}
+		if i > 0 {
+			// every node gets its own copy
+			bccsp, _ = profile.Bccsp()
+		}
+		override.General.BCCSP = bccsp
+	}
+	options.Hsm = hsm
+	return nil
+}
+
+// ApplyToCreateCaOptions sets the HSM of the profile on the options of a new CA, and the BCCSP of the profile in the
+// config override of the CA and, if the options have one, of the TLS CA. The rest of the config override is kept.
+//
+// The config override of a CA needs a registry; if the options have no config override yet, the CA override is
+// created without one and CreateCa fails until a registry is set.
+func (profile *HsmProfile) ApplyToCreateCaOptions(options *CreateCaOptions) error {
+	hsm, bccsp, err := profile.settings()
+	if err != nil {
+		return err
+	}
+	if options.ConfigOverride == nil {
+		options.ConfigOverride = &CreateCaBodyConfigOverride{}
+	}
+	if options.ConfigOverride.Ca == nil {
+		options.ConfigOverride.Ca = &ConfigCACreate{}
+	}
+	options.ConfigOverride.Ca.BCCSP = bccsp
+	if options.ConfigOverride.Tlsca != nil {
+		tlsBccsp, err := profile.Bccsp()
+		if err != nil {
+			return err
+		}
+		options.ConfigOverride.Tlsca.BCCSP = tlsBccsp
+	}
+	options.Hsm = hsm
+	return nil
+}
+
+// settings returns the HSM and the BCCSP of the profile.
+func (profile *HsmProfile) settings() (*Hsm, *Bccsp, error) {
+	hsm, err := profile.Hsm()
+	if err != nil {
+		return nil, nil, err
+	}
+	bccsp, err := profile.Bccsp()
+	if err != nil {
+		return nil, nil, err
+	}
+	return hsm, bccsp, nil
+}
@@ -0,0 +1,151 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe(`BlockchainV3 HSM profiles`, func() {
+	var blockchainService *blockchainv3.BlockchainV3
+	var profile *blockchainv3.HsmProfile
+	BeforeEach(func() {
+		var err error
+		blockchainService, err = blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL:           "http://localhost",
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+		profile = blockchainService.NewHsmProfile("tcp://hsm.example.com:2345", "root", "98765432")
+	})
+
+	It(`Validates the profile`, func() {
+		Expect(profile.Validate()).To(BeNil())
+		for _, invalid := range []*blockchainv3.HsmProfile{
+			{Label: core.StringPtr("root"), Pin: core.StringPtr("1234")},
+			blockchainService.NewHsmProfile("hsm.example.com", "root", "1234"),
+			blockchainService.NewHsmProfile("tcp://hsm.example.com:2345", "", "1234"),
+			blockchainService.NewHsmProfile("tcp://hsm.example.com:2345", "root", ""),
+			blockchainService.NewHsmProfile("tcp://hsm.example.com:2345", "root", "1234").SetHash("MD5"),
+			blockchainService.NewHsmProfile("tcp://hsm.example.com:2345", "root", "1234").SetSecurity(128),
+		} {
+			Expect(invalid.Validate()).ToNot(BeNil())
+			_, err := invalid.Bccsp()
+			Expect(err).ToNot(BeNil())
+			Expect(invalid.ApplyToCreatePeerOptions(&blockchainv3.CreatePeerOptions{})).ToNot(BeNil())
+		}
+	})
+	It(`Makes a PKCS11 BCCSP with the defaults of Fabric`, func() {
+		bccsp, err := profile.Bccsp()
+		Expect(err).To(BeNil())
+		Expect(*bccsp.Default).To(Equal(blockchainv3.Bccsp_Default_Pkcs11))
+		Expect(bccsp.SW).To(BeNil())
+		Expect(*bccsp.PKCS11).To(Equal(blockchainv3.BccspPKCS11{
+			Label:    core.StringPtr("root"),
+			Pin:      core.StringPtr("98765432"),
+			Hash:     core.StringPtr("SHA2"),
+			Security: core.Float64Ptr(256),
+		}))
+		profile.SetHash(blockchainv3.HsmProfile_Hash_Sha3).SetSecurity(384)
+		bccsp, err = profile.Bccsp()
+		Expect(err).To(BeNil())
+		Expect(*bccsp.PKCS11.Hash).To(Equal("SHA3"))
+		Expect(*bccsp.PKCS11.Security).To(Equal(float64(384)))
+	})
+	It(`Applies to peers and keeps their config override`, func() {
+		options := blockchainService.NewCreatePeerOptions("org1", "peer", &blockchainv3.CryptoObject{})
+		options.ConfigOverride = &blockchainv3.ConfigPeerCreate{
+			Peer: &blockchainv3.ConfigPeerCreatePeer{
+				ID:    core.StringPtr("peer1"),
+				BCCSP: &blockchainv3.Bccsp{Default: core.StringPtr("SW"), SW: &blockchainv3.BccspSW{}},
+			},
+		}
+		Expect(profile.ApplyToCreatePeerOptions(options)).To(BeNil())
+		Expect(*options.Hsm.Pkcs11endpoint).To(Equal("tcp://hsm.example.com:2345"))
+		Expect(*options.ConfigOverride.Peer.ID).To(Equal("peer1"))
+		Expect(*options.ConfigOverride.Peer.BCCSP.Default).To(Equal("PKCS11"))
+		Expect(options.ConfigOverride.Peer.BCCSP.SW).To(BeNil())
+		Expect(*options.ConfigOverride.Peer.BCCSP.PKCS11.Label).To(Equal("root"))
+
+		options = blockchainService.NewCreatePeerOptions("org1", "peer", &blockchainv3.CryptoObject{})
+		Expect(profile.ApplyToCreatePeerOptions(options)).To(BeNil())
+		Expect(*options.ConfigOverride.Peer.BCCSP.PKCS11.Pin).To(Equal("98765432"))
+	})
+	It(`Applies to every orderer node`, func() {
+		options := blockchainService.NewCreateOrdererOptions("raft", "ordererorg", "orderer", []blockchainv3.CryptoObject{{}, {}, {}})
+		Expect(profile.ApplyToCreateOrdererOptions(options)).To(BeNil())
+		Expect(options.ConfigOverride).To(HaveLen(3))
+		for _, override := range options.ConfigOverride {
+			Expect(*override.General.BCCSP.Default).To(Equal("PKCS11"))
+			Expect(*override.General.BCCSP.PKCS11.Label).To(Equal("root"))
+		}
+		Expect(options.ConfigOverride[0].General.BCCSP).ToNot(BeIdenticalTo(options.ConfigOverride[1].General.BCCSP))
+		Expect(options.Hsm).ToNot(BeNil())
+
+		options.ConfigOverride = options.ConfigOverride[:2]
+		err := profile.ApplyToCreateOrdererOptions(options)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("the options have 2 config overrides for 3 orderer nodes"))
+
+		options = blockchainService.NewCreateOrdererOptions("raft", "ordererorg", "orderer", nil)
+		err = profile.ApplyToCreateOrdererOptions(options)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("the options have no orderer nodes, set their crypto first"))
+		Expect(options.Hsm).To(BeNil())
+		Expect(options.ConfigOverride).To(BeNil())
+	})
+	It(`Applies to CAs and their TLS CA`, func() {
+		var body map[string]interface{}
+		testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			data, err := ioutil.ReadAll(req.Body)
+			Expect(err).To(BeNil())
+			Expect(json.Unmarshal(data, &body)).To(BeNil())
+			res.Header().Set("Content-type", "application/json")
+			res.WriteHeader(200)
+			fmt.Fprintf(res, `{"id": "ca1"}`)
+		}))
+		defer testServer.Close()
+		Expect(blockchainService.SetServiceURL(testServer.URL)).To(BeNil())
+
+		registry := &blockchainv3.ConfigCARegistry{Maxenrollments: core.Float64Ptr(-1), Identities: []blockchainv3.ConfigCARegistryIdentitiesItem{}}
+		options := blockchainService.NewCreateCaOptions("ca", &blockchainv3.CreateCaBodyConfigOverride{
+			Ca:    &blockchainv3.ConfigCACreate{Registry: registry},
+			Tlsca: &blockchainv3.ConfigCACreate{Registry: registry},
+		})
+		Expect(profile.ApplyToCreateCaOptions(options)).To(BeNil())
+		_, _, err := blockchainService.CreateCa(options)
+		Expect(err).To(BeNil())
+		Expect(body["hsm"]).To(Equal(map[string]interface{}{"pkcs11endpoint": "tcp://hsm.example.com:2345"}))
+		override := body["config_override"].(map[string]interface{})
+		for _, ca := range []string{"ca", "tlsca"} {
+			Expect(override[ca].(map[string]interface{})["BCCSP"]).To(Equal(map[string]interface{}{
+				"Default": "PKCS11",
+				"PKCS11":  map[string]interface{}{"Label": "root", "Pin": "98765432", "Hash": "SHA2", "Security": float64(256)},
+			}))
+			Expect(override[ca].(map[string]interface{})["registry"]).ToNot(BeNil())
+		}
+	})
+})
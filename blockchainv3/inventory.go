@@ -0,0 +1,521 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/IBM/go-sdk-core/v4/core"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Constants associated with the InventoryOptions.GroupBy property.
+const (
+	InventoryOptions_GroupBy_ClusterID = "cluster_id"
+	InventoryOptions_GroupBy_MspID     = "msp_id"
+)
+
+// inventoryColumn is a column of an inventory and how to read it from a component. Columns of quantities and times
+// have a sort key, so that they sort by amount rather than by their text; the key is false when the component has no
+// value or the value cannot be parsed.
+type inventoryColumn struct {
+	name    string
+	value   func(*GenericComponentResponse) string
+	sortKey func(*GenericComponentResponse) (int64, bool)
+}
+
+// inventoryColumns lists the columns of an inventory. Resource and storage columns are generated for every
+// subcomponent, e.g. `resources.peer.requests.cpu` and `storage.statedb.size`.
+var inventoryColumns = buildInventoryColumns()
+
+// defaultInventoryColumns are the columns of an inventory when none are selected, followed by the resource and
+// storage columns that have a value in at least one row.
+var defaultInventoryColumns = []string{"id", "type", "display_name", "msp_id", "cluster_id", "version", "zone", "tags", "created"}
+
+func buildInventoryColumns() (columns []inventoryColumn) {
+	text := func(value func(*GenericComponentResponse) *string) func(*GenericComponentResponse) string {
+		return func(component *GenericComponentResponse) string {
+			if s := value(component); s != nil {
+				return *s
+			}
+			return ""
+		}
+	}
+	// quantity returns the sort key of a quantity column
+	quantity := func(value func(*GenericComponentResponse) *string, parse func(string) (int64, error)) func(*GenericComponentResponse) (int64, bool) {
+		return func(component *GenericComponentResponse) (int64, bool) {
+			s := value(component)
+			if s == nil {
+				return 0, false
+			}
+			amount, err := parse(*s)
+			return amount, err == nil
+		}
+	}
+	parseCpu := func(s string) (int64, error) {
+		amount, err := ParseCpuQuantity(s)
+		return int64(amount), err
+	}
+	parseMemory := func(s string) (int64, error) {
+		amount, err := ParseMemoryQuantity(s)
+		return int64(amount), err
+	}
+	parseStorage := func(s string) (int64, error) {
+		amount, err := ParseStorageQuantity(s)
+		return int64(amount), err
+	}
+
+	columns = []inventoryColumn{
+		{"id", text(func(c *GenericComponentResponse) *string { return c.ID }), nil},
+		{"type", text(func(c *GenericComponentResponse) *string { return c.Type }), nil},
+		{"display_name", text(func(c *GenericComponentResponse) *string { return c.DisplayName }), nil},
+		{"msp_id", text(func(c *GenericComponentResponse) *string { return c.MspID }), nil},
+		{"cluster_id", text(func(c *GenericComponentResponse) *string { return c.ClusterID }), nil},
+		{"cluster_name", text(func(c *GenericComponentResponse) *string { return c.ClusterName }), nil},
+		{"location", text(func(c *GenericComponentResponse) *string { return c.Location }), nil},
+		{"version", text(func(c *GenericComponentResponse) *string { return c.Version }), nil},
+		{"zone", text(func(c *GenericComponentResponse) *string { return c.Zone }), nil},
+		{"state_db", text(func(c *GenericComponentResponse) *string { return c.StateDb }), nil},
+		{"api_url", text(func(c *GenericComponentResponse) *string { return c.ApiURL }), nil},
+		{"operations_url", text(func(c *GenericComponentResponse) *string { return c.OperationsURL }), nil},
+		{"grpcwp_url", text(func(c *GenericComponentResponse) *string { return c.GrpcwpURL }), nil},
+		{"tags", func(c *GenericComponentResponse) string { return strings.Join(c.Tags, ";") }, nil},
+		{"created", func(c *GenericComponentResponse) string {
+			if c.Timestamp == nil {
+				return ""
+			}
+			return time.Unix(0, int64(*c.Timestamp)*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+		}, func(c *GenericComponentResponse) (int64, bool) {
+			if c.Timestamp == nil {
+				return 0, false
+			}
+			return int64(*c.Timestamp), true
+		}},
+	}
+
+	resources := []struct {
+		name string
+		get  func(*GenericComponentResponseResources) *GenericResources
+	}{
+		{"ca", func(r *GenericComponentResponseResources) *GenericResources { return r.Ca }},
+		{"peer", func(r *GenericComponentResponseResources) *GenericResources { return r.Peer }},
+		{"orderer", func(r *GenericComponentResponseResources) *GenericResources { return r.Orderer }},
+		{"proxy", func(r *GenericComponentResponseResources) *GenericResources { return r.Proxy }},
+		{"statedb", func(r *GenericComponentResponseResources) *GenericResources { return r.Statedb }},
+	}
+	for _, sub := range resources {
+		get := sub.get
+		resource := func(value func(*GenericResources) *string) func(*GenericComponentResponse) *string {
+			return func(c *GenericComponentResponse) *string {
+				if c.Resources == nil || get(c.Resources) == nil {
+					return nil
+				}
+				return value(get(c.Resources))
+			}
+		}
+		prefix := "resources." + sub.name
+		requestsCpu := resource(func(r *GenericResources) *string {
+			if r.Requests == nil {
+				return nil
+			}
+			return r.Requests.Cpu
+		})
+		requestsMemory := resource(func(r *GenericResources) *string {
+			if r.Requests == nil {
+				return nil
+			}
+			return r.Requests.Memory
+		})
+		limitsCpu := resource(func(r *GenericResources) *string {
+			if r.Limits == nil {
+				return nil
+			}
+			return r.Limits.Cpu
+		})
+		limitsMemory := resource(func(r *GenericResources) *string {
+			if r.Limits == nil {
+				return nil
+			}
+			return r.Limits.Memory
+		})
+		columns = append(columns,
+			inventoryColumn{prefix + ".requests.cpu", text(requestsCpu), quantity(requestsCpu, parseCpu)},
+			inventoryColumn{prefix + ".requests.memory", text(requestsMemory), quantity(requestsMemory, parseMemory)},
+			inventoryColumn{prefix + ".limits.cpu", text(limitsCpu), quantity(limitsCpu, parseCpu)},
+			inventoryColumn{prefix + ".limits.memory", text(limitsMemory), quantity(limitsMemory, parseMemory)},
+		)
+	}
+
+	storage := []struct {
+		name string
+		get  func(*GenericComponentResponseStorage) *StorageObject
+	}{
+		{"ca", func(s *GenericComponentResponseStorage) *StorageObject { return s.Ca }},
+		{"peer", func(s *GenericComponentResponseStorage) *StorageObject { return s.Peer }},
+		{"orderer", func(s *GenericComponentResponseStorage) *StorageObject { return s.Orderer }},
+		{"statedb", func(s *GenericComponentResponseStorage) *StorageObject { return s.Statedb }},
+	}
+	for _, sub := range storage {
+		get := sub.get
+		prefix := "storage." + sub.name
+		size := func(c *GenericComponentResponse) *string {
+			if c.Storage == nil || get(c.Storage) == nil {
+				return nil
+			}
+			return get(c.Storage).Size
+		}
+		class := func(c *GenericComponentResponse) *string {
+			if c.Storage == nil || get(c.Storage) == nil {
+				return nil
+			}
+			return get(c.Storage).Class
+		}
+		columns = append(columns,
+			inventoryColumn{prefix + ".size", text(size), quantity(size, parseStorage)},
+			inventoryColumn{prefix + ".class", text(class), nil},
+		)
+	}
+	return
+}
+
+func findInventoryColumn(name string) *inventoryColumn {
+	for i := range inventoryColumns {
+		if inventoryColumns[i].name == name {
+			return &inventoryColumns[i]
+		}
+	}
+	return nil
+}
+
+// InventoryColumns returns the names of the columns an inventory can have.
+func InventoryColumns() (names []string) {
+	for _, column := range inventoryColumns {
+		names = append(names, column.name)
+	}
+	return
+}
+
+// InventoryOptions : The GetInventory options.
+type InventoryOptions struct {
+	// The columns of the inventory, see InventoryColumns(). Defaults to id, type, display_name, msp_id, cluster_id,
+	// version, zone, tags and created, followed by the resource and storage columns that have a value in at least one
+	// row.
+	Columns []string
+
+	// The columns to sort the rows by. Prefix a column with `-` to sort in descending order. Rows keep the order of the
+	// console otherwise. Resource and storage size columns sort by amount, `created` by time, other columns by text.
+	SortBy []string
+
+	// Group the rows by `msp_id` or `cluster_id`. The grouping column becomes the first column.
+	GroupBy *string `validate:"omitempty,oneof=msp_id cluster_id"`
+
+	// Allows users to set headers on API requests
+	Headers map[string]string
+}
+
+// NewInventoryOptions : Instantiate InventoryOptions
+func (*BlockchainV3) NewInventoryOptions() *InventoryOptions {
+	return &InventoryOptions{}
+}
+
+// SetColumns : Allow user to set Columns
+func (options *InventoryOptions) SetColumns(columns []string) *InventoryOptions {
+	options.Columns = columns
+	return options
+}
+
+// SetSortBy : Allow user to set SortBy
+func (options *InventoryOptions) SetSortBy(sortBy []string) *InventoryOptions {
+	options.SortBy = sortBy
+	return options
+}
+
+// SetGroupBy : Allow user to set GroupBy
+func (options *InventoryOptions) SetGroupBy(groupBy string) *InventoryOptions {
+	options.GroupBy = core.StringPtr(groupBy)
+	return options
+}
+
+// SetHeaders : Allow user to set Headers
+func (options *InventoryOptions) SetHeaders(param map[string]string) *InventoryOptions {
+	options.Headers = param
+	return options
+}
+
+// Inventory : A table of components.
+type Inventory struct {
+	// The columns of every row, in order.
+	Columns []string `json:"columns"`
+
+	// The column the rows are grouped by, if any.
+	GroupBy string `json:"group_by,omitempty"`
+
+	// The groups of rows, sorted by key with the empty key last. An inventory that is not grouped has one group with an
+	// empty key.
+	Groups []InventoryGroup `json:"groups"`
+}
+
+// InventoryGroup : Rows of an inventory that have the same value in the grouping column.
+type InventoryGroup struct {
+	Key string `json:"key"`
+
+	Rows []InventoryRow `json:"rows"`
+}
+
+// InventoryRow : The values of a row keyed by column. Missing values are empty strings.
+type InventoryRow map[string]string
+
+// GetInventory : Build an inventory of all components
+// Lists the components with their deployment attributes and flattens them into rows. See NewInventory.
+func (blockchain *BlockchainV3) GetInventory(inventoryOptions *InventoryOptions) (result *Inventory, response *core.DetailedResponse, err error) {
+	return blockchain.GetInventoryWithContext(context.Background(), inventoryOptions)
+}
+
+// GetInventoryWithContext is an alternate form of the GetInventory method which supports a Context parameter
+func (blockchain *BlockchainV3) GetInventoryWithContext(ctx context.Context, inventoryOptions *InventoryOptions) (result *Inventory, response *core.DetailedResponse, err error) {
+	if inventoryOptions == nil {
+		inventoryOptions = blockchain.NewInventoryOptions()
+	}
+	err = core.ValidateStruct(inventoryOptions, "inventoryOptions")
+	if err != nil {
+		return
+	}
+
+	listOptions := blockchain.NewListComponentsOptions().
+		SetDeploymentAttrs(ListComponentsOptions_DeploymentAttrs_Included).
+		SetHeaders(inventoryOptions.Headers)
+	components, response, err := blockchain.ListComponentsWithContext(ctx, listOptions)
+	if err != nil {
+		return
+	}
+	result, err = NewInventory(components.Components, inventoryOptions)
+	return
+}
+
+// NewInventory flattens components, including their resources and storage, into the rows of an inventory.
+func NewInventory(components []GenericComponentResponse, options *InventoryOptions) (inventory *Inventory, err error) {
+	if options == nil {
+		options = &InventoryOptions{}
+	}
+	err = core.ValidateStruct(options, "inventoryOptions")
+	if err != nil {
+		return
+	}
+
+	// every row holds every column, so that rows can be grouped by columns that are not shown
+	rows := make([]InventoryRow, len(components))
+	for i := range components {
+		rows[i] = InventoryRow{}
+		for _, column := range inventoryColumns {
+			rows[i][column.name] = column.value(&components[i])
+		}
+	}
+
+	inventory = &Inventory{}
+	columns := options.Columns
+	if len(columns) == 0 {
+		columns = append(columns, defaultInventoryColumns...)
+		for _, column := range inventoryColumns {
+			if strings.HasPrefix(column.name, "resources.") || strings.HasPrefix(column.name, "storage.") {
+				for _, row := range rows {
+					if row[column.name] != "" {
+						columns = append(columns, column.name)
+						break
+					}
+				}
+			}
+		}
+	}
+	if options.GroupBy != nil {
+		inventory.GroupBy = *options.GroupBy
+		inventory.Columns = append(inventory.Columns, inventory.GroupBy)
+	}
+	for _, column := range columns {
+		if findInventoryColumn(column) == nil {
+			err = fmt.Errorf("unknown inventory column %q", column)
+			return nil, err
+		}
+		if column != inventory.GroupBy {
+			inventory.Columns = append(inventory.Columns, column)
+		}
+	}
+
+	var sortColumns []*inventoryColumn
+	for _, sortBy := range options.SortBy {
+		column := findInventoryColumn(strings.TrimPrefix(sortBy, "-"))
+		if column == nil {
+			err = fmt.Errorf("unknown inventory sort column %q", sortBy)
+			return nil, err
+		}
+		sortColumns = append(sortColumns, column)
+	}
+	order := make([]int, len(components))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		for k, column := range sortColumns {
+			cmp := compareInventoryValues(column, &components[order[i]], &components[order[j]])
+			if cmp == 0 {
+				continue
+			}
+			if strings.HasPrefix(options.SortBy[k], "-") {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+	sorted := make([]InventoryRow, len(rows))
+	for i, index := range order {
+		sorted[i] = rows[index]
+	}
+	rows = sorted
+
+	groups := map[string]*InventoryGroup{}
+	var keys []string
+	for _, row := range rows {
+		key := ""
+		if inventory.GroupBy != "" {
+			key = row[inventory.GroupBy]
+		}
+		if groups[key] == nil {
+			groups[key] = &InventoryGroup{Key: key, Rows: []InventoryRow{}}
+			keys = append(keys, key)
+		}
+		shown := InventoryRow{}
+		for _, column := range inventory.Columns {
+			shown[column] = row[column]
+		}
+		groups[key].Rows = append(groups[key].Rows, shown)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i] == "" || keys[j] == "" {
+			return keys[j] == ""
+		}
+		return keys[i] < keys[j]
+	})
+	inventory.Groups = []InventoryGroup{}
+	for _, key := range keys {
+		inventory.Groups = append(inventory.Groups, *groups[key])
+	}
+	return
+}
+
+// compareInventoryValues compares the values of a column of two components, by their sort keys when both have one and
+// by their text otherwise. Missing values sort first.
+func compareInventoryValues(column *inventoryColumn, a *GenericComponentResponse, b *GenericComponentResponse) int {
+	if column.sortKey != nil {
+		keyA, okA := column.sortKey(a)
+		keyB, okB := column.sortKey(b)
+		switch {
+		case okA && okB && keyA < keyB:
+			return -1
+		case okA && okB && keyA > keyB:
+			return 1
+		case okA && okB:
+			return 0
+		}
+	}
+	return strings.Compare(column.value(a), column.value(b))
+}
+
+// Rows returns the rows of all groups.
+func (inventory *Inventory) Rows() (rows []InventoryRow) {
+	for _, group := range inventory.Groups {
+		rows = append(rows, group.Rows...)
+	}
+	return
+}
+
+// WriteCSV writes the inventory as CSV with a header row. Groups follow each other.
+func (inventory *Inventory) WriteCSV(writer io.Writer) error {
+	out := csv.NewWriter(writer)
+	if err := out.Write(inventory.Columns); err != nil {
+		return err
+	}
+	for _, row := range inventory.Rows() {
+		if err := out.Write(inventory.values(row)); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// WriteJSON writes the inventory as indented JSON.
+func (inventory *Inventory) WriteJSON(writer io.Writer) error {
+	data, err := json.MarshalIndent(inventory, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(append(data, '\n'))
+	return err
+}
+
+// WriteMarkdown writes the inventory as a Markdown table, or a section with a table per group if it is grouped.
+func (inventory *Inventory) WriteMarkdown(writer io.Writer) (err error) {
+	var out strings.Builder
+	table := func(rows []InventoryRow) {
+		out.WriteString("| " + strings.Join(markdownCells(inventory.Columns), " | ") + " |\n")
+		out.WriteString("|" + strings.Repeat(" --- |", len(inventory.Columns)) + "\n")
+		for _, row := range rows {
+			out.WriteString("| " + strings.Join(markdownCells(inventory.values(row)), " | ") + " |\n")
+		}
+	}
+
+	if inventory.GroupBy == "" {
+		table(inventory.Rows())
+	} else {
+		for i, group := range inventory.Groups {
+			if i > 0 {
+				out.WriteString("\n")
+			}
+			key := group.Key
+			if key == "" {
+				key = "(no " + inventory.GroupBy + ")"
+			}
+			fmt.Fprintf(&out, "## %s: %s\n\n", inventory.GroupBy, markdownCells([]string{key})[0])
+			table(group.Rows)
+		}
+	}
+	_, err = io.WriteString(writer, out.String())
+	return
+}
+
+func (inventory *Inventory) values(row InventoryRow) []string {
+	values := make([]string, len(inventory.Columns))
+	for i, column := range inventory.Columns {
+		values[i] = row[column]
+	}
+	return values
+}
+
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\n", " ", "\r", "")
+
+func markdownCells(values []string) []string {
+	cells := make([]string, len(values))
+	for i, value := range values {
+		cells[i] = markdownEscaper.Replace(value)
+	}
+	return cells
+}
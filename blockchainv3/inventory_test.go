@@ -0,0 +1,153 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+)
+
+const inventoryTestComponents = `{"components": [
+	{"id": "peer1", "type": "fabric-peer", "display_name": "Peer 1", "msp_id": "org1", "version": "2.2.1", "zone": "dal10",
+		"tags": ["fabric-peer", "ibm_saas"], "timestamp": 1537262855753, "state_db": "couchdb",
+		"resources": {"peer": {"requests": {"cpu": "200m", "memory": "1G"}, "limits": {"cpu": "200m", "memory": "1G"}}},
+		"storage": {"peer": {"size": "100Gi", "class": "default"}}},
+	{"id": "os1", "type": "fabric-orderer", "display_name": "Orderer | 1", "msp_id": "ordererorg", "cluster_id": "abcde",
+		"version": "2.2.1", "zone": "dal12", "timestamp": 1537262855000,
+		"resources": {"orderer": {"requests": {"cpu": "250m", "memory": "500M"}}}},
+	{"id": "ca1", "type": "fabric-ca", "display_name": "CA", "msp_id": "org1", "timestamp": 1537262856000},
+	{"id": "msp1", "type": "msp", "display_name": "Org 2"}
+]}`
+
+var _ = Describe(`BlockchainV3 inventory`, func() {
+	var testServer *httptest.Server
+	var blockchainService *blockchainv3.BlockchainV3
+
+	BeforeEach(func() {
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			Expect(req.URL.Path).To(Equal("/ak/api/v3/components"))
+			Expect(req.URL.Query().Get("deployment_attrs")).To(Equal("included"))
+			res.Header().Set("Content-type", "application/json")
+			res.WriteHeader(200)
+			fmt.Fprint(res, inventoryTestComponents)
+		}))
+		var serviceErr error
+		blockchainService, serviceErr = blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(serviceErr).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Flattens components with the default columns`, func() {
+		inventory, _, err := blockchainService.GetInventory(nil)
+		Expect(err).To(BeNil())
+		Expect(inventory.Columns).To(Equal([]string{"id", "type", "display_name", "msp_id", "cluster_id", "version", "zone", "tags", "created",
+			"resources.peer.requests.cpu", "resources.peer.requests.memory", "resources.peer.limits.cpu", "resources.peer.limits.memory",
+			"resources.orderer.requests.cpu", "resources.orderer.requests.memory", "storage.peer.size", "storage.peer.class"}))
+		Expect(inventory.Groups).To(HaveLen(1))
+		rows := inventory.Rows()
+		Expect(rows).To(HaveLen(4))
+		Expect(rows[0]["tags"]).To(Equal("fabric-peer;ibm_saas"))
+		Expect(rows[0]["created"]).To(Equal("2018-09-18T09:27:35Z"))
+		Expect(rows[0]["storage.peer.size"]).To(Equal("100Gi"))
+		Expect(rows[3]["created"]).To(Equal(""))
+		Expect(blockchainv3.InventoryColumns()).To(ContainElement("resources.statedb.limits.memory"))
+	})
+	It(`Selects, sorts and groups rows`, func() {
+		options := blockchainService.NewInventoryOptions().
+			SetColumns([]string{"id", "msp_id", "created"}).
+			SetSortBy([]string{"-created"}).
+			SetGroupBy(blockchainv3.InventoryOptions_GroupBy_MspID)
+		inventory, _, err := blockchainService.GetInventory(options)
+		Expect(err).To(BeNil())
+		Expect(inventory.Columns).To(Equal([]string{"msp_id", "id", "created"}))
+		Expect(inventory.Groups).To(HaveLen(3))
+		Expect(inventory.Groups[0].Key).To(Equal("ordererorg"))
+		Expect(inventory.Groups[1].Key).To(Equal("org1"))
+		Expect(inventory.Groups[1].Rows).To(Equal([]blockchainv3.InventoryRow{
+			{"msp_id": "org1", "id": "ca1", "created": "2018-09-18T09:27:36Z"},
+			{"msp_id": "org1", "id": "peer1", "created": "2018-09-18T09:27:35Z"},
+		}))
+		Expect(inventory.Groups[2].Key).To(Equal(""))
+
+		var markdown bytes.Buffer
+		Expect(inventory.WriteMarkdown(&markdown)).To(BeNil())
+		Expect(markdown.String()).To(HavePrefix("## msp_id: ordererorg\n\n| msp_id | id | created |\n| --- | --- | --- |\n| ordererorg | os1 | 2018-09-18T09:27:35Z |\n\n## msp_id: org1\n"))
+		Expect(markdown.String()).To(HaveSuffix("## msp_id: (no msp_id)\n\n| msp_id | id | created |\n| --- | --- | --- |\n|  | msp1 |  |\n"))
+	})
+	It(`Writes CSV, JSON and Markdown`, func() {
+		components, _, err := blockchainService.ListComponents(blockchainService.NewListComponentsOptions().SetDeploymentAttrs("included"))
+		Expect(err).To(BeNil())
+		inventory, err := blockchainv3.NewInventory(components.Components, &blockchainv3.InventoryOptions{
+			Columns: []string{"id", "display_name", "cluster_id"},
+			SortBy:  []string{"cluster_id", "id"},
+		})
+		Expect(err).To(BeNil())
+
+		var out bytes.Buffer
+		Expect(inventory.WriteCSV(&out)).To(BeNil())
+		Expect(out.String()).To(Equal("id,display_name,cluster_id\nca1,CA,\nmsp1,Org 2,\npeer1,Peer 1,\nos1,Orderer | 1,abcde\n"))
+
+		out.Reset()
+		Expect(inventory.WriteJSON(&out)).To(BeNil())
+		Expect(out.String()).To(MatchJSON(`{"columns": ["id", "display_name", "cluster_id"], "groups": [{"key": "", "rows": [
+			{"id": "ca1", "display_name": "CA", "cluster_id": ""}, {"id": "msp1", "display_name": "Org 2", "cluster_id": ""},
+			{"id": "peer1", "display_name": "Peer 1", "cluster_id": ""}, {"id": "os1", "display_name": "Orderer | 1", "cluster_id": "abcde"}]}]}`))
+
+		out.Reset()
+		Expect(inventory.WriteMarkdown(&out)).To(BeNil())
+		Expect(out.String()).To(ContainSubstring("| os1 | Orderer \\| 1 | abcde |\n"))
+	})
+	It(`Sorts quantities by amount`, func() {
+		var components []blockchainv3.GenericComponentResponse
+		Expect(json.Unmarshal([]byte(`[
+			{"id": "peer1", "resources": {"peer": {"requests": {"cpu": "250m"}}}, "storage": {"peer": {"size": "2Gi"}}},
+			{"id": "peer2", "resources": {"peer": {"requests": {"cpu": "1000m"}}}, "storage": {"peer": {"size": "10Gi"}}},
+			{"id": "peer3", "resources": {"peer": {"requests": {"cpu": "0.5"}}}, "storage": {"peer": {"size": "512Mi"}}}
+		]`), &components)).To(BeNil())
+		ids := func(sortBy string) (ids []string) {
+			inventory, err := blockchainv3.NewInventory(components, &blockchainv3.InventoryOptions{Columns: []string{"id"}, SortBy: []string{sortBy}})
+			Expect(err).To(BeNil())
+			for _, row := range inventory.Rows() {
+				ids = append(ids, row["id"])
+			}
+			return
+		}
+		Expect(ids("resources.peer.requests.cpu")).To(Equal([]string{"peer1", "peer3", "peer2"}))
+		Expect(ids("-storage.peer.size")).To(Equal([]string{"peer2", "peer1", "peer3"}))
+	})
+	It(`Rejects unknown columns`, func() {
+		_, err := blockchainv3.NewInventory(nil, &blockchainv3.InventoryOptions{Columns: []string{"owner"}})
+		Expect(err).ToNot(BeNil())
+		_, err = blockchainv3.NewInventory(nil, &blockchainv3.InventoryOptions{SortBy: []string{"-owner"}})
+		Expect(err).ToNot(BeNil())
+		_, err = blockchainv3.NewInventory(nil, blockchainService.NewInventoryOptions().SetGroupBy("zone"))
+		Expect(err).ToNot(BeNil())
+	})
+})
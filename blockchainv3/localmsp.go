@@ -0,0 +1,350 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"github.com/IBM/go-sdk-core/v4/core"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// The folders and files of Fabric's local MSP directory layout that LocalMsp reads and writes.
+const (
+	LocalMspDir_Cacerts           = "cacerts"
+	LocalMspDir_Intermediatecerts = "intermediatecerts"
+	LocalMspDir_Admincerts        = "admincerts"
+	LocalMspDir_Tlscacerts        = "tlscacerts"
+	LocalMspFile_Config           = "config.yaml"
+)
+
+// The OU identifiers of DefaultNodeOUs, as used by fabric-ca-client and cryptogen.
+const (
+	NodeOU_Admin   = "admin"
+	NodeOU_Client  = "client"
+	NodeOU_Orderer = "orderer"
+	NodeOU_Peer    = "peer"
+)
+
+// LocalMsp : The public part of an MSP in Fabric's local MSP directory layout, as written by cryptogen and
+// fabric-ca-client and read by the peer CLI. Certificates are base 64 encoded PEM, like in ImportMspOptions and
+// MspResponse. The signcerts and keystore folders hold an identity rather than the MSP and are left alone.
+type LocalMsp struct {
+	// The certificates of cacerts.
+	RootCerts []string
+
+	// The certificates of intermediatecerts.
+	IntermediateCerts []string
+
+	// The certificates of admincerts. May be empty when NodeOUs identify the admins.
+	Admins []string
+
+	// The certificates of tlscacerts.
+	TlsRootCerts []string
+
+	// The NodeOUs of config.yaml, if any.
+	NodeOUs *NodeOUs
+
+	// The OrganizationalUnitIdentifiers of config.yaml, if any.
+	OrganizationalUnitIdentifiers []OUIdentifier
+}
+
+// NodeOUs : The NodeOUs section of an MSP's config.yaml.
+type NodeOUs struct {
+	Enable bool `yaml:"Enable"`
+
+	ClientOUIdentifier  *OUIdentifier `yaml:"ClientOUIdentifier,omitempty"`
+	PeerOUIdentifier    *OUIdentifier `yaml:"PeerOUIdentifier,omitempty"`
+	AdminOUIdentifier   *OUIdentifier `yaml:"AdminOUIdentifier,omitempty"`
+	OrdererOUIdentifier *OUIdentifier `yaml:"OrdererOUIdentifier,omitempty"`
+}
+
+// OUIdentifier : An organizational unit and, optionally, the CA certificate that issues its identities.
+type OUIdentifier struct {
+	// Path of a certificate file, relative to the MSP directory. When writing, an empty path stands for the first
+	// certificate of cacerts.
+	Certificate string `yaml:"Certificate,omitempty"`
+
+	OrganizationalUnitIdentifier string `yaml:"OrganizationalUnitIdentifier"`
+}
+
+// localMspConfig is the config.yaml of a local MSP.
+type localMspConfig struct {
+	OrganizationalUnitIdentifiers []OUIdentifier `yaml:"OrganizationalUnitIdentifiers,omitempty"`
+	NodeOUs                       *NodeOUs       `yaml:"NodeOUs,omitempty"`
+}
+
+// DefaultNodeOUs returns enabled NodeOUs with the client, peer, admin and orderer OUs, all issued by the first
+// certificate of cacerts.
+func DefaultNodeOUs() *NodeOUs {
+	return &NodeOUs{
+		Enable:              true,
+		ClientOUIdentifier:  &OUIdentifier{OrganizationalUnitIdentifier: NodeOU_Client},
+		PeerOUIdentifier:    &OUIdentifier{OrganizationalUnitIdentifier: NodeOU_Peer},
+		AdminOUIdentifier:   &OUIdentifier{OrganizationalUnitIdentifier: NodeOU_Admin},
+		OrdererOUIdentifier: &OUIdentifier{OrganizationalUnitIdentifier: NodeOU_Orderer},
+	}
+}
+
+// NewLocalMsp returns the local MSP of an MSP definition of the console, e.g. to write it for the peer CLI.
+func NewLocalMsp(msp *MspResponse) *LocalMsp {
+	return &LocalMsp{
+		RootCerts:         msp.RootCerts,
+		IntermediateCerts: msp.IntermediateCerts,
+		Admins:            msp.Admins,
+		TlsRootCerts:      msp.TlsRootCerts,
+	}
+}
+
+// ReadLocalMsp reads the MSP in directory dir. Every file of a certificate folder must hold one or more PEM
+// certificates, and the certificates config.yaml refers to must exist.
+func ReadLocalMsp(dir string) (msp *LocalMsp, err error) {
+	msp = &LocalMsp{}
+	// the certificates read from each file, relative to dir, to check config.yaml
+	files := map[string][]string{}
+	for _, folder := range []struct {
+		name  string
+		certs *[]string
+	}{
+		{LocalMspDir_Cacerts, &msp.RootCerts},
+		{LocalMspDir_Intermediatecerts, &msp.IntermediateCerts},
+		{LocalMspDir_Admincerts, &msp.Admins},
+		{LocalMspDir_Tlscacerts, &msp.TlsRootCerts},
+	} {
+		var infos []os.FileInfo
+		infos, err = ioutil.ReadDir(filepath.Join(dir, folder.name))
+		if os.IsNotExist(err) {
+			err = nil
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+				continue
+			}
+			name := path.Join(folder.name, info.Name())
+			var certs []string
+			certs, err = readPemCerts(filepath.Join(dir, name))
+			if err != nil {
+				return nil, err
+			}
+			files[name] = certs
+			*folder.certs = append(*folder.certs, certs...)
+		}
+	}
+	if len(msp.RootCerts) == 0 {
+		return nil, fmt.Errorf("%s has no certificates in %s", dir, LocalMspDir_Cacerts)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, LocalMspFile_Config))
+	if os.IsNotExist(err) {
+		return msp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var config localMspConfig
+	err = yaml.UnmarshalStrict(data, &config)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", LocalMspFile_Config, err.Error())
+	}
+	msp.NodeOUs = config.NodeOUs
+	msp.OrganizationalUnitIdentifiers = config.OrganizationalUnitIdentifiers
+	for _, identifier := range msp.ouIdentifiers() {
+		if identifier.Certificate == "" {
+			continue
+		}
+		name := path.Clean(filepath.ToSlash(identifier.Certificate))
+		certs, ok := files[name]
+		if !ok || !strings.HasPrefix(name, LocalMspDir_Cacerts+"/") && !strings.HasPrefix(name, LocalMspDir_Intermediatecerts+"/") {
+			return nil, fmt.Errorf("%s: OU %s refers to %s, which is not a certificate of %s or %s", LocalMspFile_Config,
+				identifier.OrganizationalUnitIdentifier, identifier.Certificate, LocalMspDir_Cacerts, LocalMspDir_Intermediatecerts)
+		}
+		if len(certs) != 1 {
+			return nil, fmt.Errorf("%s: OU %s refers to %s, which holds %d certificates", LocalMspFile_Config,
+				identifier.OrganizationalUnitIdentifier, identifier.Certificate, len(certs))
+		}
+	}
+	return
+}
+
+// ImportMspOptions returns the options to import the MSP into the console with ImportMsp.
+func (msp *LocalMsp) ImportMspOptions(mspID string, displayName string) *ImportMspOptions {
+	options := &ImportMspOptions{
+		MspID:             core.StringPtr(mspID),
+		DisplayName:       core.StringPtr(displayName),
+		RootCerts:         msp.RootCerts,
+		IntermediateCerts: msp.IntermediateCerts,
+		Admins:            msp.Admins,
+		TlsRootCerts:      msp.TlsRootCerts,
+	}
+	return options
+}
+
+// Write writes the MSP to directory dir, creating it if needed. Certificates are written one per file, named after
+// their folder, e.g. cacerts/cacert-0.pem. config.yaml is only written when the MSP has NodeOUs or OU identifiers.
+func (msp *LocalMsp) Write(dir string) error {
+	if len(msp.RootCerts) == 0 {
+		return fmt.Errorf("the MSP has no root certificates")
+	}
+	for _, folder := range []struct {
+		name   string
+		prefix string
+		certs  []string
+	}{
+		{LocalMspDir_Cacerts, "cacert", msp.RootCerts},
+		{LocalMspDir_Intermediatecerts, "intermediatecert", msp.IntermediateCerts},
+		{LocalMspDir_Admincerts, "admincert", msp.Admins},
+		{LocalMspDir_Tlscacerts, "tlscacert", msp.TlsRootCerts},
+	} {
+		if len(folder.certs) == 0 {
+			continue
+		}
+		err := os.MkdirAll(filepath.Join(dir, folder.name), 0755)
+		if err != nil {
+			return err
+		}
+		for i, cert := range folder.certs {
+			data, err := decodePemCert(cert)
+			if err != nil {
+				return fmt.Errorf("%s certificate %d: %s", folder.name, i, err.Error())
+			}
+			err = ioutil.WriteFile(filepath.Join(dir, folder.name, fmt.Sprintf("%s-%d.pem", folder.prefix, i)), data, 0644)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if msp.NodeOUs == nil && len(msp.OrganizationalUnitIdentifiers) == 0 {
+		return nil
+	}
+	defaultCert := path.Join(LocalMspDir_Cacerts, "cacert-0.pem")
+	withCert := func(identifier *OUIdentifier) *OUIdentifier {
+		if identifier == nil || identifier.Certificate != "" {
+			return identifier
+		}
+		filled := *identifier
+		filled.Certificate = defaultCert
+		return &filled
+	}
+	config := localMspConfig{}
+	for i := range msp.OrganizationalUnitIdentifiers {
+		config.OrganizationalUnitIdentifiers = append(config.OrganizationalUnitIdentifiers, *withCert(&msp.OrganizationalUnitIdentifiers[i]))
+	}
+	if nodeOUs := msp.NodeOUs; nodeOUs != nil {
+		config.NodeOUs = &NodeOUs{
+			Enable:              nodeOUs.Enable,
+			ClientOUIdentifier:  withCert(nodeOUs.ClientOUIdentifier),
+			PeerOUIdentifier:    withCert(nodeOUs.PeerOUIdentifier),
+			AdminOUIdentifier:   withCert(nodeOUs.AdminOUIdentifier),
+			OrdererOUIdentifier: withCert(nodeOUs.OrdererOUIdentifier),
+		}
+	}
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, LocalMspFile_Config), data, 0644)
+}
+
+// ouIdentifiers returns the OU identifiers of the MSP.
+func (msp *LocalMsp) ouIdentifiers() []OUIdentifier {
+	identifiers := append([]OUIdentifier{}, msp.OrganizationalUnitIdentifiers...)
+	if nodeOUs := msp.NodeOUs; nodeOUs != nil {
+		for _, identifier := range []*OUIdentifier{nodeOUs.ClientOUIdentifier, nodeOUs.PeerOUIdentifier, nodeOUs.AdminOUIdentifier, nodeOUs.OrdererOUIdentifier} {
+			if identifier != nil {
+				identifiers = append(identifiers, *identifier)
+			}
+		}
+	}
+	return identifiers
+}
+
+// readPemCerts returns the certificates of a PEM file, each as base 64 encoded PEM.
+func readPemCerts(file string) (certs []string, err error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return
+	}
+	for rest := bytes.TrimSpace(data); len(rest) > 0; rest = bytes.TrimSpace(rest) {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("%s is not a PEM file", file)
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("%s holds a %s, not a certificate", file, block.Type)
+		}
+		certs = append(certs, base64.StdEncoding.EncodeToString(pem.EncodeToMemory(block)))
+	}
+	if len(certs) == 0 {
+		err = fmt.Errorf("%s is empty", file)
+	}
+	return
+}
+
+// decodePemCert returns the PEM of a base 64 encoded PEM certificate.
+func decodePemCert(cert string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(cert)
+	if err != nil {
+		return nil, fmt.Errorf("not base 64 encoded PEM")
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("not base 64 encoded PEM")
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// ImportLocalMsp reads the MSP in directory dir and imports it into the console with ImportMsp.
+func (blockchain *BlockchainV3) ImportLocalMsp(ctx context.Context, dir string, mspID string, displayName string) (result *MspResponse, response *core.DetailedResponse, err error) {
+	msp, err := ReadLocalMsp(dir)
+	if err != nil {
+		return
+	}
+	return blockchain.ImportMspWithContext(ctx, msp.ImportMspOptions(mspID, displayName))
+}
+
+// GetLocalMsp gets an MSP definition of the console as a local MSP. Set its NodeOUs, e.g. to DefaultNodeOUs(), before
+// writing it if the organization identifies its admins by OU.
+func (blockchain *BlockchainV3) GetLocalMsp(ctx context.Context, id string) (msp *LocalMsp, err error) {
+	// GenericComponentResponse has no certificates of MSP definitions
+	rawComponent, err := blockchain.getRawComponent(ctx, id, nil)
+	if err != nil {
+		return
+	}
+	var definition *MspResponse
+	err = UnmarshalMspResponse(rawComponent, &definition)
+	if err != nil {
+		return
+	}
+	if definition.Type != nil && *definition.Type != ComponentType_Msp {
+		return nil, fmt.Errorf("component %s is of type %q, not an MSP", id, *definition.Type)
+	}
+	return NewLocalMsp(definition), nil
+}
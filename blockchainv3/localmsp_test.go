@@ -0,0 +1,191 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+)
+
+const localMspTestConfig = `NodeOUs:
+  Enable: true
+  ClientOUIdentifier:
+    Certificate: cacerts/ca.example.com-cert.pem
+    OrganizationalUnitIdentifier: client
+  PeerOUIdentifier:
+    Certificate: cacerts/ca.example.com-cert.pem
+    OrganizationalUnitIdentifier: peer
+  AdminOUIdentifier:
+    Certificate: cacerts/ca.example.com-cert.pem
+    OrganizationalUnitIdentifier: admin
+  OrdererOUIdentifier:
+    Certificate: cacerts/ca.example.com-cert.pem
+    OrganizationalUnitIdentifier: orderer
+`
+
+var _ = Describe(`BlockchainV3 local MSP directories`, func() {
+	var dir string
+	var caPEM, intermediatePEM, intermediate2PEM, tlsPEM, adminPEM string
+	encode := func(cert string) string { return base64.StdEncoding.EncodeToString([]byte(cert)) }
+	writeFile := func(name string, content string) {
+		Expect(os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)).To(BeNil())
+		Expect(ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)).To(BeNil())
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "msp")
+		Expect(err).To(BeNil())
+		caPEM, intermediatePEM, intermediate2PEM = testCertificate("ca"), testCertificate("ica1"), testCertificate("ica2")
+		tlsPEM, adminPEM = testCertificate("tlsca"), testCertificate("admin")
+		writeFile("cacerts/ca.example.com-cert.pem", caPEM)
+		writeFile("intermediatecerts/chain.pem", intermediatePEM+intermediate2PEM)
+		writeFile("tlscacerts/tlsca.example.com-cert.pem", tlsPEM)
+		writeFile("signcerts/cert.pem", adminPEM)
+		writeFile("keystore/priv_sk", "not a certificate")
+		writeFile("config.yaml", localMspTestConfig)
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It(`Reads a cryptogen MSP`, func() {
+		msp, err := blockchainv3.ReadLocalMsp(dir)
+		Expect(err).To(BeNil())
+		Expect(msp.RootCerts).To(Equal([]string{encode(caPEM)}))
+		Expect(msp.IntermediateCerts).To(Equal([]string{encode(intermediatePEM), encode(intermediate2PEM)}))
+		Expect(msp.Admins).To(BeEmpty())
+		Expect(msp.TlsRootCerts).To(Equal([]string{encode(tlsPEM)}))
+		Expect(msp.NodeOUs.Enable).To(BeTrue())
+		Expect(*msp.NodeOUs.AdminOUIdentifier).To(Equal(blockchainv3.OUIdentifier{
+			Certificate: "cacerts/ca.example.com-cert.pem", OrganizationalUnitIdentifier: "admin",
+		}))
+
+		options := msp.ImportMspOptions("org1", "Org 1")
+		Expect(*options.MspID).To(Equal("org1"))
+		Expect(options.RootCerts).To(Equal(msp.RootCerts))
+		Expect(options.TlsRootCerts).To(Equal(msp.TlsRootCerts))
+	})
+	It(`Rejects broken MSPs`, func() {
+		writeFile("config.yaml", "NodeOUs:\n  Enable: true\n  AdminOUIdentifier:\n    Certificate: cacerts/missing.pem\n    OrganizationalUnitIdentifier: admin\n")
+		_, err := blockchainv3.ReadLocalMsp(dir)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("config.yaml: OU admin refers to cacerts/missing.pem, which is not a certificate of cacerts or intermediatecerts"))
+
+		writeFile("config.yaml", "NodeOUs:\n  Enable: true\n  AdminOUIdentifier:\n    Certificate: intermediatecerts/chain.pem\n    OrganizationalUnitIdentifier: admin\n")
+		_, err = blockchainv3.ReadLocalMsp(dir)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("which holds 2 certificates"))
+
+		writeFile("config.yaml", "NodeOUs:\n  Enabled: true\n")
+		_, err = blockchainv3.ReadLocalMsp(dir)
+		Expect(err).ToNot(BeNil())
+
+		writeFile("config.yaml", "")
+		writeFile("admincerts/admin.pem", "garbage")
+		_, err = blockchainv3.ReadLocalMsp(dir)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(HaveSuffix("admin.pem is not a PEM file"))
+
+		Expect(os.RemoveAll(filepath.Join(dir, "admincerts"))).To(BeNil())
+		Expect(os.RemoveAll(filepath.Join(dir, "cacerts"))).To(BeNil())
+		_, err = blockchainv3.ReadLocalMsp(dir)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(HaveSuffix("has no certificates in cacerts"))
+	})
+	It(`Writes an MSP the way it reads it`, func() {
+		out := filepath.Join(dir, "out")
+		msp := blockchainv3.NewLocalMsp(&blockchainv3.MspResponse{
+			RootCerts:    []string{encode(caPEM)},
+			Admins:       []string{encode(adminPEM)},
+			TlsRootCerts: []string{encode(tlsPEM)},
+		})
+		msp.NodeOUs = blockchainv3.DefaultNodeOUs()
+		Expect(msp.Write(out)).To(BeNil())
+
+		data, err := ioutil.ReadFile(filepath.Join(out, "admincerts", "admincert-0.pem"))
+		Expect(err).To(BeNil())
+		Expect(string(data)).To(Equal(adminPEM))
+		config, err := ioutil.ReadFile(filepath.Join(out, "config.yaml"))
+		Expect(err).To(BeNil())
+		Expect(string(config)).To(ContainSubstring("  PeerOUIdentifier:\n    Certificate: cacerts/cacert-0.pem\n    OrganizationalUnitIdentifier: peer\n"))
+		_, err = os.Stat(filepath.Join(out, "intermediatecerts"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		read, err := blockchainv3.ReadLocalMsp(out)
+		Expect(err).To(BeNil())
+		Expect(read.RootCerts).To(Equal(msp.RootCerts))
+		Expect(read.Admins).To(Equal(msp.Admins))
+		Expect(read.NodeOUs.ClientOUIdentifier.Certificate).To(Equal("cacerts/cacert-0.pem"))
+		// the caller's NodeOUs are not changed
+		Expect(msp.NodeOUs.ClientOUIdentifier.Certificate).To(Equal(""))
+
+		Expect((&blockchainv3.LocalMsp{}).Write(out)).ToNot(BeNil())
+		Expect((&blockchainv3.LocalMsp{RootCerts: []string{"bm9wZQ=="}}).Write(out)).ToNot(BeNil())
+	})
+	It(`Imports and exports MSPs of the console`, func() {
+		testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			res.Header().Set("Content-type", "application/json")
+			switch req.URL.Path {
+			case "/ak/api/v3/components/msp":
+				Expect(req.Method).To(Equal("POST"))
+				var body map[string]interface{}
+				Expect(json.NewDecoder(req.Body).Decode(&body)).To(BeNil())
+				Expect(body["msp_id"]).To(Equal("org1"))
+				Expect(body["root_certs"]).To(Equal([]interface{}{encode(caPEM)}))
+				Expect(body["intermediate_certs"]).To(HaveLen(2))
+				fmt.Fprint(res, `{"id": "org1msp", "type": "msp", "msp_id": "org1"}`)
+			case "/ak/api/v3/components/org1msp":
+				json.NewEncoder(res).Encode(map[string]interface{}{
+					"id": "org1msp", "type": "msp", "msp_id": "org1", "root_certs": []string{encode(caPEM)}, "admins": []string{encode(adminPEM)},
+				})
+			case "/ak/api/v3/components/peer1":
+				fmt.Fprint(res, `{"id": "peer1", "type": "fabric-peer"}`)
+			default:
+				res.WriteHeader(404)
+			}
+		}))
+		defer testServer.Close()
+		blockchainService, err := blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+
+		result, _, err := blockchainService.ImportLocalMsp(context.Background(), dir, "org1", "Org 1")
+		Expect(err).To(BeNil())
+		Expect(*result.ID).To(Equal("org1msp"))
+
+		msp, err := blockchainService.GetLocalMsp(context.Background(), "org1msp")
+		Expect(err).To(BeNil())
+		Expect(msp.Admins).To(Equal([]string{encode(adminPEM)}))
+		_, err = blockchainService.GetLocalMsp(context.Background(), "peer1")
+		Expect(err).ToNot(BeNil())
+	})
+})
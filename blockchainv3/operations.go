@@ -0,0 +1,122 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Operation : A REST operation implemented by BlockchainV3.
+type Operation struct {
+	// The name of the BlockchainV3 method, e.g. `UpdatePeer`.
+	Name string
+
+	// The HTTP method, e.g. `PUT`.
+	Method string
+
+	// The path template relative to the service URL, e.g. `/ak/api/v3/kubernetes/components/fabric-peer/{id}`.
+	Path string
+}
+
+// operations lists every operation of the service. Routes with a literal segment are listed before templated routes
+// that would also match them (`/purge` before `/{id}`).
+var operations = []Operation{
+	{"DeleteAllComponents", http.MethodDelete, `/ak/api/v3/kubernetes/components/purge`},
+	{"GetComponent", http.MethodGet, `/ak/api/v3/components/{id}`},
+	{"RemoveComponent", http.MethodDelete, `/ak/api/v3/components/{id}`},
+	{"DeleteComponent", http.MethodDelete, `/ak/api/v3/kubernetes/components/{id}`},
+	{"CreateCa", http.MethodPost, `/ak/api/v3/kubernetes/components/fabric-ca`},
+	{"ImportCa", http.MethodPost, `/ak/api/v3/components/fabric-ca`},
+	{"UpdateCa", http.MethodPut, `/ak/api/v3/kubernetes/components/fabric-ca/{id}`},
+	{"EditCa", http.MethodPut, `/ak/api/v3/components/fabric-ca/{id}`},
+	{"CaAction", http.MethodPost, `/ak/api/v3/kubernetes/components/fabric-ca/{id}/actions`},
+	{"CreatePeer", http.MethodPost, `/ak/api/v3/kubernetes/components/fabric-peer`},
+	{"ImportPeer", http.MethodPost, `/ak/api/v3/components/fabric-peer`},
+	{"EditPeer", http.MethodPut, `/ak/api/v3/components/fabric-peer/{id}`},
+	{"PeerAction", http.MethodPost, `/ak/api/v3/kubernetes/components/fabric-peer/{id}/actions`},
+	{"UpdatePeer", http.MethodPut, `/ak/api/v3/kubernetes/components/fabric-peer/{id}`},
+	{"CreateOrderer", http.MethodPost, `/ak/api/v3/kubernetes/components/fabric-orderer`},
+	{"ImportOrderer", http.MethodPost, `/ak/api/v3/components/fabric-orderer`},
+	{"EditOrderer", http.MethodPut, `/ak/api/v3/components/fabric-orderer/{id}`},
+	{"OrdererAction", http.MethodPost, `/ak/api/v3/kubernetes/components/fabric-orderer/{id}/actions`},
+	{"UpdateOrderer", http.MethodPut, `/ak/api/v3/kubernetes/components/fabric-orderer/{id}`},
+	{"SubmitBlock", http.MethodPut, `/ak/api/v3/kubernetes/components/{id}/config`},
+	{"ImportMsp", http.MethodPost, `/ak/api/v3/components/msp`},
+	{"EditMsp", http.MethodPut, `/ak/api/v3/components/msp/{id}`},
+	{"GetMspCertificate", http.MethodGet, `/ak/api/v3/components/msps/{msp_id}`},
+	{"EditAdminCerts", http.MethodPut, `/ak/api/v3/kubernetes/components/{id}/certs`},
+	{"ListComponents", http.MethodGet, `/ak/api/v3/components`},
+	{"GetComponentsByType", http.MethodGet, `/ak/api/v3/components/types/{type}`},
+	{"GetComponentsByTag", http.MethodGet, `/ak/api/v3/components/tags/{tag}`},
+	{"RemoveComponentsByTag", http.MethodDelete, `/ak/api/v3/components/tags/{tag}`},
+	{"DeleteComponentsByTag", http.MethodDelete, `/ak/api/v3/kubernetes/components/tags/{tag}`},
+	{"GetSettings", http.MethodGet, `/ak/api/v3/settings`},
+	{"EditSettings", http.MethodPut, `/ak/api/v3/settings`},
+	{"GetFabVersions", http.MethodGet, `/ak/api/v3/kubernetes/fabric/versions`},
+	{"GetHealth", http.MethodGet, `/ak/api/v3/health`},
+	{"ListNotifications", http.MethodGet, `/ak/api/v3/notifications`},
+	{"ListSigTxs", http.MethodGet, `/ak/api/v3/signature_collections`},
+	{"CreateSigTx", http.MethodPost, `/ak/api/v3/signature_collections`},
+	{"SignSigTx", http.MethodPut, `/ak/api/v3/signature_collections`},
+	{"GetSigTx", http.MethodGet, `/ak/api/v3/signature_collections/{id}`},
+	{"DeleteSigTx", http.MethodDelete, `/ak/api/v3/signature_collections/{id}`},
+	{"ArchiveNotifications", http.MethodPost, `/ak/api/v3/notifications/bulk`},
+	{"Restart", http.MethodPost, `/ak/api/v3/restart`},
+	{"DeleteAllSessions", http.MethodDelete, `/ak/api/v3/sessions`},
+	{"DeleteAllNotifications", http.MethodDelete, `/ak/api/v3/notifications/purge`},
+	{"ClearCaches", http.MethodDelete, `/ak/api/v3/cache`},
+	{"GetPostman", http.MethodGet, `/ak/api/v3/postman`},
+	{"GetSwagger", http.MethodGet, `/ak/api/v3/openapi`},
+}
+
+var operationPatterns = compileOperationPatterns()
+
+func compileOperationPatterns() []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, len(operations))
+	for i, op := range operations {
+		segments := strings.Split(op.Path, "/")
+		for j, segment := range segments {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+				segments[j] = "[^/]+"
+			} else {
+				segments[j] = regexp.QuoteMeta(segment)
+			}
+		}
+		patterns[i] = regexp.MustCompile(strings.Join(segments, "/") + "$")
+	}
+	return patterns
+}
+
+// ImplementedOperations returns the REST operations implemented by BlockchainV3.
+func ImplementedOperations() []Operation {
+	list := make([]Operation, len(operations))
+	copy(list, operations)
+	return list
+}
+
+// LookupOperation returns the operation that sends requests with the given HTTP method to the given URL path. The path
+// may include the path of the service URL as a prefix.
+func LookupOperation(method string, path string) (operation Operation, ok bool) {
+	for i, op := range operations {
+		if op.Method == strings.ToUpper(method) && operationPatterns[i].MatchString(path) {
+			return op, true
+		}
+	}
+	return
+}
@@ -0,0 +1,206 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"context"
+	"fmt"
+	"github.com/IBM/go-sdk-core/v4/core"
+	"sort"
+)
+
+// RaftZone : A Kubernetes zone that orderer nodes can be placed in.
+type RaftZone struct {
+	Name string
+
+	// The region of the zone, if the cluster spans regions.
+	Region string
+}
+
+// RaftPlacement : The zones, and regions, of the nodes of a Raft cluster, in the form of CreateOrdererOptions.
+type RaftPlacement struct {
+	// The zone of each node.
+	Zone []string
+
+	// The region of each node, or nil if the zones have no regions.
+	Region []string
+
+	// Where the placement does not survive the loss of a zone, see CheckRaftZones.
+	Warnings []string
+}
+
+// PlanRaftPlacement spreads the nodes of a Raft cluster over zones. Nodes are placed in the zones in turn, alternating
+// between regions, so that every zone and every region has as few nodes as possible. It fails if a zone would have a
+// majority of the nodes, which happens with a single zone, or with two zones and an odd number of nodes, unless the
+// cluster has a single node.
+//
+// Placements where the loss of a zone still breaks quorum, such as a single node or 4 nodes in 3 zones, are planned
+// with warnings.
+func PlanRaftPlacement(zones []RaftZone, nodes int) (placement *RaftPlacement, err error) {
+	if nodes < 1 {
+		return nil, fmt.Errorf("a Raft cluster needs at least 1 node")
+	}
+	if len(zones) == 0 {
+		return nil, fmt.Errorf("no zones to place the nodes in")
+	}
+
+	// order the zones so that consecutive zones are in different regions where possible
+	var regions []string
+	zonesByRegion := map[string][]RaftZone{}
+	seen := map[string]bool{}
+	withRegions := false
+	for _, zone := range zones {
+		if zone.Name == "" {
+			return nil, fmt.Errorf("zones need a name")
+		}
+		if seen[zone.Name] {
+			return nil, fmt.Errorf("zone %s is listed twice", zone.Name)
+		}
+		seen[zone.Name] = true
+		if _, ok := zonesByRegion[zone.Region]; !ok {
+			regions = append(regions, zone.Region)
+		}
+		zonesByRegion[zone.Region] = append(zonesByRegion[zone.Region], zone)
+		withRegions = withRegions || zone.Region != ""
+	}
+	var ordered []RaftZone
+	for i := 0; len(ordered) < len(zones); i++ {
+		for _, region := range regions {
+			if i < len(zonesByRegion[region]) {
+				ordered = append(ordered, zonesByRegion[region][i])
+			}
+		}
+	}
+
+	placement = &RaftPlacement{Zone: make([]string, nodes)}
+	if withRegions {
+		placement.Region = make([]string, nodes)
+	}
+	for i := 0; i < nodes; i++ {
+		zone := ordered[i%len(ordered)]
+		placement.Zone[i] = zone.Name
+		if withRegions {
+			placement.Region[i] = zone.Region
+		}
+	}
+	if largest := (nodes + len(ordered) - 1) / len(ordered); nodes > 1 && largest*2 > nodes {
+		return nil, fmt.Errorf("%d nodes in %d zones give a zone a majority of the nodes", nodes, len(ordered))
+	}
+	placement.Warnings = CheckRaftZones(placement.Zone)
+	return placement, nil
+}
+
+// Apply sets the zones and regions of the placement on the options of new orderers. The options must have a crypto
+// object per node of the placement.
+func (placement *RaftPlacement) Apply(options *CreateOrdererOptions) error {
+	if len(options.Crypto) != len(placement.Zone) {
+		return fmt.Errorf("the placement is for %d nodes, the options have %d", len(placement.Zone), len(options.Crypto))
+	}
+	options.Zone = append([]string{}, placement.Zone...)
+	options.Region = nil
+	if placement.Region != nil {
+		options.Region = append([]string{}, placement.Region...)
+	}
+	return nil
+}
+
+// CheckRaftZones returns a warning for every zone whose loss would leave the nodes of a Raft cluster, given by their
+// zones, without quorum. Nodes without a zone could be anywhere and are warned about too.
+func CheckRaftZones(zones []string) (warnings []string) {
+	nodes := len(zones)
+	quorum := nodes/2 + 1
+	counts := map[string]int{}
+	var names []string
+	for _, zone := range zones {
+		if counts[zone] == 0 {
+			names = append(names, zone)
+		}
+		counts[zone]++
+	}
+	sort.Strings(names)
+	for _, zone := range names {
+		if zone == "" {
+			warnings = append(warnings, fmt.Sprintf("%d of %d nodes have no zone", counts[zone], nodes))
+			continue
+		}
+		if left := nodes - counts[zone]; left < quorum {
+			warnings = append(warnings, fmt.Sprintf("losing zone %s leaves %d of %d nodes, short of the quorum of %d", zone, left, nodes, quorum))
+		}
+	}
+	return
+}
+
+// RaftClusterLayout : The zones of the nodes of a Raft cluster.
+type RaftClusterLayout struct {
+	ClusterID string
+
+	ClusterName string
+
+	// The ids of the nodes, sorted.
+	NodeIDs []string
+
+	// The number of nodes in each zone. Nodes without a zone are counted under "".
+	Zones map[string]int
+
+	// Where the cluster does not survive the loss of a zone, see CheckRaftZones.
+	Warnings []string
+}
+
+// RaftClusterLayouts groups orderer nodes by cluster id and checks the zones of each cluster. Components that are not
+// orderers are ignored. Clusters are sorted by name, then id.
+func RaftClusterLayouts(components []GenericComponentResponse) (layouts []*RaftClusterLayout) {
+	byCluster := map[string]*RaftClusterLayout{}
+	zones := map[string][]string{}
+	for _, component := range components {
+		if core.StringNilMapper(component.Type) != ComponentType_FabricOrderer {
+			continue
+		}
+		clusterID := core.StringNilMapper(component.ClusterID)
+		layout := byCluster[clusterID]
+		if layout == nil {
+			layout = &RaftClusterLayout{ClusterID: clusterID, ClusterName: core.StringNilMapper(component.ClusterName), Zones: map[string]int{}}
+			byCluster[clusterID] = layout
+			layouts = append(layouts, layout)
+		}
+		zone := core.StringNilMapper(component.Zone)
+		layout.NodeIDs = append(layout.NodeIDs, core.StringNilMapper(component.ID))
+		layout.Zones[zone]++
+		zones[clusterID] = append(zones[clusterID], zone)
+	}
+	for _, layout := range layouts {
+		sort.Strings(layout.NodeIDs)
+		layout.Warnings = CheckRaftZones(zones[layout.ClusterID])
+	}
+	sort.Slice(layouts, func(i, j int) bool {
+		if layouts[i].ClusterName != layouts[j].ClusterName {
+			return layouts[i].ClusterName < layouts[j].ClusterName
+		}
+		return layouts[i].ClusterID < layouts[j].ClusterID
+	})
+	return
+}
+
+// GetRaftClusterLayouts returns the layouts of the Raft clusters of the console, see RaftClusterLayouts.
+func (blockchain *BlockchainV3) GetRaftClusterLayouts(ctx context.Context) ([]*RaftClusterLayout, error) {
+	options := blockchain.NewGetComponentsByTypeOptions(ComponentType_FabricOrderer).
+		SetDeploymentAttrs(GetComponentsByTypeOptions_DeploymentAttrs_Included)
+	orderers, _, err := blockchain.GetComponentsByTypeWithContext(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return RaftClusterLayouts(orderers.Components), nil
+}
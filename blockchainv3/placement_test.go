@@ -0,0 +1,115 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe(`BlockchainV3 Raft placement`, func() {
+	It(`Spreads nodes over zones and regions`, func() {
+		placement, err := blockchainv3.PlanRaftPlacement([]blockchainv3.RaftZone{{Name: "dal10"}, {Name: "dal12"}, {Name: "dal13"}}, 5)
+		Expect(err).To(BeNil())
+		Expect(placement.Zone).To(Equal([]string{"dal10", "dal12", "dal13", "dal10", "dal12"}))
+		Expect(placement.Region).To(BeNil())
+		Expect(placement.Warnings).To(BeEmpty())
+
+		placement, err = blockchainv3.PlanRaftPlacement([]blockchainv3.RaftZone{
+			{Name: "dal10", Region: "us-south"}, {Name: "dal12", Region: "us-south"},
+			{Name: "wdc04", Region: "us-east"}, {Name: "wdc06", Region: "us-east"},
+		}, 4)
+		Expect(err).To(BeNil())
+		Expect(placement.Zone).To(Equal([]string{"dal10", "wdc04", "dal12", "wdc06"}))
+		Expect(placement.Region).To(Equal([]string{"us-south", "us-east", "us-south", "us-east"}))
+		// a zone has a quarter of the nodes, losing it leaves 3 of 4
+		Expect(placement.Warnings).To(BeEmpty())
+	})
+	It(`Refuses single-zone majorities and warns about quorum loss`, func() {
+		_, err := blockchainv3.PlanRaftPlacement([]blockchainv3.RaftZone{{Name: "dal10"}}, 3)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("3 nodes in 1 zones give a zone a majority of the nodes"))
+		_, err = blockchainv3.PlanRaftPlacement([]blockchainv3.RaftZone{{Name: "dal10"}, {Name: "dal12"}}, 5)
+		Expect(err).ToNot(BeNil())
+		_, err = blockchainv3.PlanRaftPlacement([]blockchainv3.RaftZone{{Name: "dal10"}, {Name: "dal10"}}, 2)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("zone dal10 is listed twice"))
+
+		placement, err := blockchainv3.PlanRaftPlacement([]blockchainv3.RaftZone{{Name: "dal10"}, {Name: "dal12"}, {Name: "dal13"}}, 4)
+		Expect(err).To(BeNil())
+		Expect(placement.Warnings).To(Equal([]string{"losing zone dal10 leaves 2 of 4 nodes, short of the quorum of 3"}))
+
+		// a single node, the default size of an ordering service, cannot survive the loss of its zone
+		placement, err = blockchainv3.PlanRaftPlacement([]blockchainv3.RaftZone{{Name: "dal10"}, {Name: "dal12"}}, 1)
+		Expect(err).To(BeNil())
+		Expect(placement.Zone).To(Equal([]string{"dal10"}))
+		Expect(placement.Warnings).To(Equal([]string{"losing zone dal10 leaves 0 of 1 nodes, short of the quorum of 1"}))
+
+		Expect(blockchainv3.CheckRaftZones([]string{"dal10", "dal10", "dal12", ""})).To(Equal([]string{
+			"1 of 4 nodes have no zone",
+			"losing zone dal10 leaves 2 of 4 nodes, short of the quorum of 3",
+		}))
+	})
+	It(`Applies placements to orderer options`, func() {
+		service, err := blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{URL: "http://localhost", Authenticator: &core.NoAuthAuthenticator{}})
+		Expect(err).To(BeNil())
+		placement, err := blockchainv3.PlanRaftPlacement([]blockchainv3.RaftZone{{Name: "dal10"}, {Name: "dal12"}, {Name: "dal13"}}, 3)
+		Expect(err).To(BeNil())
+		options := service.NewCreateOrdererOptions("raft", "ordererorg", "orderer", []blockchainv3.CryptoObject{{}, {}, {}})
+		Expect(placement.Apply(options)).To(BeNil())
+		Expect(options.Zone).To(Equal([]string{"dal10", "dal12", "dal13"}))
+		Expect(options.Region).To(BeNil())
+		options.Crypto = options.Crypto[:1]
+		Expect(placement.Apply(options)).ToNot(BeNil())
+	})
+	It(`Checks the layout of existing clusters`, func() {
+		testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			Expect(req.URL.Path).To(Equal("/ak/api/v3/components/types/fabric-orderer"))
+			Expect(req.URL.Query().Get("deployment_attrs")).To(Equal("included"))
+			res.Header().Set("Content-type", "application/json")
+			res.WriteHeader(200)
+			fmt.Fprintf(res, `{"components": [
+				{"id": "os1", "type": "fabric-orderer", "cluster_id": "c1", "cluster_name": "orderer", "zone": "dal10"},
+				{"id": "os2", "type": "fabric-orderer", "cluster_id": "c1", "cluster_name": "orderer", "zone": "dal12"},
+				{"id": "os3", "type": "fabric-orderer", "cluster_id": "c1", "cluster_name": "orderer", "zone": "dal13"},
+				{"id": "single2", "type": "fabric-orderer", "cluster_id": "c2", "cluster_name": "dev", "zone": "dal10"},
+				{"id": "single1", "type": "fabric-orderer", "cluster_id": "c2", "cluster_name": "dev", "zone": "dal10"}
+			]}`)
+		}))
+		defer testServer.Close()
+		service, err := blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{URL: testServer.URL, Authenticator: &core.NoAuthAuthenticator{}})
+		Expect(err).To(BeNil())
+
+		layouts, err := service.GetRaftClusterLayouts(context.Background())
+		Expect(err).To(BeNil())
+		Expect(layouts).To(HaveLen(2))
+		Expect(layouts[0].ClusterName).To(Equal("dev"))
+		Expect(layouts[0].NodeIDs).To(Equal([]string{"single1", "single2"}))
+		Expect(layouts[0].Zones).To(Equal(map[string]int{"dal10": 2}))
+		Expect(layouts[0].Warnings).To(Equal([]string{"losing zone dal10 leaves 0 of 2 nodes, short of the quorum of 2"}))
+		Expect(layouts[1].ClusterID).To(Equal("c1"))
+		Expect(layouts[1].Zones).To(Equal(map[string]int{"dal10": 1, "dal12": 1, "dal13": 1}))
+		Expect(layouts[1].Warnings).To(BeEmpty())
+	})
+})
@@ -0,0 +1,521 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/common"
+	"github.com/IBM/go-sdk-core/v4/core"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// PostmanCollectionSchema is the schema URL of Postman v2.1 collections.
+const PostmanCollectionSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// PostmanCollection : A Postman v2.1 collection, as generated by GetPostman.
+//
+// Parts of the format the SDK does not edit (descriptions, events, saved responses, body options) are kept as raw JSON
+// so that a collection survives a load/save round trip.
+type PostmanCollection struct {
+	Info PostmanInfo `json:"info"`
+
+	// The requests and folders of the collection.
+	Item []PostmanItem `json:"item"`
+
+	// The default auth of every request.
+	Auth *PostmanAuth `json:"auth,omitempty"`
+
+	// Collection variables, referenced as `{{key}}` in requests.
+	Variable []PostmanVariable `json:"variable,omitempty"`
+
+	// Pre-request and test scripts.
+	Event []json.RawMessage `json:"event,omitempty"`
+}
+
+// PostmanInfo : Metadata of a Postman collection.
+type PostmanInfo struct {
+	PostmanID string `json:"_postman_id,omitempty"`
+
+	Name string `json:"name"`
+
+	// A string, or an object with `content` and `type`.
+	Description json.RawMessage `json:"description,omitempty"`
+
+	Schema string `json:"schema"`
+}
+
+// PostmanItem : A request, or a folder of items when Item is set.
+type PostmanItem struct {
+	ID string `json:"id,omitempty"`
+
+	Name string `json:"name,omitempty"`
+
+	// A string, or an object with `content` and `type`.
+	Description json.RawMessage `json:"description,omitempty"`
+
+	// The items of a folder.
+	Item []PostmanItem `json:"item,omitempty"`
+
+	// The request of a request item.
+	Request *PostmanRequest `json:"request,omitempty"`
+
+	// Saved example responses.
+	Response []json.RawMessage `json:"response,omitempty"`
+
+	Auth *PostmanAuth `json:"auth,omitempty"`
+
+	Event []json.RawMessage `json:"event,omitempty"`
+}
+
+// PostmanRequest : A request of a Postman collection.
+type PostmanRequest struct {
+	Method string `json:"method,omitempty"`
+
+	URL *PostmanURL `json:"url,omitempty"`
+
+	Header []PostmanHeader `json:"header,omitempty"`
+
+	Body *PostmanBody `json:"body,omitempty"`
+
+	Auth *PostmanAuth `json:"auth,omitempty"`
+
+	Description json.RawMessage `json:"description,omitempty"`
+}
+
+// PostmanURL : The URL of a request. Postman accepts a plain string as well, which is decoded into Raw.
+type PostmanURL struct {
+	// The full URL, possibly with `{{variables}}`.
+	Raw string `json:"raw,omitempty"`
+
+	Protocol string `json:"protocol,omitempty"`
+
+	// The host name split on dots.
+	Host []string `json:"host,omitempty"`
+
+	Port string `json:"port,omitempty"`
+
+	// The path split on slashes.
+	Path []string `json:"path,omitempty"`
+
+	Query []PostmanVariable `json:"query,omitempty"`
+
+	// Values of `:name` path variables.
+	Variable []PostmanVariable `json:"variable,omitempty"`
+}
+
+// UnmarshalJSON accepts a URL object or a plain string.
+func (postmanURL *PostmanURL) UnmarshalJSON(data []byte) error {
+	var raw string
+	if json.Unmarshal(data, &raw) == nil {
+		*postmanURL = PostmanURL{Raw: raw}
+		return nil
+	}
+	type plain PostmanURL
+	return json.Unmarshal(data, (*plain)(postmanURL))
+}
+
+// PostmanHeader : A request header.
+type PostmanHeader struct {
+	Key string `json:"key"`
+
+	Value string `json:"value"`
+
+	Type string `json:"type,omitempty"`
+
+	Disabled bool `json:"disabled,omitempty"`
+
+	Description json.RawMessage `json:"description,omitempty"`
+}
+
+// PostmanBody : A request body.
+type PostmanBody struct {
+	// `raw`, `urlencoded`, `formdata`, `file` or `graphql`.
+	Mode string `json:"mode,omitempty"`
+
+	Raw string `json:"raw,omitempty"`
+
+	Options json.RawMessage `json:"options,omitempty"`
+
+	Urlencoded json.RawMessage `json:"urlencoded,omitempty"`
+
+	Formdata json.RawMessage `json:"formdata,omitempty"`
+}
+
+// PostmanAuth : The auth of a collection, folder or request. The parameters of type Type are in the field of the same
+// name, e.g. the token of `bearer` auth is the `token` entry of Bearer.
+type PostmanAuth struct {
+	Type string `json:"type"`
+
+	Bearer []PostmanVariable `json:"bearer,omitempty"`
+
+	Basic []PostmanVariable `json:"basic,omitempty"`
+
+	Apikey []PostmanVariable `json:"apikey,omitempty"`
+}
+
+// PostmanVariable : A key/value pair, used for variables, query parameters and auth parameters.
+type PostmanVariable struct {
+	ID string `json:"id,omitempty"`
+
+	Key string `json:"key"`
+
+	Value interface{} `json:"value,omitempty"`
+
+	Type string `json:"type,omitempty"`
+
+	Disabled bool `json:"disabled,omitempty"`
+
+	Description json.RawMessage `json:"description,omitempty"`
+}
+
+// LoadPostmanCollection reads a collection file.
+func LoadPostmanCollection(path string) (collection *PostmanCollection, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	collection = new(PostmanCollection)
+	err = json.Unmarshal(data, collection)
+	if err != nil {
+		err = fmt.Errorf("postman collection %s: %s", path, err.Error())
+		collection = nil
+	}
+	return
+}
+
+// Save writes the collection to path, creating missing directories. The file is only readable by its owner because
+// collections embed credentials.
+func (collection *PostmanCollection) Save(path string) error {
+	data, err := json.MarshalIndent(collection, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// Requests returns the request items of the collection, descending into folders.
+func (collection *PostmanCollection) Requests() (requests []*PostmanItem) {
+	collection.walk(func(item *PostmanItem) {
+		if item.Request != nil {
+			requests = append(requests, item)
+		}
+	})
+	return
+}
+
+// walk calls visit with every item of the collection, folders before their items.
+func (collection *PostmanCollection) walk(visit func(*PostmanItem)) {
+	var walk func(items []PostmanItem)
+	walk = func(items []PostmanItem) {
+		for i := range items {
+			visit(&items[i])
+			walk(items[i].Item)
+		}
+	}
+	walk(collection.Item)
+}
+
+// GetVariable returns the value of a collection variable, or nil if it is not defined.
+func (collection *PostmanCollection) GetVariable(key string) interface{} {
+	for _, variable := range collection.Variable {
+		if variable.Key == key {
+			return variable.Value
+		}
+	}
+	return nil
+}
+
+// SetVariable sets a collection variable, adding it if it is not defined.
+func (collection *PostmanCollection) SetVariable(key string, value string) {
+	for i := range collection.Variable {
+		if collection.Variable[i].Key == key {
+			collection.Variable[i].Value = value
+			return
+		}
+	}
+	collection.Variable = append(collection.Variable, PostmanVariable{Key: key, Value: value, Type: "string"})
+}
+
+// postmanVariableReference matches a URL that starts with a variable, e.g. `{{base_url}}/ak/api/v3/components`.
+var postmanVariableReference = regexp.MustCompile(`^\{\{([^{}]+)\}\}`)
+
+// SetBaseURL points every request of the collection to another console, e.g. `https://console.example.com:443`.
+// Requests whose URL starts with a variable keep it and the variable is set instead. The path of baseURL, if any,
+// is prepended to the path of every request.
+func (collection *PostmanCollection) SetBaseURL(baseURL string) error {
+	base, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return err
+	}
+	if base.Scheme == "" || base.Host == "" {
+		return fmt.Errorf("base URL %q is not an absolute URL", baseURL)
+	}
+	basePath := strings.Trim(base.Path, "/")
+
+	for _, item := range collection.Requests() {
+		target := item.Request.URL
+		if target == nil {
+			continue
+		}
+		if match := postmanVariableReference.FindStringSubmatch(target.Raw); match != nil {
+			collection.SetVariable(match[1], base.String())
+			continue
+		}
+
+		rest := target.Raw
+		if i := strings.Index(rest, "://"); i >= 0 {
+			rest = rest[i+3:]
+		}
+		if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+			rest = rest[i:]
+		} else {
+			rest = ""
+		}
+		if basePath != "" {
+			rest = "/" + basePath + rest
+		}
+		target.Raw = base.Scheme + "://" + base.Host + rest
+		target.Protocol = base.Scheme
+		target.Host = strings.Split(base.Hostname(), ".")
+		target.Port = base.Port()
+		if basePath != "" {
+			target.Path = append(strings.Split(basePath, "/"), target.Path...)
+		}
+	}
+	return nil
+}
+
+// SetBearerAuth makes every request of the collection authenticate with an IAM bearer token. Auth set on folders or
+// requests and `Authorization` headers are removed so that requests inherit the auth of the collection.
+func (collection *PostmanCollection) SetBearerAuth(token string) {
+	collection.setAuth(&PostmanAuth{
+		Type:   "bearer",
+		Bearer: []PostmanVariable{{Key: "token", Value: token, Type: "string"}},
+	})
+}
+
+// SetBasicAuth makes every request of the collection authenticate with an IBP api key and secret, or another basic
+// auth username and password. See SetBearerAuth.
+func (collection *PostmanCollection) SetBasicAuth(username string, password string) {
+	collection.setAuth(&PostmanAuth{
+		Type: "basic",
+		Basic: []PostmanVariable{
+			{Key: "username", Value: username, Type: "string"},
+			{Key: "password", Value: password, Type: "string"},
+		},
+	})
+}
+
+func (collection *PostmanCollection) setAuth(auth *PostmanAuth) {
+	collection.Auth = auth
+	collection.walk(func(item *PostmanItem) {
+		item.Auth = nil
+		if item.Request == nil {
+			return
+		}
+		item.Request.Auth = nil
+		headers := item.Request.Header[:0]
+		for _, header := range item.Request.Header {
+			if !strings.EqualFold(header.Key, "Authorization") {
+				headers = append(headers, header)
+			}
+		}
+		item.Request.Header = headers
+	})
+}
+
+// SetApiKeyAuth replaces the IAM api key of a collection generated with the `api_key` auth type. The key is replaced
+// in every variable and `apikey` auth parameter named like an api key (`api_key`, `apikey`, `apiKey`). An error is
+// returned if the collection holds no api key.
+func (collection *PostmanCollection) SetApiKeyAuth(apiKey string) error {
+	replaced := 0
+	replace := func(variables []PostmanVariable, names func(string) bool) {
+		for i := range variables {
+			if names(variables[i].Key) {
+				variables[i].Value = apiKey
+				replaced++
+			}
+		}
+	}
+
+	replace(collection.Variable, isPostmanApiKeyName)
+	for _, auth := range collection.auths() {
+		// the `value` parameter of Postman's own apikey auth holds the key
+		replace(auth.Apikey, func(key string) bool { return key == "value" || isPostmanApiKeyName(key) })
+	}
+
+	if replaced == 0 {
+		return fmt.Errorf("the collection holds no api key")
+	}
+	return nil
+}
+
+// auths returns every auth of the collection, folders and requests.
+func (collection *PostmanCollection) auths() (auths []*PostmanAuth) {
+	if collection.Auth != nil {
+		auths = append(auths, collection.Auth)
+	}
+	collection.walk(func(item *PostmanItem) {
+		if item.Auth != nil {
+			auths = append(auths, item.Auth)
+		}
+		if item.Request != nil && item.Request.Auth != nil {
+			auths = append(auths, item.Request.Auth)
+		}
+	})
+	return
+}
+
+func isPostmanApiKeyName(key string) bool {
+	return strings.EqualFold(strings.Replace(key, "_", "", -1), "apikey")
+}
+
+// validatePostmanCredentials checks that the credentials required by the auth type are set.
+func validatePostmanCredentials(options *GetPostmanOptions) error {
+	var missing []string
+	switch *options.AuthType {
+	case GetPostmanOptions_AuthType_Bearer:
+		if options.Token == nil || *options.Token == "" {
+			missing = append(missing, "token")
+		}
+	case GetPostmanOptions_AuthType_ApiKey:
+		if options.ApiKey == nil || *options.ApiKey == "" {
+			missing = append(missing, "api_key")
+		}
+	case GetPostmanOptions_AuthType_Basic:
+		if options.Username == nil || *options.Username == "" {
+			missing = append(missing, "username")
+		}
+		if options.Password == nil || *options.Password == "" {
+			missing = append(missing, "password")
+		}
+	default:
+		return fmt.Errorf("unknown auth_type %q, expected %s, %s or %s", *options.AuthType,
+			GetPostmanOptions_AuthType_Bearer, GetPostmanOptions_AuthType_ApiKey, GetPostmanOptions_AuthType_Basic)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("auth_type %s requires %s", *options.AuthType, strings.Join(missing, " and "))
+	}
+	return nil
+}
+
+// GetPostmanCollection : Generate and decode a Postman collection
+// Like GetPostman, but returns the generated collection. The credentials required by the auth type are checked before
+// the request is sent: `token` for `bearer`, `api_key` for `api_key`, and `username` and `password` for `basic`.
+func (blockchain *BlockchainV3) GetPostmanCollection(getPostmanOptions *GetPostmanOptions) (result *PostmanCollection, response *core.DetailedResponse, err error) {
+	return blockchain.GetPostmanCollectionWithContext(context.Background(), getPostmanOptions)
+}
+
+// GetPostmanCollectionWithContext is an alternate form of the GetPostmanCollection method which supports a Context parameter
+func (blockchain *BlockchainV3) GetPostmanCollectionWithContext(ctx context.Context, getPostmanOptions *GetPostmanOptions) (result *PostmanCollection, response *core.DetailedResponse, err error) {
+	err = core.ValidateNotNil(getPostmanOptions, "getPostmanOptions cannot be nil")
+	if err != nil {
+		return
+	}
+	err = core.ValidateStruct(getPostmanOptions, "getPostmanOptions")
+	if err != nil {
+		return
+	}
+	err = validatePostmanCredentials(getPostmanOptions)
+	if err != nil {
+		return
+	}
+
+	builder := core.NewRequestBuilder(core.GET)
+	builder = builder.WithContext(ctx)
+	builder.EnableGzipCompression = blockchain.GetEnableGzipCompression()
+	_, err = builder.ResolveRequestURL(blockchain.Service.Options.URL, `/ak/api/v3/postman`, nil)
+	if err != nil {
+		return
+	}
+
+	for headerName, headerValue := range getPostmanOptions.Headers {
+		builder.AddHeader(headerName, headerValue)
+	}
+
+	sdkHeaders := common.GetSdkHeaders("blockchain", "V3", "GetPostman")
+	for headerName, headerValue := range sdkHeaders {
+		builder.AddHeader(headerName, headerValue)
+	}
+	builder.AddHeader("Accept", "application/json")
+
+	builder.AddQuery("auth_type", fmt.Sprint(*getPostmanOptions.AuthType))
+	if getPostmanOptions.Token != nil {
+		builder.AddQuery("token", fmt.Sprint(*getPostmanOptions.Token))
+	}
+	if getPostmanOptions.ApiKey != nil {
+		builder.AddQuery("api_key", fmt.Sprint(*getPostmanOptions.ApiKey))
+	}
+	if getPostmanOptions.Username != nil {
+		builder.AddQuery("username", fmt.Sprint(*getPostmanOptions.Username))
+	}
+	if getPostmanOptions.Password != nil {
+		builder.AddQuery("password", fmt.Sprint(*getPostmanOptions.Password))
+	}
+
+	request, err := builder.Build()
+	if err != nil {
+		return
+	}
+
+	// the collection is served as a download, so its content type is not always JSON
+	var body io.ReadCloser
+	response, err = blockchain.Service.Request(request, &body)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+	result = new(PostmanCollection)
+	err = json.NewDecoder(body).Decode(result)
+	if err != nil {
+		err = fmt.Errorf("decoding the postman collection: %s", err.Error())
+		result = nil
+		return
+	}
+	response.Result = result
+
+	return
+}
+
+// DownloadPostmanCollection : Generate a Postman collection and write it to a file
+// Calls GetPostmanCollection and saves the collection to path. See PostmanCollection.Save.
+func (blockchain *BlockchainV3) DownloadPostmanCollection(getPostmanOptions *GetPostmanOptions, path string) (result *PostmanCollection, response *core.DetailedResponse, err error) {
+	return blockchain.DownloadPostmanCollectionWithContext(context.Background(), getPostmanOptions, path)
+}
+
+// DownloadPostmanCollectionWithContext is an alternate form of the DownloadPostmanCollection method which supports a Context parameter
+func (blockchain *BlockchainV3) DownloadPostmanCollectionWithContext(ctx context.Context, getPostmanOptions *GetPostmanOptions, path string) (result *PostmanCollection, response *core.DetailedResponse, err error) {
+	result, response, err = blockchain.GetPostmanCollectionWithContext(ctx, getPostmanOptions)
+	if err != nil {
+		return
+	}
+	err = result.Save(path)
+	return
+}
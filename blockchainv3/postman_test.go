@@ -0,0 +1,171 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+)
+
+const postmanTestCollection = `{
+  "info": {"_postman_id": "1234", "name": "IBM Blockchain Platform", "description": {"content": "APIs", "type": "text/markdown"},
+    "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+  "item": [
+    {"name": "Components", "item": [
+      {"name": "Get all components", "request": {"method": "GET",
+        "header": [{"key": "Authorization", "value": "Bearer old-token"}, {"key": "Accept", "value": "application/json"}],
+        "url": {"raw": "https://old.console.com:8443/ak/api/v3/components?deployment_attrs=included", "protocol": "https",
+          "host": ["old", "console", "com"], "port": "8443", "path": ["ak", "api", "v3", "components"],
+          "query": [{"key": "deployment_attrs", "value": "included"}]}},
+        "response": [{"name": "example", "code": 200}]},
+      {"name": "Delete all components", "request": {"method": "DELETE", "auth": {"type": "bearer", "bearer": [{"key": "token", "value": "old-token"}]},
+        "url": "https://old.console.com:8443/ak/api/v3/kubernetes/components/purge"}}
+    ]},
+    {"name": "Get settings", "request": {"method": "GET", "url": {"raw": "{{base_url}}/ak/api/v3/settings"}},
+      "event": [{"listen": "test", "script": {"exec": ["pm.test()"]}}]}
+  ],
+  "variable": [{"key": "base_url", "value": "https://old.console.com:8443"}, {"key": "api_key", "value": "old-key"}]
+}`
+
+var _ = Describe(`BlockchainV3 Postman collections`, func() {
+	var testServer *httptest.Server
+	var lastQuery string
+
+	BeforeEach(func() {
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			Expect(req.URL.Path).To(Equal("/ak/api/v3/postman"))
+			lastQuery = req.URL.RawQuery
+			res.Header().Set("Content-type", "application/octet-stream")
+			res.WriteHeader(200)
+			fmt.Fprint(res, postmanTestCollection)
+		}))
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	newService := func() *blockchainv3.BlockchainV3 {
+		blockchainService, serviceErr := blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(serviceErr).To(BeNil())
+		return blockchainService
+	}
+
+	It(`Decodes the generated collection`, func() {
+		blockchainService := newService()
+		collection, response, err := blockchainService.GetPostmanCollection(blockchainService.NewGetPostmanOptions(blockchainv3.GetPostmanOptions_AuthType_Bearer).SetToken("old-token"))
+		Expect(err).To(BeNil())
+		Expect(response.Result).To(Equal(collection))
+		Expect(lastQuery).To(Equal("auth_type=bearer&token=old-token"))
+
+		Expect(collection.Info.Name).To(Equal("IBM Blockchain Platform"))
+		Expect(collection.Info.Schema).To(Equal(blockchainv3.PostmanCollectionSchema))
+		requests := collection.Requests()
+		Expect(requests).To(HaveLen(3))
+		Expect(requests[0].Request.URL.Path).To(Equal([]string{"ak", "api", "v3", "components"}))
+		Expect(requests[1].Request.URL.Raw).To(Equal("https://old.console.com:8443/ak/api/v3/kubernetes/components/purge"))
+		Expect(collection.GetVariable("api_key")).To(Equal("old-key"))
+	})
+	It(`Checks the credentials of each auth type`, func() {
+		blockchainService := newService()
+		for authType, missing := range map[string]string{
+			blockchainv3.GetPostmanOptions_AuthType_Bearer: "token",
+			blockchainv3.GetPostmanOptions_AuthType_ApiKey: "api_key",
+			blockchainv3.GetPostmanOptions_AuthType_Basic:  "username and password",
+		} {
+			_, _, err := blockchainService.GetPostmanCollection(blockchainService.NewGetPostmanOptions(authType))
+			Expect(err).ToNot(BeNil())
+			Expect(err.Error()).To(Equal(fmt.Sprintf("auth_type %s requires %s", authType, missing)))
+		}
+		_, _, err := blockchainService.GetPostmanCollection(blockchainService.NewGetPostmanOptions("oauth"))
+		Expect(err).ToNot(BeNil())
+		_, _, err = blockchainService.GetPostmanCollection(nil)
+		Expect(err).ToNot(BeNil())
+	})
+	It(`Writes the collection to disk and reads it back`, func() {
+		tempDir, err := ioutil.TempDir("", "postman")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(tempDir)
+		path := filepath.Join(tempDir, "collections", "basic.json")
+
+		blockchainService := newService()
+		options := blockchainService.NewGetPostmanOptions(blockchainv3.GetPostmanOptions_AuthType_Basic).SetUsername("key").SetPassword("secret")
+		collection, _, err := blockchainService.DownloadPostmanCollection(options, path)
+		Expect(err).To(BeNil())
+		info, err := os.Stat(path)
+		Expect(err).To(BeNil())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+		loaded, err := blockchainv3.LoadPostmanCollection(path)
+		Expect(err).To(BeNil())
+		saved, _ := json.Marshal(loaded)
+		downloaded, _ := json.Marshal(collection)
+		Expect(saved).To(MatchJSON(downloaded))
+		Expect(string(loaded.Info.Description)).To(MatchJSON(`{"content": "APIs", "type": "text/markdown"}`))
+		Expect(loaded.Item[1].Event).To(HaveLen(1))
+	})
+	It(`Rewrites the base URL`, func() {
+		blockchainService := newService()
+		collection, _, err := blockchainService.GetPostmanCollection(blockchainService.NewGetPostmanOptions(blockchainv3.GetPostmanOptions_AuthType_ApiKey).SetApiKey("old-key"))
+		Expect(err).To(BeNil())
+
+		Expect(collection.SetBaseURL("ftp//nowhere")).ToNot(BeNil())
+		Expect(collection.SetBaseURL("https://new.example.com/ibp/")).To(BeNil())
+		requests := collection.Requests()
+		Expect(requests[0].Request.URL.Raw).To(Equal("https://new.example.com/ibp/ak/api/v3/components?deployment_attrs=included"))
+		Expect(requests[0].Request.URL.Host).To(Equal([]string{"new", "example", "com"}))
+		Expect(requests[0].Request.URL.Port).To(Equal(""))
+		Expect(requests[0].Request.URL.Path).To(Equal([]string{"ibp", "ak", "api", "v3", "components"}))
+		Expect(requests[1].Request.URL.Raw).To(Equal("https://new.example.com/ibp/ak/api/v3/kubernetes/components/purge"))
+		Expect(requests[2].Request.URL.Raw).To(Equal("{{base_url}}/ak/api/v3/settings"))
+		Expect(collection.GetVariable("base_url")).To(Equal("https://new.example.com/ibp"))
+	})
+	It(`Rewrites the auth`, func() {
+		blockchainService := newService()
+		collection, _, err := blockchainService.GetPostmanCollection(blockchainService.NewGetPostmanOptions(blockchainv3.GetPostmanOptions_AuthType_Bearer).SetToken("old-token"))
+		Expect(err).To(BeNil())
+
+		collection.SetBasicAuth("customer-key", "customer-secret")
+		Expect(collection.Auth.Type).To(Equal("basic"))
+		Expect(collection.Auth.Basic).To(ContainElement(blockchainv3.PostmanVariable{Key: "password", Value: "customer-secret", Type: "string"}))
+		requests := collection.Requests()
+		Expect(requests[0].Request.Header).To(Equal([]blockchainv3.PostmanHeader{{Key: "Accept", Value: "application/json"}}))
+		Expect(requests[1].Request.Auth).To(BeNil())
+
+		collection.SetBearerAuth("customer-token")
+		Expect(collection.Auth.Bearer).To(Equal([]blockchainv3.PostmanVariable{{Key: "token", Value: "customer-token", Type: "string"}}))
+
+		Expect(collection.SetApiKeyAuth("customer-key")).To(BeNil())
+		Expect(collection.GetVariable("api_key")).To(Equal("customer-key"))
+		Expect((&blockchainv3.PostmanCollection{}).SetApiKeyAuth("customer-key")).ToNot(BeNil())
+
+		collection.SetVariable("env", "prod")
+		Expect(collection.GetVariable("env")).To(Equal("prod"))
+	})
+})
@@ -0,0 +1,99 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// RedactedValue replaces secrets in rendered requests and recorded interactions.
+const RedactedValue = "[REDACTED]"
+
+// sensitiveFields lists the (lower case) JSON properties of the API that carry secrets: enroll secrets, passwords,
+// private keys, HSM pins and console credentials.
+var sensitiveFields = map[string]bool{
+	"enroll_secret": true,
+	"pass":          true,
+	"password":      true,
+	"ekey":          true,
+	"tls_key":       true,
+	"keyfile":       true,
+	"pin":           true,
+	"token":         true,
+	"api_key":       true,
+	"api_secret":    true,
+	"private_key":   true,
+}
+
+// sensitiveHeaders lists the (canonical) HTTP headers that carry credentials.
+var sensitiveHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"Set-Cookie":    true,
+	"X-Api-Key":     true,
+}
+
+// redactHeaders returns a copy of header with the values of credential headers replaced by RedactedValue.
+func redactHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	if redacted == nil {
+		redacted = http.Header{}
+	}
+	for name := range redacted {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			redacted[name] = []string{RedactedValue}
+		}
+	}
+	return redacted
+}
+
+// redactJSON returns a copy of a JSON document with the values of secret properties replaced by RedactedValue. A body
+// that is not JSON is returned unchanged.
+func redactJSON(body []byte) []byte {
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if len(body) == 0 || decoder.Decode(&doc) != nil {
+		return body
+	}
+	redacted, err := json.Marshal(redactValue(doc))
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if sensitiveFields[strings.ToLower(key)] && item != nil {
+				v[key] = RedactedValue
+			} else {
+				v[key] = redactValue(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
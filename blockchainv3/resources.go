@@ -0,0 +1,441 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"fmt"
+	"github.com/IBM/go-sdk-core/v4/core"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// CpuQuantity : An amount of CPU in millicores, the unit of the "100m" form of Kubernetes CPU quantities.
+type CpuQuantity int64
+
+// MemoryQuantity : An amount of memory in bytes.
+type MemoryQuantity int64
+
+// StorageQuantity : An amount of disk space in bytes.
+type StorageQuantity int64
+
+// quantitySuffixes are the multipliers of the suffixes of Kubernetes quantities.
+var quantitySuffixes = map[string]*big.Rat{
+	"n":  big.NewRat(1, 1000000000),
+	"u":  big.NewRat(1, 1000000),
+	"m":  big.NewRat(1, 1000),
+	"":   big.NewRat(1, 1),
+	"k":  big.NewRat(1000, 1),
+	"M":  new(big.Rat).SetInt64(1000000),
+	"G":  new(big.Rat).SetInt64(1000000000),
+	"T":  new(big.Rat).SetInt64(1000000000000),
+	"P":  new(big.Rat).SetInt64(1000000000000000),
+	"E":  new(big.Rat).SetInt64(1000000000000000000),
+	"Ki": new(big.Rat).SetInt64(1 << 10),
+	"Mi": new(big.Rat).SetInt64(1 << 20),
+	"Gi": new(big.Rat).SetInt64(1 << 30),
+	"Ti": new(big.Rat).SetInt64(1 << 40),
+	"Pi": new(big.Rat).SetInt64(1 << 50),
+	"Ei": new(big.Rat).SetInt64(1 << 60),
+}
+
+// quantityPattern matches a Kubernetes quantity: a decimal number followed by a suffix or by an exponent.
+var quantityPattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]*)?|\.[0-9]+)(?:([eE][+-]?[0-9]+)|([a-zA-Z]*))$`)
+
+// parseQuantity parses a Kubernetes quantity and returns it in units of 1/scale, rounded up like Kubernetes rounds
+// quantities up to their precision.
+func parseQuantity(quantity string, scale int64) (int64, error) {
+	match := quantityPattern.FindStringSubmatch(strings.TrimSpace(quantity))
+	if match == nil {
+		return 0, fmt.Errorf("%q is not a quantity", quantity)
+	}
+	value, ok := new(big.Rat).SetString(match[1])
+	if !ok {
+		return 0, fmt.Errorf("%q is not a quantity", quantity)
+	}
+	if match[2] != "" {
+		exponent, ok := new(big.Rat).SetString("1" + match[2])
+		if !ok {
+			return 0, fmt.Errorf("%q is not a quantity", quantity)
+		}
+		value.Mul(value, exponent)
+	} else {
+		multiplier, ok := quantitySuffixes[match[3]]
+		if !ok {
+			return 0, fmt.Errorf("%q has the unknown suffix %q", quantity, match[3])
+		}
+		value.Mul(value, multiplier)
+	}
+	value.Mul(value, new(big.Rat).SetInt64(scale))
+	rounded := new(big.Int).Quo(value.Num(), value.Denom())
+	if new(big.Rat).SetInt(rounded).Cmp(value) < 0 {
+		rounded.Add(rounded, big.NewInt(1))
+	}
+	if !rounded.IsInt64() {
+		return 0, fmt.Errorf("%q is too large", quantity)
+	}
+	return rounded.Int64(), nil
+}
+
+// ParseCpuQuantity : Parse a Kubernetes CPU quantity, such as "100m", "0.5" or "2"
+// Fractions of a millicore are rounded up.
+func ParseCpuQuantity(quantity string) (CpuQuantity, error) {
+	millicores, err := parseQuantity(quantity, 1000)
+	return CpuQuantity(millicores), err
+}
+
+// ParseMemoryQuantity : Parse a Kubernetes memory quantity, such as "512Mi", "2Gi" or "1G"
+// Fractions of a byte are rounded up.
+func ParseMemoryQuantity(quantity string) (MemoryQuantity, error) {
+	bytes, err := parseQuantity(quantity, 1)
+	return MemoryQuantity(bytes), err
+}
+
+// ParseStorageQuantity : Parse a Kubernetes storage quantity, such as "100Gi"
+// Fractions of a byte are rounded up.
+func ParseStorageQuantity(quantity string) (StorageQuantity, error) {
+	bytes, err := parseQuantity(quantity, 1)
+	return StorageQuantity(bytes), err
+}
+
+// Cores returns the quantity in cores.
+func (quantity CpuQuantity) Cores() float64 {
+	return float64(quantity) / 1000
+}
+
+// String returns the quantity in the form Kubernetes uses: whole cores, or millicores.
+func (quantity CpuQuantity) String() string {
+	if quantity%1000 == 0 {
+		return fmt.Sprintf("%d", quantity/1000)
+	}
+	return fmt.Sprintf("%dm", int64(quantity))
+}
+
+// String returns the quantity with the largest binary suffix that represents it exactly.
+func (quantity MemoryQuantity) String() string {
+	return formatBytes(int64(quantity))
+}
+
+// String returns the quantity with the largest binary suffix that represents it exactly.
+func (quantity StorageQuantity) String() string {
+	return formatBytes(int64(quantity))
+}
+
+// formatBytes formats a number of bytes with the largest binary suffix that represents it exactly.
+func formatBytes(bytes int64) string {
+	for _, suffix := range []string{"Ei", "Pi", "Ti", "Gi", "Mi", "Ki"} {
+		multiplier := quantitySuffixes[suffix].Num().Int64()
+		if bytes != 0 && bytes%multiplier == 0 {
+			return fmt.Sprintf("%d%s", bytes/multiplier, suffix)
+		}
+	}
+	return fmt.Sprintf("%d", bytes)
+}
+
+// effectiveRequests returns the CPU and memory requests of a subcomponent as Kubernetes applies them: a request that is
+// not set defaults to its limit.
+func effectiveRequests(requests *ResourceRequests, limits *ResourceLimits) *ResourceRequests {
+	effective := &ResourceRequests{}
+	if requests != nil {
+		effective.Cpu, effective.Memory = requests.Cpu, requests.Memory
+	}
+	if limits != nil {
+		if effective.Cpu == nil {
+			effective.Cpu = limits.Cpu
+		}
+		if effective.Memory == nil {
+			effective.Memory = limits.Memory
+		}
+	}
+	return effective
+}
+
+// validateResourceObject checks that the requests and limits of a subcomponent are quantities and that no request
+// exceeds its limit. Limits that are not set default to the requests, and requests that are not set to the limits.
+func validateResourceObject(name string, requests *ResourceRequests, limits *ResourceLimits) error {
+	if requests == nil {
+		requests = &ResourceRequests{}
+	}
+	var requestedCpu CpuQuantity
+	var requestedMemory MemoryQuantity
+	var err error
+	if requests.Cpu != nil {
+		if requestedCpu, err = ParseCpuQuantity(*requests.Cpu); err != nil {
+			return fmt.Errorf("%s: requests.cpu: %s", name, err.Error())
+		}
+	}
+	if requests.Memory != nil {
+		if requestedMemory, err = ParseMemoryQuantity(*requests.Memory); err != nil {
+			return fmt.Errorf("%s: requests.memory: %s", name, err.Error())
+		}
+	}
+	if limits == nil {
+		return nil
+	}
+	if limits.Cpu != nil {
+		cpu, err := ParseCpuQuantity(*limits.Cpu)
+		if err != nil {
+			return fmt.Errorf("%s: limits.cpu: %s", name, err.Error())
+		}
+		if requests.Cpu != nil && requestedCpu > cpu {
+			return fmt.Errorf("%s: the cpu request %s exceeds the cpu limit %s", name, *requests.Cpu, *limits.Cpu)
+		}
+	}
+	if limits.Memory != nil {
+		memory, err := ParseMemoryQuantity(*limits.Memory)
+		if err != nil {
+			return fmt.Errorf("%s: limits.memory: %s", name, err.Error())
+		}
+		if requests.Memory != nil && requestedMemory > memory {
+			return fmt.Errorf("%s: the memory request %s exceeds the memory limit %s", name, *requests.Memory, *limits.Memory)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the requests and limits of every subcomponent are quantities and that no request exceeds its
+// limit.
+func (resources *PeerResources) Validate() error {
+	subcomponents := resources.subcomponents()
+	if resources.Statedb != nil && resources.Couchdb != nil {
+		subcomponents = append(subcomponents, resourceSubcomponent{"couchdb", resources.Couchdb.Requests, resources.Couchdb.Limits})
+	}
+	for _, subcomponent := range subcomponents {
+		if err := validateResourceObject(subcomponent.name, subcomponent.requests, subcomponent.limits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks that the requests and limits of every subcomponent are quantities and that no request exceeds its
+// limit.
+func (resources *CreateOrdererRaftBodyResources) Validate() error {
+	for _, subcomponent := range resources.subcomponents() {
+		if err := validateResourceObject(subcomponent.name, subcomponent.requests, subcomponent.limits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks that the requests and limits of the CA are quantities and that no request exceeds its limit.
+func (resources *CreateCaBodyResources) Validate() error {
+	for _, subcomponent := range resources.subcomponents() {
+		if err := validateResourceObject(subcomponent.name, subcomponent.requests, subcomponent.limits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resourceSubcomponent are the requests and limits of a container of a component.
+type resourceSubcomponent struct {
+	name     string
+	requests *ResourceRequests
+	limits   *ResourceLimits
+}
+
+// subcomponents returns the subcomponents that have resources. The legacy couchdb field is left out if statedb is set,
+// as both configure the same container.
+func (resources *PeerResources) subcomponents() (subcomponents []resourceSubcomponent) {
+	if resources.Peer != nil {
+		subcomponents = append(subcomponents, resourceSubcomponent{"peer", resources.Peer.Requests, resources.Peer.Limits})
+	}
+	if resources.Proxy != nil {
+		subcomponents = append(subcomponents, resourceSubcomponent{"proxy", resources.Proxy.Requests, resources.Proxy.Limits})
+	}
+	if resources.Statedb != nil {
+		subcomponents = append(subcomponents, resourceSubcomponent{"statedb", resources.Statedb.Requests, resources.Statedb.Limits})
+	} else if resources.Couchdb != nil {
+		subcomponents = append(subcomponents, resourceSubcomponent{"couchdb", resources.Couchdb.Requests, resources.Couchdb.Limits})
+	}
+	if resources.Chaincodelauncher != nil {
+		subcomponents = append(subcomponents, resourceSubcomponent{"chaincodelauncher", resources.Chaincodelauncher.Requests, resources.Chaincodelauncher.Limits})
+	}
+	if resources.Dind != nil {
+		subcomponents = append(subcomponents, resourceSubcomponent{"dind", resources.Dind.Requests, resources.Dind.Limits})
+	}
+	if resources.Fluentd != nil {
+		subcomponents = append(subcomponents, resourceSubcomponent{"fluentd", resources.Fluentd.Requests, resources.Fluentd.Limits})
+	}
+	return
+}
+
+// subcomponents returns the subcomponents that have resources.
+func (resources *CreateOrdererRaftBodyResources) subcomponents() (subcomponents []resourceSubcomponent) {
+	if resources.Orderer != nil {
+		subcomponents = append(subcomponents, resourceSubcomponent{"orderer", resources.Orderer.Requests, resources.Orderer.Limits})
+	}
+	if resources.Proxy != nil {
+		subcomponents = append(subcomponents, resourceSubcomponent{"proxy", resources.Proxy.Requests, resources.Proxy.Limits})
+	}
+	return
+}
+
+// subcomponents returns the subcomponents that have resources.
+func (resources *CreateCaBodyResources) subcomponents() (subcomponents []resourceSubcomponent) {
+	if resources.Ca != nil {
+		subcomponents = append(subcomponents, resourceSubcomponent{"ca", resources.Ca.Requests, resources.Ca.Limits})
+	}
+	return
+}
+
+// PlannedNetwork : The options of the components of a network that is yet to be created.
+type PlannedNetwork struct {
+	Cas []*CreateCaOptions
+
+	Peers []*CreatePeerOptions
+
+	// Every node of the options of an orderer cluster is counted.
+	Orderers []*CreateOrdererOptions
+}
+
+// ResourceTotals : The CPU, memory and storage requested by components.
+type ResourceTotals struct {
+	Cpu CpuQuantity
+
+	Memory MemoryQuantity
+
+	Storage StorageQuantity
+
+	// The components (display names) of which neither a request nor a limit is set. The console requests its defaults
+	// for them, which are not part of the totals.
+	Defaulted []string
+}
+
+// RequestedResources returns the totals of the CPU, memory and storage requested by the components of the network,
+// after validating their resources. CAs request their CPU and memory once per replica, and their storage once, as
+// replicas share a database.
+func (network *PlannedNetwork) RequestedResources() (totals *ResourceTotals, err error) {
+	totals = &ResourceTotals{}
+	for _, ca := range network.Cas {
+		name := core.StringNilMapper(ca.DisplayName)
+		replicas := int64(1)
+		if ca.Replicas != nil && *ca.Replicas > 1 {
+			replicas = int64(*ca.Replicas)
+		}
+		var subcomponents []resourceSubcomponent
+		if ca.Resources != nil {
+			if err = ca.Resources.Validate(); err != nil {
+				return nil, fmt.Errorf("%s: %s", name, err.Error())
+			}
+			subcomponents = ca.Resources.subcomponents()
+		}
+		var storage []*StorageObject
+		if ca.Storage != nil {
+			storage = []*StorageObject{ca.Storage.Ca}
+		}
+		if err = totals.add(name, replicas, 1, subcomponents, storage); err != nil {
+			return nil, err
+		}
+	}
+	for _, peer := range network.Peers {
+		name := core.StringNilMapper(peer.DisplayName)
+		var subcomponents []resourceSubcomponent
+		if peer.Resources != nil {
+			if err = peer.Resources.Validate(); err != nil {
+				return nil, fmt.Errorf("%s: %s", name, err.Error())
+			}
+			subcomponents = peer.Resources.subcomponents()
+		}
+		var storage []*StorageObject
+		if peer.Storage != nil {
+			storage = []*StorageObject{peer.Storage.Peer, peer.Storage.Statedb}
+		}
+		if err = totals.add(name, 1, 1, subcomponents, storage); err != nil {
+			return nil, err
+		}
+	}
+	for _, orderer := range network.Orderers {
+		name := core.StringNilMapper(orderer.DisplayName)
+		var subcomponents []resourceSubcomponent
+		if orderer.Resources != nil {
+			if err = orderer.Resources.Validate(); err != nil {
+				return nil, fmt.Errorf("%s: %s", name, err.Error())
+			}
+			subcomponents = orderer.Resources.subcomponents()
+		}
+		var storage []*StorageObject
+		if orderer.Storage != nil {
+			storage = []*StorageObject{orderer.Storage.Orderer}
+		}
+		nodes := int64(len(orderer.Crypto))
+		if err = totals.add(name, nodes, nodes, subcomponents, storage); err != nil {
+			return nil, err
+		}
+	}
+	return totals, nil
+}
+
+// add adds the CPU and memory requests of a component to the totals count times, and its storage storageCount times.
+// Requests that are not set count as their limits. Components without CPU or memory requests or limits, or without
+// storage, are recorded as defaulted.
+func (totals *ResourceTotals) add(name string, count int64, storageCount int64, subcomponents []resourceSubcomponent, storage []*StorageObject) error {
+	defaulted := len(subcomponents) == 0 || len(storage) == 0
+	for _, subcomponent := range subcomponents {
+		requests := effectiveRequests(subcomponent.requests, subcomponent.limits)
+		if requests.Cpu == nil || requests.Memory == nil {
+			defaulted = true
+		}
+		// the requests and limits were validated
+		if requests.Cpu != nil {
+			cpu, _ := ParseCpuQuantity(*requests.Cpu)
+			totals.Cpu += cpu * CpuQuantity(count)
+		}
+		if requests.Memory != nil {
+			memory, _ := ParseMemoryQuantity(*requests.Memory)
+			totals.Memory += memory * MemoryQuantity(count)
+		}
+	}
+	for _, disk := range storage {
+		if disk == nil {
+			continue
+		}
+		if disk.Size == nil {
+			defaulted = true
+			continue
+		}
+		size, err := ParseStorageQuantity(*disk.Size)
+		if err != nil {
+			return fmt.Errorf("%s: storage size: %s", name, err.Error())
+		}
+		totals.Storage += size * StorageQuantity(storageCount)
+	}
+	if defaulted {
+		totals.Defaulted = append(totals.Defaulted, name)
+	}
+	return nil
+}
+
+// Fits checks that the totals fit in the capacity of a cluster. The error lists every resource the totals exceed.
+func (totals *ResourceTotals) Fits(capacity *ResourceTotals) error {
+	var exceeded []string
+	if totals.Cpu > capacity.Cpu {
+		exceeded = append(exceeded, fmt.Sprintf("cpu %s > %s", totals.Cpu, capacity.Cpu))
+	}
+	if totals.Memory > capacity.Memory {
+		exceeded = append(exceeded, fmt.Sprintf("memory %s > %s", totals.Memory, capacity.Memory))
+	}
+	if totals.Storage > capacity.Storage {
+		exceeded = append(exceeded, fmt.Sprintf("storage %s > %s", totals.Storage, capacity.Storage))
+	}
+	if len(exceeded) > 0 {
+		return fmt.Errorf("the requested resources exceed the capacity: %s", strings.Join(exceeded, ", "))
+	}
+	return nil
+}
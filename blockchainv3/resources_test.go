@@ -0,0 +1,158 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// testResourceObject returns the resources of a subcomponent, without limits if limits are empty.
+func testResourceObject(cpu string, memory string, limitCpu string, limitMemory string) *blockchainv3.ResourceObject {
+	resources := &blockchainv3.ResourceObject{
+		Requests: &blockchainv3.ResourceRequests{Cpu: core.StringPtr(cpu), Memory: core.StringPtr(memory)},
+	}
+	if limitCpu != "" || limitMemory != "" {
+		resources.Limits = &blockchainv3.ResourceLimits{}
+		if limitCpu != "" {
+			resources.Limits.Cpu = core.StringPtr(limitCpu)
+		}
+		if limitMemory != "" {
+			resources.Limits.Memory = core.StringPtr(limitMemory)
+		}
+	}
+	return resources
+}
+
+var _ = Describe(`BlockchainV3 resource quantities`, func() {
+	It(`Parses CPU quantities`, func() {
+		for quantity, millicores := range map[string]int64{
+			"100m": 100, "0.5": 500, "2": 2000, "1.5k": 1500000, "250000u": 250, "1n": 1, "2e3": 2000000, ".25": 250,
+		} {
+			cpu, err := blockchainv3.ParseCpuQuantity(quantity)
+			Expect(err).To(BeNil(), quantity)
+			Expect(int64(cpu)).To(Equal(millicores), quantity)
+		}
+		cpu, _ := blockchainv3.ParseCpuQuantity("1500m")
+		Expect(cpu.String()).To(Equal("1500m"))
+		Expect(cpu.Cores()).To(Equal(1.5))
+		Expect((cpu + 500).String()).To(Equal("2"))
+		for _, invalid := range []string{"", "abc", "-1", "1 cpu", "1Xi", "1.2.3"} {
+			_, err := blockchainv3.ParseCpuQuantity(invalid)
+			Expect(err).ToNot(BeNil(), invalid)
+		}
+	})
+	It(`Parses memory and storage quantities`, func() {
+		for quantity, bytes := range map[string]int64{
+			"512Mi": 512 << 20, "2Gi": 2 << 30, "1G": 1000000000, "100": 100, "1.5Ki": 1536, "1e3": 1000, "1Ei": 1 << 60,
+		} {
+			memory, err := blockchainv3.ParseMemoryQuantity(quantity)
+			Expect(err).To(BeNil(), quantity)
+			Expect(int64(memory)).To(Equal(bytes), quantity)
+		}
+		memory, _ := blockchainv3.ParseMemoryQuantity("1.5Gi")
+		Expect(memory.String()).To(Equal("1536Mi"))
+		Expect((memory * 2).String()).To(Equal("3Gi"))
+		storage, err := blockchainv3.ParseStorageQuantity("100Gi")
+		Expect(err).To(BeNil())
+		Expect((storage - 36<<30).String()).To(Equal("64Gi"))
+		_, err = blockchainv3.ParseStorageQuantity("16Ei")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal(`"16Ei" is too large`))
+	})
+	It(`Checks that requests do not exceed limits`, func() {
+		peer := &blockchainv3.PeerResources{
+			Peer:    testResourceObject("200m", "1Gi", "1", "2Gi"),
+			Statedb: testResourceObject("100m", "512Mi", "", ""),
+		}
+		Expect(peer.Validate()).To(BeNil())
+		peer.Proxy = testResourceObject("200m", "1Gi", "100m", "")
+		err := peer.Validate()
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("proxy: the cpu request 200m exceeds the cpu limit 100m"))
+		peer.Proxy = nil
+		peer.Couchdb = &blockchainv3.ResourceObjectCouchDb{Requests: &blockchainv3.ResourceRequests{Memory: core.StringPtr("lots")}}
+		err = peer.Validate()
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal(`couchdb: requests.memory: "lots" is not a quantity`))
+
+		orderer := &blockchainv3.CreateOrdererRaftBodyResources{Orderer: testResourceObject("1", "2G", "1", "2Gi")}
+		Expect(orderer.Validate()).To(BeNil())
+		orderer.Orderer = testResourceObject("1", "2Gi", "", "2G")
+		err = orderer.Validate()
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("orderer: the memory request 2Gi exceeds the memory limit 2G"))
+
+		// requests default to the limits
+		ca := &blockchainv3.CreateCaBodyResources{Ca: &blockchainv3.ResourceObject{Limits: &blockchainv3.ResourceLimits{Cpu: core.StringPtr("1")}}}
+		Expect(ca.Validate()).To(BeNil())
+		ca.Ca.Limits.Memory = core.StringPtr("lots")
+		err = ca.Validate()
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal(`ca: limits.memory: "lots" is not a quantity`))
+	})
+	It(`Totals the requested resources of a planned network`, func() {
+		service, err := blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{URL: "http://localhost", Authenticator: &core.NoAuthAuthenticator{}})
+		Expect(err).To(BeNil())
+		ca := service.NewCreateCaOptions("ca", nil).SetReplicas(2).
+			SetResources(&blockchainv3.CreateCaBodyResources{Ca: testResourceObject("100m", "128Mi", "", "")}).
+			SetStorage(&blockchainv3.CreateCaBodyStorage{Ca: &blockchainv3.StorageObject{Size: core.StringPtr("1Gi")}})
+		peer := service.NewCreatePeerOptions("org1", "peer", &blockchainv3.CryptoObject{}).
+			SetResources(&blockchainv3.PeerResources{
+				Peer:  testResourceObject("200m", "1Gi", "", ""),
+				Proxy: testResourceObject("100m", "128Mi", "", ""),
+			}).
+			SetStorage(&blockchainv3.CreatePeerBodyStorage{
+				Peer:    &blockchainv3.StorageObject{Size: core.StringPtr("50Gi")},
+				Statedb: &blockchainv3.StorageObject{Size: core.StringPtr("10Gi")},
+			})
+		orderer := service.NewCreateOrdererOptions("raft", "ordererorg", "orderer", []blockchainv3.CryptoObject{{}, {}, {}}).
+			SetResources(&blockchainv3.CreateOrdererRaftBodyResources{Orderer: testResourceObject("250m", "512Mi", "", "")})
+		network := &blockchainv3.PlannedNetwork{
+			Cas:      []*blockchainv3.CreateCaOptions{ca},
+			Peers:    []*blockchainv3.CreatePeerOptions{peer},
+			Orderers: []*blockchainv3.CreateOrdererOptions{orderer},
+		}
+		totals, err := network.RequestedResources()
+		Expect(err).To(BeNil())
+		Expect(totals.Cpu.String()).To(Equal("1250m"))
+		Expect(totals.Memory.String()).To(Equal("2944Mi"))
+		Expect(totals.Storage.String()).To(Equal("61Gi"))
+		// the orderers have no storage settings
+		Expect(totals.Defaulted).To(Equal([]string{"orderer"}))
+
+		Expect(totals.Fits(&blockchainv3.ResourceTotals{Cpu: 2000, Memory: 4 << 30, Storage: 100 << 30})).To(BeNil())
+		err = totals.Fits(&blockchainv3.ResourceTotals{Cpu: 1000, Memory: 4 << 30, Storage: 50 << 30})
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("the requested resources exceed the capacity: cpu 1250m > 1, storage 61Gi > 50Gi"))
+
+		peer.Resources.Peer.Limits = &blockchainv3.ResourceLimits{Memory: core.StringPtr("512Mi")}
+		_, err = network.RequestedResources()
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("peer: peer: the memory request 1Gi exceeds the memory limit 512Mi"))
+
+		// requests that are not set count as their limits
+		peer.Resources.Peer = &blockchainv3.ResourceObject{Limits: &blockchainv3.ResourceLimits{Cpu: core.StringPtr("1"), Memory: core.StringPtr("2Gi")}}
+		totals, err = network.RequestedResources()
+		Expect(err).To(BeNil())
+		Expect(totals.Cpu.String()).To(Equal("2050m"))
+		Expect(totals.Memory.String()).To(Equal("3968Mi"))
+		Expect(totals.Defaulted).To(Equal([]string{"orderer"}))
+	})
+})
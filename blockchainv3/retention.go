@@ -0,0 +1,256 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"context"
+	"fmt"
+	"github.com/IBM/go-sdk-core/v4/core"
+	"strings"
+	"time"
+)
+
+// Constants associated with the NotificationRetentionPolicy.Action property. The API has no operation that deletes
+// selected notifications, only DeleteAllNotifications, so they can only be archived.
+const (
+	NotificationRetentionPolicy_Action_Archive = "archive"
+)
+
+// DefaultNotificationRetentionBatchSize is the default number of notifications listed per page and archived per
+// request.
+const DefaultNotificationRetentionBatchSize = 100
+
+// NotificationRetentionPolicy : Which notifications to archive. A notification is selected if it matches
+// every criterion that is set; a policy without criteria selects every notification.
+type NotificationRetentionPolicy struct {
+	// What to do with the selected notifications, one of NotificationRetentionPolicy_Action_*.
+	Action *string `validate:"required"`
+
+	// Select notifications created longer than this ago, by their `ts_display`.
+	OlderThan *time.Duration
+
+	// Select notifications of these types, e.g. "notification", "webhook_tx" or "other".
+	Types []string
+
+	// Select notifications with these statuses, e.g. "pending", "error" or "success".
+	Statuses []string
+
+	// Select notifications of this component only. Notifications are filtered by component by the console.
+	ComponentID *string
+
+	// The number of notifications listed per page and archived per request. Defaults to
+	// DefaultNotificationRetentionBatchSize.
+	BatchSize *int64
+
+	// Select the notifications and render the requests that would archive them, without sending them.
+	DryRun *bool
+}
+
+// NewNotificationRetentionPolicy : Instantiate NotificationRetentionPolicy
+func (*BlockchainV3) NewNotificationRetentionPolicy(action string) *NotificationRetentionPolicy {
+	return &NotificationRetentionPolicy{
+		Action: core.StringPtr(action),
+	}
+}
+
+// SetAction : Allow user to set Action
+func (policy *NotificationRetentionPolicy) SetAction(action string) *NotificationRetentionPolicy {
+	policy.Action = core.StringPtr(action)
+	return policy
+}
+
+// SetOlderThan : Allow user to set OlderThan
+func (policy *NotificationRetentionPolicy) SetOlderThan(olderThan time.Duration) *NotificationRetentionPolicy {
+	policy.OlderThan = &olderThan
+	return policy
+}
+
+// SetTypes : Allow user to set Types
+func (policy *NotificationRetentionPolicy) SetTypes(types []string) *NotificationRetentionPolicy {
+	policy.Types = types
+	return policy
+}
+
+// SetStatuses : Allow user to set Statuses
+func (policy *NotificationRetentionPolicy) SetStatuses(statuses []string) *NotificationRetentionPolicy {
+	policy.Statuses = statuses
+	return policy
+}
+
+// SetComponentID : Allow user to set ComponentID
+func (policy *NotificationRetentionPolicy) SetComponentID(componentID string) *NotificationRetentionPolicy {
+	policy.ComponentID = core.StringPtr(componentID)
+	return policy
+}
+
+// SetBatchSize : Allow user to set BatchSize
+func (policy *NotificationRetentionPolicy) SetBatchSize(batchSize int64) *NotificationRetentionPolicy {
+	policy.BatchSize = &batchSize
+	return policy
+}
+
+// SetDryRun : Allow user to set DryRun
+func (policy *NotificationRetentionPolicy) SetDryRun(dryRun bool) *NotificationRetentionPolicy {
+	policy.DryRun = core.BoolPtr(dryRun)
+	return policy
+}
+
+// selects reports whether the policy selects a notification, given the time the selection started.
+func (policy *NotificationRetentionPolicy) selects(notification *NotificationData, now time.Time) bool {
+	if notification.ID == nil {
+		return false
+	}
+	if policy.OlderThan != nil {
+		if notification.TsDisplay == nil {
+			return false
+		}
+		created := time.Unix(0, int64(*notification.TsDisplay)*int64(time.Millisecond))
+		if now.Sub(created) < *policy.OlderThan {
+			return false
+		}
+	}
+	if len(policy.Types) > 0 && !containsString(policy.Types, core.StringNilMapper(notification.Type)) {
+		return false
+	}
+	if len(policy.Statuses) > 0 && !containsString(policy.Statuses, core.StringNilMapper(notification.Status)) {
+		return false
+	}
+	return true
+}
+
+// NotificationRetentionResult : The outcome of ApplyNotificationRetention.
+type NotificationRetentionResult struct {
+	// The action of the policy.
+	Action string
+
+	// Whether the requests were only rendered.
+	DryRun bool
+
+	// The number of notifications listed.
+	Examined int
+
+	// The notifications the policy selected, in the order of the console.
+	Selected []NotificationData
+
+	// The ids of the notifications that were archived. Empty in dry-run mode.
+	Processed []string
+
+	// In dry-run mode, the request of every batch.
+	Requests []*DryRunResult
+}
+
+// Report returns a line per selected notification, preceded by a summary line.
+func (result *NotificationRetentionResult) Report() string {
+	var report strings.Builder
+	// the actions are verbs
+	if result.DryRun {
+		fmt.Fprintf(&report, "would %s %d of %d notifications in %d requests\n", result.Action, len(result.Selected), result.Examined, len(result.Requests))
+	} else {
+		fmt.Fprintf(&report, "%sd %d of %d selected notifications (%d examined)\n", result.Action, len(result.Processed), len(result.Selected), result.Examined)
+	}
+	for _, notification := range result.Selected {
+		created := "unknown time"
+		if notification.TsDisplay != nil {
+			created = time.Unix(0, int64(*notification.TsDisplay)*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(&report, "%s\t%s\t%s\t%s\t%s\n", core.StringNilMapper(notification.ID), created,
+			core.StringNilMapper(notification.Type), core.StringNilMapper(notification.Status), core.StringNilMapper(notification.Message))
+	}
+	return report.String()
+}
+
+// ApplyNotificationRetention archives the notifications a policy selects. The notifications are listed page by page
+// with ListNotifications, and every notification is selected before any is archived, so that the pages do not shift
+// while they are listed. The selected notifications are then archived with ArchiveNotifications in batches.
+//
+// In dry-run mode the batches are rendered by a clone of the client, which is left unchanged. If a batch fails, the
+// result holds the notifications processed by the previous batches.
+func (blockchain *BlockchainV3) ApplyNotificationRetention(ctx context.Context, policy *NotificationRetentionPolicy) (result *NotificationRetentionResult, err error) {
+	err = core.ValidateStruct(policy, "policy")
+	if err != nil {
+		return
+	}
+	action := *policy.Action
+	if action == "delete" {
+		return nil, fmt.Errorf("selected notifications cannot be deleted, the API only deletes all of them; archive them instead")
+	}
+	if action != NotificationRetentionPolicy_Action_Archive {
+		return nil, fmt.Errorf("unknown retention action %q", action)
+	}
+	batchSize := int64(DefaultNotificationRetentionBatchSize)
+	if policy.BatchSize != nil {
+		if *policy.BatchSize < 1 {
+			return nil, fmt.Errorf("the batch size must be at least 1")
+		}
+		batchSize = *policy.BatchSize
+	}
+	result = &NotificationRetentionResult{Action: action, DryRun: policy.DryRun != nil && *policy.DryRun}
+
+	now := time.Now()
+	for skip := int64(0); ; {
+		options := blockchain.NewListNotificationsOptions().SetLimit(float64(batchSize)).SetSkip(float64(skip))
+		if policy.ComponentID != nil {
+			options.SetComponentID(*policy.ComponentID)
+		}
+		page, _, err := blockchain.ListNotificationsWithContext(ctx, options)
+		if err != nil {
+			return nil, fmt.Errorf("listing notifications failed: %s", err.Error())
+		}
+		for i := range page.Notifications {
+			if policy.selects(&page.Notifications[i], now) {
+				result.Selected = append(result.Selected, page.Notifications[i])
+			}
+		}
+		result.Examined += len(page.Notifications)
+		skip += int64(len(page.Notifications))
+		if int64(len(page.Notifications)) < batchSize || (page.Total != nil && float64(skip) >= *page.Total) {
+			break
+		}
+	}
+
+	// the dry-run layer is installed on a clone, so that the client of the caller is not changed
+	client := blockchain
+	if result.DryRun {
+		client = blockchain.Clone()
+		ctx = client.DryRunContext(ctx)
+	}
+	batches := (int64(len(result.Selected)) + batchSize - 1) / batchSize
+	for batch := int64(0); batch < batches; batch++ {
+		var ids []string
+		for _, notification := range result.Selected[batch*batchSize : minInt64((batch+1)*batchSize, int64(len(result.Selected)))] {
+			ids = append(ids, *notification.ID)
+		}
+		_, _, err = client.ArchiveNotificationsWithContext(ctx, client.NewArchiveNotificationsOptions(ids))
+		if request, ok := GetDryRunResult(err); ok {
+			result.Requests = append(result.Requests, request)
+			continue
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to %s batch %d of %d: %s", action, batch+1, batches, err.Error())
+		}
+		result.Processed = append(result.Processed, ids...)
+	}
+	return result, nil
+}
+
+// minInt64 returns the smaller of two integers.
+func minInt64(a int64, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
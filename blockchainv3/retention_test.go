@@ -0,0 +1,177 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ = Describe(`BlockchainV3 notification retention`, func() {
+	var testServer *httptest.Server
+	var blockchainService *blockchainv3.BlockchainV3
+	var mutex sync.Mutex
+	var notifications []map[string]interface{}
+	var archived [][]string
+	var listed []string
+	var failBatch int
+
+	BeforeEach(func() {
+		now := time.Now()
+		notifications = nil
+		archived, listed = nil, nil
+		failBatch = 0
+		day := 24 * time.Hour
+		for i, n := range []struct {
+			age          time.Duration
+			kind, status string
+			component    string
+		}{
+			{40 * day, "notification", "success", "peer1"},
+			{40 * day, "webhook_tx", "error", "peer1"},
+			{35 * day, "notification", "pending", "ca1"},
+			{31 * day, "notification", "success", "ca1"},
+			{10 * day, "notification", "success", "peer1"},
+			{1 * day, "other", "error", "peer1"},
+			{1 * time.Hour, "notification", "success", "ca1"},
+		} {
+			notifications = append(notifications, map[string]interface{}{
+				"id":           fmt.Sprintf("n%d", i+1),
+				"type":         n.kind,
+				"status":       n.status,
+				"message":      "message " + strconv.Itoa(i+1),
+				"ts_display":   now.Add(-n.age).UnixNano() / int64(time.Millisecond),
+				"component_id": n.component,
+			})
+		}
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			mutex.Lock()
+			defer mutex.Unlock()
+			res.Header().Set("Content-type", "application/json")
+			op, ok := blockchainv3.LookupOperation(req.Method, req.URL.Path)
+			switch {
+			case ok && op.Name == "ListNotifications":
+				limit, _ := strconv.Atoi(req.URL.Query().Get("limit"))
+				skip, _ := strconv.Atoi(req.URL.Query().Get("skip"))
+				listed = append(listed, fmt.Sprintf("%d+%d", skip, limit))
+				var matching []map[string]interface{}
+				for _, notification := range notifications {
+					if component := req.URL.Query().Get("component_id"); component == "" || notification["component_id"] == component {
+						matching = append(matching, notification)
+					}
+				}
+				page := []map[string]interface{}{}
+				for i := skip; i < skip+limit && i < len(matching); i++ {
+					page = append(page, matching[i])
+				}
+				json.NewEncoder(res).Encode(map[string]interface{}{"total": len(matching), "returning": len(page), "notifications": page})
+			case ok && op.Name == "ArchiveNotifications":
+				var body struct {
+					NotificationIds []string `json:"notification_ids"`
+				}
+				Expect(json.NewDecoder(req.Body).Decode(&body)).To(BeNil())
+				archived = append(archived, body.NotificationIds)
+				if len(archived) == failBatch {
+					res.WriteHeader(500)
+					fmt.Fprintf(res, `{"message": "database unavailable"}`)
+					return
+				}
+				fmt.Fprintf(res, `{"message": "ok", "details": "%d notifications"}`, len(body.NotificationIds))
+			default:
+				Fail("unexpected request " + req.Method + " " + req.URL.Path)
+			}
+		}))
+		var err error
+		blockchainService, err = blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Archives old notifications in batches`, func() {
+		policy := blockchainService.NewNotificationRetentionPolicy(blockchainv3.NotificationRetentionPolicy_Action_Archive).
+			SetOlderThan(30 * 24 * time.Hour).SetBatchSize(3)
+		result, err := blockchainService.ApplyNotificationRetention(context.Background(), policy)
+		Expect(err).To(BeNil())
+		Expect(listed).To(Equal([]string{"0+3", "3+3", "6+3"}))
+		Expect(result.Examined).To(Equal(7))
+		Expect(archived).To(Equal([][]string{{"n1", "n2", "n3"}, {"n4"}}))
+		Expect(result.Processed).To(Equal([]string{"n1", "n2", "n3", "n4"}))
+		Expect(result.Report()).To(HavePrefix("archived 4 of 4 selected notifications (7 examined)\nn1\t"))
+	})
+	It(`Selects by type, status and component`, func() {
+		policy := blockchainService.NewNotificationRetentionPolicy(blockchainv3.NotificationRetentionPolicy_Action_Archive).
+			SetStatuses([]string{"success", "error"}).SetTypes([]string{"notification", "other"}).SetComponentID("peer1")
+		result, err := blockchainService.ApplyNotificationRetention(context.Background(), policy)
+		Expect(err).To(BeNil())
+		Expect(result.Examined).To(Equal(4))
+		Expect(archived).To(Equal([][]string{{"n1", "n5", "n6"}}))
+	})
+	It(`Renders the requests in dry-run mode`, func() {
+		policy := blockchainService.NewNotificationRetentionPolicy(blockchainv3.NotificationRetentionPolicy_Action_Archive).
+			SetOlderThan(30 * 24 * time.Hour).SetBatchSize(2).SetDryRun(true)
+		result, err := blockchainService.ApplyNotificationRetention(context.Background(), policy)
+		Expect(err).To(BeNil())
+		Expect(archived).To(BeNil())
+		Expect(result.Processed).To(BeEmpty())
+		Expect(result.Requests).To(HaveLen(2))
+		Expect(result.Requests[0].Operation).To(Equal("ArchiveNotifications"))
+		Expect(string(result.Requests[1].Body)).To(MatchJSON(`{"notification_ids": ["n3", "n4"]}`))
+		report := result.Report()
+		Expect(report).To(HavePrefix("would archive 4 of 7 notifications in 2 requests\n"))
+		Expect(strings.Count(report, "\n")).To(Equal(5))
+
+		// the client of the caller is not changed
+		Expect(blockchainService.IsDryRun()).To(BeFalse())
+		Expect(blockchainService.Service.Options.Authenticator).To(Equal(&core.NoAuthAuthenticator{}))
+		_, _, err = blockchainService.ArchiveNotificationsWithContext(context.Background(), blockchainService.NewArchiveNotificationsOptions([]string{"n1"}))
+		Expect(err).To(BeNil())
+		Expect(archived).To(Equal([][]string{{"n1"}}))
+	})
+	It(`Stops at the first failed batch`, func() {
+		failBatch = 2
+		policy := blockchainService.NewNotificationRetentionPolicy(blockchainv3.NotificationRetentionPolicy_Action_Archive).SetBatchSize(2)
+		result, err := blockchainService.ApplyNotificationRetention(context.Background(), policy)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("failed to archive batch 2 of 4: database unavailable"))
+		Expect(result.Processed).To(Equal([]string{"n1", "n2"}))
+		Expect(archived).To(HaveLen(2))
+
+		_, err = blockchainService.ApplyNotificationRetention(context.Background(), policy.SetAction("purge"))
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal(`unknown retention action "purge"`))
+		_, err = blockchainService.ApplyNotificationRetention(context.Background(), policy.SetAction("delete"))
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("selected notifications cannot be deleted, the API only deletes all of them; archive them instead"))
+	})
+})
@@ -0,0 +1,281 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+)
+
+// A settings file holds the body of EditSettings as indented JSON, with the keys sorted so that it diffs well, e.g.
+//
+//	{
+//	  "fabric_general_timeout_ms": 10000,
+//	  "file_logging": {
+//	    "server": {
+//	      "level": "info"
+//	    }
+//	  },
+//	  "max_req_per_min": 25
+//	}
+//
+// Settings that the file does not hold are left as they are by ApplySettings.
+
+// ParseSettingsFile : Parse a settings file. Unknown keys are rejected, so that a misspelled setting is not silently
+// ignored.
+func ParseSettingsFile(data []byte) (options *EditSettingsOptions, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	options = &EditSettingsOptions{}
+	err = decoder.Decode(options)
+	if err != nil {
+		return nil, fmt.Errorf("invalid settings file: %s", err.Error())
+	}
+	if options.Headers != nil {
+		return nil, fmt.Errorf("invalid settings file: unknown field \"Headers\"")
+	}
+	return
+}
+
+// ReadSettingsFile : Read a settings file
+func ReadSettingsFile(file string) (*EditSettingsOptions, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSettingsFile(data)
+}
+
+// MarshalSettingsFile : Render the settings of the options as a settings file. Headers are not saved.
+func MarshalSettingsFile(options *EditSettingsOptions) ([]byte, error) {
+	values, err := settingsTree(options)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// WriteSettingsFile : Write the settings of the options to a settings file
+func WriteSettingsFile(file string, options *EditSettingsOptions) error {
+	data, err := MarshalSettingsFile(options)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+// EditableSettings returns the settings of a console that EditSettings can change, in the form of its options.
+// Settings the console does not report are left unset.
+func EditableSettings(settings *GetPublicSettingsResponse) *EditSettingsOptions {
+	options := &EditSettingsOptions{
+		MaxReqPerMin:   settings.MAXREQPERMIN,
+		MaxReqPerMinAk: settings.MAXREQPERMINAK,
+	}
+	if settings.INACTIVITYTIMEOUTS != nil {
+		options.InactivityTimeouts = &EditSettingsBodyInactivityTimeouts{
+			Enabled:     settings.INACTIVITYTIMEOUTS.Enabled,
+			MaxIdleTime: settings.INACTIVITYTIMEOUTS.MaxIdleTime,
+		}
+	}
+	if logging := settings.FILELOGGING; logging != nil {
+		options.FileLogging = &EditLogSettingsBody{}
+		if logging.Client != nil {
+			options.FileLogging.Client = logging.Client.Client
+		}
+		if logging.Server != nil {
+			options.FileLogging.Server = logging.Server.Server
+		}
+	}
+	// the timeouts are reported under the names of the EditSettings body
+	if timeouts, ok := settings.TIMEOUTS.(map[string]interface{}); ok {
+		for name, field := range map[string]**float64{
+			"fabric_get_block_timeout_ms":     &options.FabricGetBlockTimeoutMs,
+			"fabric_instantiate_timeout_ms":   &options.FabricInstantiateTimeoutMs,
+			"fabric_join_channel_timeout_ms":  &options.FabricJoinChannelTimeoutMs,
+			"fabric_install_cc_timeout_ms":    &options.FabricInstallCcTimeoutMs,
+			"fabric_lc_install_cc_timeout_ms": &options.FabricLcInstallCcTimeoutMs,
+			"fabric_lc_get_cc_timeout_ms":     &options.FabricLcGetCcTimeoutMs,
+			"fabric_general_timeout_ms":       &options.FabricGeneralTimeoutMs,
+		} {
+			if value, ok := timeouts[name].(float64); ok {
+				*field = &value
+			}
+		}
+	}
+	return options
+}
+
+// SettingsChange : A setting whose value differs from the desired one. Key is the path of the setting in the
+// EditSettings body, e.g. "file_logging.server.level". Current is nil if the console does not report the setting.
+type SettingsChange struct {
+	Key string
+
+	Current interface{}
+
+	Desired interface{}
+}
+
+// String renders the change as `<key>: <current> -> <desired>`.
+func (change SettingsChange) String() string {
+	current := "unset"
+	if change.Current != nil {
+		current = fmt.Sprintf("%v", change.Current)
+	}
+	return fmt.Sprintf("%s: %s -> %v", change.Key, current, change.Desired)
+}
+
+// DiffSettings returns the settings of desired that differ from the settings of a console, sorted by key. Settings
+// that desired does not set are not compared.
+func DiffSettings(current *GetPublicSettingsResponse, desired *EditSettingsOptions) (changes []SettingsChange, err error) {
+	currentValues, err := settingsValues(EditableSettings(current))
+	if err != nil {
+		return
+	}
+	desiredValues, err := settingsValues(desired)
+	if err != nil {
+		return
+	}
+	for _, key := range sortedKeys(desiredValues) {
+		if !reflect.DeepEqual(currentValues[key], desiredValues[key]) {
+			changes = append(changes, SettingsChange{Key: key, Current: currentValues[key], Desired: desiredValues[key]})
+		}
+	}
+	return
+}
+
+// SettingsApplyResult : The outcome of ApplySettings.
+type SettingsApplyResult struct {
+	// The settings that were changed. Empty if the console already had the desired settings.
+	Changes []SettingsChange
+
+	// The settings of the console, read back after the changes.
+	Settings *GetPublicSettingsResponse
+}
+
+// ApplySettings changes the settings of the console to the settings of desired. Only the settings that differ are
+// sent to EditSettings, so that unchanged logging or rate limit settings do not restart the console. The settings are
+// then read back with GetSettings, and ApplySettings fails if any of them did not take effect.
+func (blockchain *BlockchainV3) ApplySettings(ctx context.Context, desired *EditSettingsOptions) (result *SettingsApplyResult, err error) {
+	if desired == nil {
+		return nil, fmt.Errorf("desired cannot be nil")
+	}
+	current, _, err := blockchain.GetSettingsWithContext(ctx, blockchain.NewGetSettingsOptions().SetHeaders(desired.Headers))
+	if err != nil {
+		return nil, fmt.Errorf("reading the settings failed: %s", err.Error())
+	}
+	result = &SettingsApplyResult{Settings: current}
+	result.Changes, err = DiffSettings(current, desired)
+	if err != nil || len(result.Changes) == 0 {
+		return
+	}
+
+	changed := map[string]interface{}{}
+	for _, change := range result.Changes {
+		changed[change.Key] = change.Desired
+	}
+	options, err := settingsOptions(changed)
+	if err != nil {
+		return
+	}
+	_, _, err = blockchain.EditSettingsWithContext(ctx, options.SetHeaders(desired.Headers))
+	if err != nil {
+		return result, fmt.Errorf("changing the settings failed: %s", err.Error())
+	}
+
+	result.Settings, _, err = blockchain.GetSettingsWithContext(ctx, blockchain.NewGetSettingsOptions().SetHeaders(desired.Headers))
+	if err != nil {
+		return result, fmt.Errorf("reading back the settings failed: %s", err.Error())
+	}
+	unconfirmed, err := DiffSettings(result.Settings, options)
+	if err != nil {
+		return
+	}
+	if len(unconfirmed) > 0 {
+		var changes []string
+		for _, change := range unconfirmed {
+			changes = append(changes, change.String())
+		}
+		return result, fmt.Errorf("%d settings did not take effect: %s", len(unconfirmed), strings.Join(changes, ", "))
+	}
+	return
+}
+
+// settingsTree returns the settings of the options as nested JSON values, without the headers.
+func settingsTree(options *EditSettingsOptions) (tree map[string]interface{}, err error) {
+	data, err := json.Marshal(options)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &tree)
+	delete(tree, "Headers")
+	return
+}
+
+// settingsValues returns the settings of the options keyed by their dotted path.
+func settingsValues(options *EditSettingsOptions) (values map[string]interface{}, err error) {
+	tree, err := settingsTree(options)
+	if err != nil {
+		return
+	}
+	values = map[string]interface{}{}
+	var flatten func(prefix string, node map[string]interface{})
+	flatten = func(prefix string, node map[string]interface{}) {
+		for key, value := range node {
+			if child, ok := value.(map[string]interface{}); ok {
+				flatten(prefix+key+".", child)
+			} else {
+				values[prefix+key] = value
+			}
+		}
+	}
+	flatten("", tree)
+	return
+}
+
+// settingsOptions returns options that set the settings keyed by their dotted path, the inverse of settingsValues.
+func settingsOptions(values map[string]interface{}) (options *EditSettingsOptions, err error) {
+	tree := map[string]interface{}{}
+	for key, value := range values {
+		node := tree
+		path := strings.Split(key, ".")
+		for _, name := range path[:len(path)-1] {
+			child, ok := node[name].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				node[name] = child
+			}
+			node = child
+		}
+		node[path[len(path)-1]] = value
+	}
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return
+	}
+	options = &EditSettingsOptions{}
+	err = json.Unmarshal(data, options)
+	return
+}
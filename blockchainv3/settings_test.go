@@ -0,0 +1,169 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe(`BlockchainV3 settings as code`, func() {
+	var testServer *httptest.Server
+	var blockchainService *blockchainv3.BlockchainV3
+	var settings map[string]interface{}
+	var edits []map[string]interface{}
+	var ignoreEdits bool
+
+	BeforeEach(func() {
+		edits, ignoreEdits = nil, false
+		Expect(json.Unmarshal([]byte(`{
+			"ATHENA_ID": "17v7e",
+			"INACTIVITY_TIMEOUTS": {"enabled": false, "max_idle_time": 90000},
+			"FILE_LOGGING": {
+				"server": {"server": {"enabled": true, "level": "info", "unique_name": false}},
+				"client": {"client": {"enabled": true, "level": "warn", "unique_name": false}}
+			},
+			"MAX_REQ_PER_MIN": 25,
+			"MAX_REQ_PER_MIN_AK": 25,
+			"TIMEOUTS": {"fabric_get_block_timeout_ms": 10000, "fabric_general_timeout_ms": 10000}
+		}`), &settings)).To(BeNil())
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			Expect(req.URL.Path).To(Equal("/ak/api/v3/settings"))
+			if req.Method == http.MethodPut {
+				var body map[string]interface{}
+				Expect(json.NewDecoder(req.Body).Decode(&body)).To(BeNil())
+				edits = append(edits, body)
+				if !ignoreEdits {
+					for key, value := range body {
+						switch key {
+						case "max_req_per_min":
+							settings["MAX_REQ_PER_MIN"] = value
+						case "file_logging":
+							for side, values := range value.(map[string]interface{}) {
+								current := settings["FILE_LOGGING"].(map[string]interface{})[side].(map[string]interface{})[side].(map[string]interface{})
+								for name, value := range values.(map[string]interface{}) {
+									current[name] = value
+								}
+							}
+						case "fabric_general_timeout_ms":
+							settings["TIMEOUTS"].(map[string]interface{})[key] = value
+						}
+					}
+				}
+			}
+			res.Header().Set("Content-type", "application/json")
+			Expect(json.NewEncoder(res).Encode(settings)).To(BeNil())
+		}))
+		var err error
+		blockchainService, err = blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Saves and loads settings files`, func() {
+		current, _, err := blockchainService.GetSettings(blockchainService.NewGetSettingsOptions())
+		Expect(err).To(BeNil())
+		editable := blockchainv3.EditableSettings(current)
+		Expect(*editable.FileLogging.Client.Level).To(Equal("warn"))
+		Expect(*editable.FabricGetBlockTimeoutMs).To(Equal(10000.0))
+		Expect(editable.FabricJoinChannelTimeoutMs).To(BeNil())
+
+		dir, err := ioutil.TempDir("", "settings")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+		file := filepath.Join(dir, "settings.json")
+		Expect(blockchainv3.WriteSettingsFile(file, editable.SetHeaders(map[string]string{"X-Test": "1"}))).To(BeNil())
+		data, err := ioutil.ReadFile(file)
+		Expect(err).To(BeNil())
+		Expect(string(data)).To(HavePrefix("{\n  \"fabric_general_timeout_ms\": 10000,\n  \"fabric_get_block_timeout_ms\": 10000,\n  \"file_logging\": {\n"))
+		Expect(string(data)).ToNot(ContainSubstring("X-Test"))
+
+		loaded, err := blockchainv3.ReadSettingsFile(file)
+		Expect(err).To(BeNil())
+		Expect(loaded.Headers).To(BeNil())
+		Expect(loaded.SetHeaders(editable.Headers)).To(Equal(editable))
+
+		_, err = blockchainv3.ParseSettingsFile([]byte(`{"max_req_per_minute": 50}`))
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal(`invalid settings file: json: unknown field "max_req_per_minute"`))
+	})
+	It(`Diffs and applies only the changed settings`, func() {
+		desired, err := blockchainv3.ParseSettingsFile([]byte(`{
+			"inactivity_timeouts": {"enabled": false},
+			"file_logging": {"server": {"enabled": true, "level": "debug"}},
+			"max_req_per_min": 50,
+			"max_req_per_min_ak": 25,
+			"fabric_general_timeout_ms": 20000
+		}`))
+		Expect(err).To(BeNil())
+		current, _, err := blockchainService.GetSettings(blockchainService.NewGetSettingsOptions())
+		Expect(err).To(BeNil())
+		changes, err := blockchainv3.DiffSettings(current, desired)
+		Expect(err).To(BeNil())
+		var rendered []string
+		for _, change := range changes {
+			rendered = append(rendered, change.String())
+		}
+		Expect(rendered).To(Equal([]string{
+			"fabric_general_timeout_ms: 10000 -> 20000",
+			"file_logging.server.level: info -> debug",
+			"max_req_per_min: 25 -> 50",
+		}))
+
+		result, err := blockchainService.ApplySettings(context.Background(), desired)
+		Expect(err).To(BeNil())
+		Expect(result.Changes).To(Equal(changes))
+		Expect(*result.Settings.MAXREQPERMIN).To(Equal(50.0))
+		Expect(edits).To(HaveLen(1))
+		sent, _ := json.Marshal(edits[0])
+		Expect(string(sent)).To(MatchJSON(`{
+			"fabric_general_timeout_ms": 20000,
+			"file_logging": {"server": {"level": "debug"}},
+			"max_req_per_min": 50
+		}`))
+
+		// nothing left to change
+		result, err = blockchainService.ApplySettings(context.Background(), desired)
+		Expect(err).To(BeNil())
+		Expect(result.Changes).To(BeEmpty())
+		Expect(edits).To(HaveLen(1))
+	})
+	It(`Fails when changes do not take effect`, func() {
+		ignoreEdits = true
+		desired := blockchainService.NewEditSettingsOptions().SetMaxReqPerMin(50).SetMaxReqPerMinAk(100)
+		result, err := blockchainService.ApplySettings(context.Background(), desired)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("2 settings did not take effect: max_req_per_min: 25 -> 50, max_req_per_min_ak: 25 -> 100"))
+		Expect(result.Changes).To(HaveLen(2))
+		Expect(edits).To(HaveLen(1))
+	})
+})
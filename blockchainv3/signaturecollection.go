@@ -0,0 +1,705 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/IBM-Blockchain/ibp-go-sdk/common"
+	"github.com/IBM/go-sdk-core/v4/core"
+	"reflect"
+)
+
+// ListSigTxs : Get all signature collection txs
+// Get the signature collection transactions of the console. These transactions collect the signatures of the
+// organizations that must approve a channel update before it can be submitted to the ordering service.
+func (blockchain *BlockchainV3) ListSigTxs(listSigTxsOptions *ListSigTxsOptions) (result *GetSignatureCollectionsResponse, response *core.DetailedResponse, err error) {
+	return blockchain.ListSigTxsWithContext(context.Background(), listSigTxsOptions)
+}
+
+// ListSigTxsWithContext is an alternate form of the ListSigTxs method which supports a Context parameter
+func (blockchain *BlockchainV3) ListSigTxsWithContext(ctx context.Context, listSigTxsOptions *ListSigTxsOptions) (result *GetSignatureCollectionsResponse, response *core.DetailedResponse, err error) {
+	err = core.ValidateStruct(listSigTxsOptions, "listSigTxsOptions")
+	if err != nil {
+		return
+	}
+
+	builder := core.NewRequestBuilder(core.GET)
+	builder = builder.WithContext(ctx)
+	builder.EnableGzipCompression = blockchain.GetEnableGzipCompression()
+	_, err = builder.ResolveRequestURL(blockchain.Service.Options.URL, `/ak/api/v3/signature_collections`, nil)
+	if err != nil {
+		return
+	}
+
+	if listSigTxsOptions != nil {
+		for headerName, headerValue := range listSigTxsOptions.Headers {
+			builder.AddHeader(headerName, headerValue)
+		}
+	}
+
+	sdkHeaders := common.GetSdkHeaders("blockchain", "V3", "ListSigTxs")
+	for headerName, headerValue := range sdkHeaders {
+		builder.AddHeader(headerName, headerValue)
+	}
+	builder.AddHeader("Accept", "application/json")
+
+	request, err := builder.Build()
+	if err != nil {
+		return
+	}
+
+	var rawResponse map[string]json.RawMessage
+	response, err = blockchain.Service.Request(request, &rawResponse)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalModel(rawResponse, "", &result, UnmarshalGetSignatureCollectionsResponse)
+	if err != nil {
+		return
+	}
+	response.Result = result
+
+	return
+}
+
+// GetSigTx : Get a signature collection tx
+// Get a signature collection transaction, with the signatures collected so far.
+func (blockchain *BlockchainV3) GetSigTx(getSigTxOptions *GetSigTxOptions) (result *SignatureCollection, response *core.DetailedResponse, err error) {
+	return blockchain.GetSigTxWithContext(context.Background(), getSigTxOptions)
+}
+
+// GetSigTxWithContext is an alternate form of the GetSigTx method which supports a Context parameter
+func (blockchain *BlockchainV3) GetSigTxWithContext(ctx context.Context, getSigTxOptions *GetSigTxOptions) (result *SignatureCollection, response *core.DetailedResponse, err error) {
+	err = core.ValidateNotNil(getSigTxOptions, "getSigTxOptions cannot be nil")
+	if err != nil {
+		return
+	}
+	err = core.ValidateStruct(getSigTxOptions, "getSigTxOptions")
+	if err != nil {
+		return
+	}
+
+	pathParamsMap := map[string]string{
+		"id": *getSigTxOptions.ID,
+	}
+
+	builder := core.NewRequestBuilder(core.GET)
+	builder = builder.WithContext(ctx)
+	builder.EnableGzipCompression = blockchain.GetEnableGzipCompression()
+	_, err = builder.ResolveRequestURL(blockchain.Service.Options.URL, `/ak/api/v3/signature_collections/{id}`, pathParamsMap)
+	if err != nil {
+		return
+	}
+
+	for headerName, headerValue := range getSigTxOptions.Headers {
+		builder.AddHeader(headerName, headerValue)
+	}
+
+	sdkHeaders := common.GetSdkHeaders("blockchain", "V3", "GetSigTx")
+	for headerName, headerValue := range sdkHeaders {
+		builder.AddHeader(headerName, headerValue)
+	}
+	builder.AddHeader("Accept", "application/json")
+
+	request, err := builder.Build()
+	if err != nil {
+		return
+	}
+
+	var rawResponse map[string]json.RawMessage
+	response, err = blockchain.Service.Request(request, &rawResponse)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalModel(rawResponse, "", &result, UnmarshalSignatureCollection)
+	if err != nil {
+		return
+	}
+	response.Result = result
+
+	return
+}
+
+// CreateSigTx : Create a signature collection tx
+// Create a signature collection transaction for a channel config update. The console sends the transaction to the
+// consoles of the organizations that must sign it.
+func (blockchain *BlockchainV3) CreateSigTx(createSigTxOptions *CreateSigTxOptions) (result *SignatureCollectionResponse, response *core.DetailedResponse, err error) {
+	return blockchain.CreateSigTxWithContext(context.Background(), createSigTxOptions)
+}
+
+// CreateSigTxWithContext is an alternate form of the CreateSigTx method which supports a Context parameter
+func (blockchain *BlockchainV3) CreateSigTxWithContext(ctx context.Context, createSigTxOptions *CreateSigTxOptions) (result *SignatureCollectionResponse, response *core.DetailedResponse, err error) {
+	err = core.ValidateNotNil(createSigTxOptions, "createSigTxOptions cannot be nil")
+	if err != nil {
+		return
+	}
+	err = core.ValidateStruct(createSigTxOptions, "createSigTxOptions")
+	if err != nil {
+		return
+	}
+
+	builder := core.NewRequestBuilder(core.POST)
+	builder = builder.WithContext(ctx)
+	builder.EnableGzipCompression = blockchain.GetEnableGzipCompression()
+	_, err = builder.ResolveRequestURL(blockchain.Service.Options.URL, `/ak/api/v3/signature_collections`, nil)
+	if err != nil {
+		return
+	}
+
+	for headerName, headerValue := range createSigTxOptions.Headers {
+		builder.AddHeader(headerName, headerValue)
+	}
+
+	sdkHeaders := common.GetSdkHeaders("blockchain", "V3", "CreateSigTx")
+	for headerName, headerValue := range sdkHeaders {
+		builder.AddHeader(headerName, headerValue)
+	}
+	builder.AddHeader("Accept", "application/json")
+	builder.AddHeader("Content-Type", "application/json")
+
+	body := make(map[string]interface{})
+	if createSigTxOptions.TxID != nil {
+		body["tx_id"] = createSigTxOptions.TxID
+	}
+	if createSigTxOptions.Channel != nil {
+		body["channel"] = createSigTxOptions.Channel
+	}
+	if createSigTxOptions.ConfigUpdate != nil {
+		body["config_update"] = createSigTxOptions.ConfigUpdate
+	}
+	if createSigTxOptions.OriginatorMsp != nil {
+		body["originator_msp"] = createSigTxOptions.OriginatorMsp
+	}
+	if createSigTxOptions.Orgs2sign != nil {
+		body["orgs2sign"] = createSigTxOptions.Orgs2sign
+	}
+	if createSigTxOptions.CurrentPolicy != nil {
+		body["current_policy"] = createSigTxOptions.CurrentPolicy
+	}
+	if createSigTxOptions.Orderers != nil {
+		body["orderers"] = createSigTxOptions.Orderers
+	}
+	if createSigTxOptions.JSONDiff != nil {
+		body["json_diff"] = createSigTxOptions.JSONDiff
+	}
+	_, err = builder.SetBodyContentJSON(body)
+	if err != nil {
+		return
+	}
+
+	request, err := builder.Build()
+	if err != nil {
+		return
+	}
+
+	var rawResponse map[string]json.RawMessage
+	response, err = blockchain.Service.Request(request, &rawResponse)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalModel(rawResponse, "", &result, UnmarshalSignatureCollectionResponse)
+	if err != nil {
+		return
+	}
+	response.Result = result
+
+	return
+}
+
+// SignSigTx : Sign a signature collection tx
+// Add the signature of an organization to a signature collection transaction. The signature is the signature of the
+// config update by an admin of the organization, computed by the caller; the console only stores and relays it.
+func (blockchain *BlockchainV3) SignSigTx(signSigTxOptions *SignSigTxOptions) (result *SignatureCollectionResponse, response *core.DetailedResponse, err error) {
+	return blockchain.SignSigTxWithContext(context.Background(), signSigTxOptions)
+}
+
+// SignSigTxWithContext is an alternate form of the SignSigTx method which supports a Context parameter
+func (blockchain *BlockchainV3) SignSigTxWithContext(ctx context.Context, signSigTxOptions *SignSigTxOptions) (result *SignatureCollectionResponse, response *core.DetailedResponse, err error) {
+	err = core.ValidateNotNil(signSigTxOptions, "signSigTxOptions cannot be nil")
+	if err != nil {
+		return
+	}
+	err = core.ValidateStruct(signSigTxOptions, "signSigTxOptions")
+	if err != nil {
+		return
+	}
+
+	builder := core.NewRequestBuilder(core.PUT)
+	builder = builder.WithContext(ctx)
+	builder.EnableGzipCompression = blockchain.GetEnableGzipCompression()
+	_, err = builder.ResolveRequestURL(blockchain.Service.Options.URL, `/ak/api/v3/signature_collections`, nil)
+	if err != nil {
+		return
+	}
+
+	for headerName, headerValue := range signSigTxOptions.Headers {
+		builder.AddHeader(headerName, headerValue)
+	}
+
+	sdkHeaders := common.GetSdkHeaders("blockchain", "V3", "SignSigTx")
+	for headerName, headerValue := range sdkHeaders {
+		builder.AddHeader(headerName, headerValue)
+	}
+	builder.AddHeader("Accept", "application/json")
+	builder.AddHeader("Content-Type", "application/json")
+
+	body := make(map[string]interface{})
+	if signSigTxOptions.TxID != nil {
+		body["tx_id"] = signSigTxOptions.TxID
+	}
+	if signSigTxOptions.Org != nil {
+		body["org"] = signSigTxOptions.Org
+	}
+	_, err = builder.SetBodyContentJSON(body)
+	if err != nil {
+		return
+	}
+
+	request, err := builder.Build()
+	if err != nil {
+		return
+	}
+
+	var rawResponse map[string]json.RawMessage
+	response, err = blockchain.Service.Request(request, &rawResponse)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalModel(rawResponse, "", &result, UnmarshalSignatureCollectionResponse)
+	if err != nil {
+		return
+	}
+	response.Result = result
+
+	return
+}
+
+// ListSigTxsOptions : The ListSigTxs options.
+type ListSigTxsOptions struct {
+
+	// Allows users to set headers on API requests
+	Headers map[string]string
+}
+
+// NewListSigTxsOptions : Instantiate ListSigTxsOptions
+func (*BlockchainV3) NewListSigTxsOptions() *ListSigTxsOptions {
+	return &ListSigTxsOptions{}
+}
+
+// SetHeaders : Allow user to set Headers
+func (options *ListSigTxsOptions) SetHeaders(param map[string]string) *ListSigTxsOptions {
+	options.Headers = param
+	return options
+}
+
+// GetSigTxOptions : The GetSigTx options.
+type GetSigTxOptions struct {
+	// The unique transaction ID of this signature collection.
+	ID *string `json:"id" validate:"required,ne="`
+
+	// Allows users to set headers on API requests
+	Headers map[string]string
+}
+
+// NewGetSigTxOptions : Instantiate GetSigTxOptions
+func (*BlockchainV3) NewGetSigTxOptions(id string) *GetSigTxOptions {
+	return &GetSigTxOptions{
+		ID: core.StringPtr(id),
+	}
+}
+
+// SetID : Allow user to set ID
+func (options *GetSigTxOptions) SetID(id string) *GetSigTxOptions {
+	options.ID = core.StringPtr(id)
+	return options
+}
+
+// SetHeaders : Allow user to set Headers
+func (options *GetSigTxOptions) SetHeaders(param map[string]string) *GetSigTxOptions {
+	options.Headers = param
+	return options
+}
+
+// CreateSigTxOptions : The CreateSigTx options.
+type CreateSigTxOptions struct {
+	// The unique transaction ID of this signature collection. Must start with a letter.
+	TxID *string `json:"tx_id" validate:"required"`
+
+	// The name of the channel the config update is for.
+	Channel *string `json:"channel" validate:"required"`
+
+	// The base 64 encoded `ConfigUpdate` protobuf of the channel update.
+	ConfigUpdate *string `json:"config_update" validate:"required"`
+
+	// The MSP ID of the organization that created the transaction.
+	OriginatorMsp *string `json:"originator_msp" validate:"required"`
+
+	// The organizations that must sign the config update. The originator may include its own signature.
+	Orgs2sign []SignatureCollectionOrg `json:"orgs2sign" validate:"required"`
+
+	// The signature policy of the config update.
+	CurrentPolicy *SignatureCollectionPolicy `json:"current_policy,omitempty"`
+
+	// The URLs of the orderers to submit the signed config update to.
+	Orderers []string `json:"orderers,omitempty"`
+
+	// A description of the changes of the config update, shown in the console.
+	JSONDiff map[string]interface{} `json:"json_diff,omitempty"`
+
+	// Allows users to set headers on API requests
+	Headers map[string]string
+}
+
+// NewCreateSigTxOptions : Instantiate CreateSigTxOptions
+func (*BlockchainV3) NewCreateSigTxOptions(txID string, channel string, configUpdate string, originatorMsp string, orgs2sign []SignatureCollectionOrg) *CreateSigTxOptions {
+	return &CreateSigTxOptions{
+		TxID:          core.StringPtr(txID),
+		Channel:       core.StringPtr(channel),
+		ConfigUpdate:  core.StringPtr(configUpdate),
+		OriginatorMsp: core.StringPtr(originatorMsp),
+		Orgs2sign:     orgs2sign,
+	}
+}
+
+// SetTxID : Allow user to set TxID
+func (options *CreateSigTxOptions) SetTxID(txID string) *CreateSigTxOptions {
+	options.TxID = core.StringPtr(txID)
+	return options
+}
+
+// SetChannel : Allow user to set Channel
+func (options *CreateSigTxOptions) SetChannel(channel string) *CreateSigTxOptions {
+	options.Channel = core.StringPtr(channel)
+	return options
+}
+
+// SetConfigUpdate : Allow user to set ConfigUpdate
+func (options *CreateSigTxOptions) SetConfigUpdate(configUpdate string) *CreateSigTxOptions {
+	options.ConfigUpdate = core.StringPtr(configUpdate)
+	return options
+}
+
+// SetOriginatorMsp : Allow user to set OriginatorMsp
+func (options *CreateSigTxOptions) SetOriginatorMsp(originatorMsp string) *CreateSigTxOptions {
+	options.OriginatorMsp = core.StringPtr(originatorMsp)
+	return options
+}
+
+// SetOrgs2sign : Allow user to set Orgs2sign
+func (options *CreateSigTxOptions) SetOrgs2sign(orgs2sign []SignatureCollectionOrg) *CreateSigTxOptions {
+	options.Orgs2sign = orgs2sign
+	return options
+}
+
+// SetCurrentPolicy : Allow user to set CurrentPolicy
+func (options *CreateSigTxOptions) SetCurrentPolicy(currentPolicy *SignatureCollectionPolicy) *CreateSigTxOptions {
+	options.CurrentPolicy = currentPolicy
+	return options
+}
+
+// SetOrderers : Allow user to set Orderers
+func (options *CreateSigTxOptions) SetOrderers(orderers []string) *CreateSigTxOptions {
+	options.Orderers = orderers
+	return options
+}
+
+// SetJSONDiff : Allow user to set JSONDiff
+func (options *CreateSigTxOptions) SetJSONDiff(jsonDiff map[string]interface{}) *CreateSigTxOptions {
+	options.JSONDiff = jsonDiff
+	return options
+}
+
+// SetHeaders : Allow user to set Headers
+func (options *CreateSigTxOptions) SetHeaders(param map[string]string) *CreateSigTxOptions {
+	options.Headers = param
+	return options
+}
+
+// SignSigTxOptions : The SignSigTx options.
+type SignSigTxOptions struct {
+	// The unique transaction ID of this signature collection.
+	TxID *string `json:"tx_id" validate:"required"`
+
+	// The signing organization, with its signature.
+	Org *SignatureCollectionOrg `json:"org" validate:"required"`
+
+	// Allows users to set headers on API requests
+	Headers map[string]string
+}
+
+// NewSignSigTxOptions : Instantiate SignSigTxOptions
+func (*BlockchainV3) NewSignSigTxOptions(txID string, org *SignatureCollectionOrg) *SignSigTxOptions {
+	return &SignSigTxOptions{
+		TxID: core.StringPtr(txID),
+		Org:  org,
+	}
+}
+
+// SetTxID : Allow user to set TxID
+func (options *SignSigTxOptions) SetTxID(txID string) *SignSigTxOptions {
+	options.TxID = core.StringPtr(txID)
+	return options
+}
+
+// SetOrg : Allow user to set Org
+func (options *SignSigTxOptions) SetOrg(org *SignatureCollectionOrg) *SignSigTxOptions {
+	options.Org = org
+	return options
+}
+
+// SetHeaders : Allow user to set Headers
+func (options *SignSigTxOptions) SetHeaders(param map[string]string) *SignSigTxOptions {
+	options.Headers = param
+	return options
+}
+
+// GetSignatureCollectionsResponse : GetSignatureCollectionsResponse struct
+type GetSignatureCollectionsResponse struct {
+	// Number of signature collection transactions.
+	Total *float64 `json:"total,omitempty"`
+
+	// The signature collection transactions.
+	Transactions []SignatureCollection `json:"transactions,omitempty"`
+}
+
+// UnmarshalGetSignatureCollectionsResponse unmarshals an instance of GetSignatureCollectionsResponse from the specified map of raw messages.
+func UnmarshalGetSignatureCollectionsResponse(m map[string]json.RawMessage, result interface{}) (err error) {
+	obj := new(GetSignatureCollectionsResponse)
+	err = core.UnmarshalPrimitive(m, "total", &obj.Total)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalModel(m, "transactions", &obj.Transactions, UnmarshalSignatureCollection)
+	if err != nil {
+		return
+	}
+	reflect.ValueOf(result).Elem().Set(reflect.ValueOf(obj))
+	return
+}
+
+// Constants associated with the SignatureCollection.Status property.
+// Whether the transaction is still collecting signatures.
+const (
+	SignatureCollection_Status_Closed = "closed"
+	SignatureCollection_Status_Open   = "open"
+)
+
+// SignatureCollection : A signature collection transaction.
+type SignatureCollection struct {
+	// The unique transaction ID of this signature collection.
+	TxID *string `json:"tx_id,omitempty"`
+
+	// Whether the transaction is still collecting signatures.
+	Status *string `json:"status,omitempty"`
+
+	// The name of the channel the config update is for.
+	Channel *string `json:"channel,omitempty"`
+
+	// The base 64 encoded `ConfigUpdate` protobuf of the channel update.
+	ConfigUpdate *string `json:"config_update,omitempty"`
+
+	// The MSP ID of the organization that created the transaction.
+	OriginatorMsp *string `json:"originator_msp,omitempty"`
+
+	// The organizations that must sign the config update, with the signatures collected so far.
+	Orgs2sign []SignatureCollectionOrg `json:"orgs2sign,omitempty"`
+
+	// The signature policy of the config update.
+	CurrentPolicy *SignatureCollectionPolicy `json:"current_policy,omitempty"`
+
+	// The URLs of the orderers to submit the signed config update to.
+	Orderers []string `json:"orderers,omitempty"`
+
+	// A description of the changes of the config update, shown in the console.
+	JSONDiff map[string]interface{} `json:"json_diff,omitempty"`
+
+	// UTC UNIX timestamp of the creation of the transaction. In milliseconds.
+	Timestamp *float64 `json:"timestamp,omitempty"`
+}
+
+// UnmarshalSignatureCollection unmarshals an instance of SignatureCollection from the specified map of raw messages.
+func UnmarshalSignatureCollection(m map[string]json.RawMessage, result interface{}) (err error) {
+	obj := new(SignatureCollection)
+	err = core.UnmarshalPrimitive(m, "tx_id", &obj.TxID)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalPrimitive(m, "status", &obj.Status)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalPrimitive(m, "channel", &obj.Channel)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalPrimitive(m, "config_update", &obj.ConfigUpdate)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalPrimitive(m, "originator_msp", &obj.OriginatorMsp)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalModel(m, "orgs2sign", &obj.Orgs2sign, UnmarshalSignatureCollectionOrg)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalModel(m, "current_policy", &obj.CurrentPolicy, UnmarshalSignatureCollectionPolicy)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalPrimitive(m, "orderers", &obj.Orderers)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalPrimitive(m, "json_diff", &obj.JSONDiff)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalPrimitive(m, "timestamp", &obj.Timestamp)
+	if err != nil {
+		return
+	}
+	reflect.ValueOf(result).Elem().Set(reflect.ValueOf(obj))
+	return
+}
+
+// Signed returns the MSP IDs of the organizations that have signed, in the order of Orgs2sign.
+func (collection *SignatureCollection) Signed() (mspIDs []string) {
+	for _, org := range collection.Orgs2sign {
+		if org.Signature != nil && *org.Signature != "" {
+			mspIDs = append(mspIDs, core.StringNilMapper(org.MspID))
+		}
+	}
+	return
+}
+
+// Pending returns the MSP IDs of the organizations that have not signed yet, in the order of Orgs2sign.
+func (collection *SignatureCollection) Pending() (mspIDs []string) {
+	for _, org := range collection.Orgs2sign {
+		if org.Signature == nil || *org.Signature == "" {
+			mspIDs = append(mspIDs, core.StringNilMapper(org.MspID))
+		}
+	}
+	return
+}
+
+// HasEnoughSignatures reports whether the transaction has the number of signatures its policy requires, or the
+// signatures of every organization if it has no policy.
+func (collection *SignatureCollection) HasEnoughSignatures() bool {
+	signed := len(collection.Signed())
+	if collection.CurrentPolicy != nil && collection.CurrentPolicy.NumberOfSignatures != nil {
+		return float64(signed) >= *collection.CurrentPolicy.NumberOfSignatures
+	}
+	return signed == len(collection.Orgs2sign)
+}
+
+// SignatureCollectionOrg : An organization that must sign a signature collection transaction.
+type SignatureCollectionOrg struct {
+	// The MSP ID of the organization.
+	MspID *string `json:"msp_id" validate:"required"`
+
+	// The URL of the console of the organization, where the transaction is sent to be signed.
+	OptoolsURL *string `json:"optools_url,omitempty"`
+
+	// The base 64 encoded PEM certificate of the admin that signs, or signed, for the organization.
+	Certificate *string `json:"certificate,omitempty"`
+
+	// The base 64 encoded signature of the config update. Not set until the organization signs.
+	Signature *string `json:"signature,omitempty"`
+
+	// UTC UNIX timestamp of the signature. In milliseconds.
+	Timestamp *float64 `json:"timestamp,omitempty"`
+}
+
+// NewSignatureCollectionOrg : Instantiate SignatureCollectionOrg (Generic Model Constructor)
+func (*BlockchainV3) NewSignatureCollectionOrg(mspID string) (model *SignatureCollectionOrg, err error) {
+	model = &SignatureCollectionOrg{
+		MspID: core.StringPtr(mspID),
+	}
+	err = core.ValidateStruct(model, "required parameters")
+	return
+}
+
+// UnmarshalSignatureCollectionOrg unmarshals an instance of SignatureCollectionOrg from the specified map of raw messages.
+func UnmarshalSignatureCollectionOrg(m map[string]json.RawMessage, result interface{}) (err error) {
+	obj := new(SignatureCollectionOrg)
+	err = core.UnmarshalPrimitive(m, "msp_id", &obj.MspID)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalPrimitive(m, "optools_url", &obj.OptoolsURL)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalPrimitive(m, "certificate", &obj.Certificate)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalPrimitive(m, "signature", &obj.Signature)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalPrimitive(m, "timestamp", &obj.Timestamp)
+	if err != nil {
+		return
+	}
+	reflect.ValueOf(result).Elem().Set(reflect.ValueOf(obj))
+	return
+}
+
+// SignatureCollectionPolicy : The signature policy of a signature collection transaction.
+type SignatureCollectionPolicy struct {
+	// The number of organizations that must sign.
+	NumberOfSignatures *float64 `json:"number_of_signatures,omitempty"`
+}
+
+// UnmarshalSignatureCollectionPolicy unmarshals an instance of SignatureCollectionPolicy from the specified map of raw messages.
+func UnmarshalSignatureCollectionPolicy(m map[string]json.RawMessage, result interface{}) (err error) {
+	obj := new(SignatureCollectionPolicy)
+	err = core.UnmarshalPrimitive(m, "number_of_signatures", &obj.NumberOfSignatures)
+	if err != nil {
+		return
+	}
+	reflect.ValueOf(result).Elem().Set(reflect.ValueOf(obj))
+	return
+}
+
+// SignatureCollectionResponse : SignatureCollectionResponse struct
+type SignatureCollectionResponse struct {
+	// Response message. "ok" indicates the api completed successfully.
+	Message *string `json:"message,omitempty"`
+
+	// The unique transaction ID of this signature collection.
+	TxID *string `json:"tx_id,omitempty"`
+}
+
+// UnmarshalSignatureCollectionResponse unmarshals an instance of SignatureCollectionResponse from the specified map of raw messages.
+func UnmarshalSignatureCollectionResponse(m map[string]json.RawMessage, result interface{}) (err error) {
+	obj := new(SignatureCollectionResponse)
+	err = core.UnmarshalPrimitive(m, "message", &obj.Message)
+	if err != nil {
+		return
+	}
+	err = core.UnmarshalPrimitive(m, "tx_id", &obj.TxID)
+	if err != nil {
+		return
+	}
+	reflect.ValueOf(result).Elem().Set(reflect.ValueOf(obj))
+	return
+}
@@ -0,0 +1,146 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe(`BlockchainV3 signature collections`, func() {
+	const collection = `{
+		"tx_id": "abcdef", "status": "open", "channel": "mychannel", "config_update": "CgljaGFubmVs",
+		"originator_msp": "org1", "current_policy": {"number_of_signatures": 2}, "orderers": ["grpcs://orderer:7050"],
+		"timestamp": 1537262855753,
+		"orgs2sign": [
+			{"msp_id": "org1", "optools_url": "https://org1", "certificate": "Y2VydDE=", "signature": "c2lnMQ==", "timestamp": 1537262855753},
+			{"msp_id": "org2", "optools_url": "https://org2"},
+			{"msp_id": "org3", "optools_url": "https://org3", "signature": ""}
+		]
+	}`
+	var testServer *httptest.Server
+	var blockchainService *blockchainv3.BlockchainV3
+	var requests []string
+	var bodies []map[string]interface{}
+
+	BeforeEach(func() {
+		requests, bodies = nil, nil
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			op, ok := blockchainv3.LookupOperation(req.Method, req.URL.Path)
+			Expect(ok).To(BeTrue())
+			requests = append(requests, op.Name)
+			if req.Body != nil {
+				raw, _ := ioutil.ReadAll(req.Body)
+				if len(raw) > 0 {
+					var body map[string]interface{}
+					Expect(json.Unmarshal(raw, &body)).To(BeNil())
+					bodies = append(bodies, body)
+				}
+			}
+			res.Header().Set("Content-type", "application/json")
+			switch op.Name {
+			case "ListSigTxs":
+				fmt.Fprintf(res, `{"total": 1, "transactions": [%s]}`, collection)
+			case "GetSigTx":
+				Expect(req.URL.Path).To(Equal("/ak/api/v3/signature_collections/abcdef"))
+				fmt.Fprint(res, collection)
+			case "CreateSigTx", "SignSigTx":
+				fmt.Fprint(res, `{"message": "ok", "tx_id": "abcdef"}`)
+			default:
+				Fail("unexpected request " + req.Method + " " + req.URL.Path)
+			}
+		}))
+		var err error
+		blockchainService, err = blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Lists and gets signature collections`, func() {
+		list, _, err := blockchainService.ListSigTxs(blockchainService.NewListSigTxsOptions())
+		Expect(err).To(BeNil())
+		Expect(*list.Total).To(Equal(1.0))
+		Expect(list.Transactions).To(HaveLen(1))
+
+		tx, _, err := blockchainService.GetSigTx(blockchainService.NewGetSigTxOptions("abcdef"))
+		Expect(err).To(BeNil())
+		Expect(requests).To(Equal([]string{"ListSigTxs", "GetSigTx"}))
+		Expect(*tx.Status).To(Equal(blockchainv3.SignatureCollection_Status_Open))
+		Expect(*tx.CurrentPolicy.NumberOfSignatures).To(Equal(2.0))
+		Expect(tx.Orderers).To(Equal([]string{"grpcs://orderer:7050"}))
+		Expect(*tx.Orgs2sign[0].Certificate).To(Equal("Y2VydDE="))
+		Expect(tx.Signed()).To(Equal([]string{"org1"}))
+		Expect(tx.Pending()).To(Equal([]string{"org2", "org3"}))
+		Expect(tx.HasEnoughSignatures()).To(BeFalse())
+
+		tx.Orgs2sign[1].Signature = core.StringPtr("c2lnMg==")
+		Expect(tx.HasEnoughSignatures()).To(BeTrue())
+		tx.CurrentPolicy = nil
+		Expect(tx.HasEnoughSignatures()).To(BeFalse())
+
+		_, _, err = blockchainService.GetSigTx(blockchainService.NewGetSigTxOptions(""))
+		Expect(err).ToNot(BeNil())
+	})
+	It(`Creates and signs signature collections`, func() {
+		org2, err := blockchainService.NewSignatureCollectionOrg("org2")
+		Expect(err).To(BeNil())
+		org2.OptoolsURL = core.StringPtr("https://org2")
+		createOptions := blockchainService.NewCreateSigTxOptions("abcdef", "mychannel", "CgljaGFubmVs", "org1", []blockchainv3.SignatureCollectionOrg{*org2}).
+			SetCurrentPolicy(&blockchainv3.SignatureCollectionPolicy{NumberOfSignatures: core.Float64Ptr(1)}).
+			SetOrderers([]string{"grpcs://orderer:7050"})
+		result, _, err := blockchainService.CreateSigTx(createOptions)
+		Expect(err).To(BeNil())
+		Expect(*result.TxID).To(Equal("abcdef"))
+
+		org2.Certificate = core.StringPtr("Y2VydDI=")
+		org2.Signature = core.StringPtr("c2lnMg==")
+		result, _, err = blockchainService.SignSigTx(blockchainService.NewSignSigTxOptions("abcdef", org2))
+		Expect(err).To(BeNil())
+		Expect(*result.Message).To(Equal("ok"))
+
+		Expect(requests).To(Equal([]string{"CreateSigTx", "SignSigTx"}))
+		created, _ := json.Marshal(bodies[0])
+		Expect(string(created)).To(MatchJSON(`{
+			"tx_id": "abcdef", "channel": "mychannel", "config_update": "CgljaGFubmVs", "originator_msp": "org1",
+			"orgs2sign": [{"msp_id": "org2", "optools_url": "https://org2"}],
+			"current_policy": {"number_of_signatures": 1}, "orderers": ["grpcs://orderer:7050"]
+		}`))
+		signed, _ := json.Marshal(bodies[1])
+		Expect(string(signed)).To(MatchJSON(`{
+			"tx_id": "abcdef",
+			"org": {"msp_id": "org2", "optools_url": "https://org2", "certificate": "Y2VydDI=", "signature": "c2lnMg=="}
+		}`))
+
+		_, _, err = blockchainService.CreateSigTx(createOptions.SetChannel("").SetOrgs2sign(nil))
+		Expect(err).ToNot(BeNil())
+		_, _, err = blockchainService.SignSigTx(nil)
+		Expect(err).ToNot(BeNil())
+	})
+})
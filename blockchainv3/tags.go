@@ -0,0 +1,290 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// tagUpdateAttempts is how many times a tag update is written before giving up on a component whose tags keep being
+// replaced by another writer.
+const tagUpdateAttempts = 3
+
+// tagLocks serializes the tag updates of this process per console and component.
+var tagLocks sync.Map
+
+// ComponentSelector : Selects components for bulk tagging. Every criterion that is set must match; a criterion with
+// several values matches any of them. Only peers, orderers and CAs are selected since other components have no tags.
+type ComponentSelector struct {
+	// Component ids.
+	IDs []string
+
+	// Component types, e.g. `fabric-peer`.
+	Types []string
+
+	MspIDs []string
+
+	ClusterIDs []string
+
+	// Tags the component must all have.
+	Tags []string
+
+	// Any other condition.
+	Match func(component *GenericComponentResponse) bool
+}
+
+// Matches reports whether the selector selects component.
+func (selector *ComponentSelector) Matches(component *GenericComponentResponse) bool {
+	if !isTaggable(component.Type) {
+		return false
+	}
+	oneOf := func(values []string, value *string) bool {
+		if len(values) == 0 {
+			return true
+		}
+		return value != nil && containsString(values, *value)
+	}
+	if !oneOf(selector.IDs, component.ID) || !oneOf(selector.Types, component.Type) ||
+		!oneOf(selector.MspIDs, component.MspID) || !oneOf(selector.ClusterIDs, component.ClusterID) {
+		return false
+	}
+	for _, tag := range selector.Tags {
+		if !containsString(component.Tags, tag) {
+			return false
+		}
+	}
+	return selector.Match == nil || selector.Match(component)
+}
+
+// TagUpdate : The outcome of a tag update of one component.
+type TagUpdate struct {
+	ID string
+
+	Type string
+
+	// The tags of the component after the update, or before it if it failed.
+	Tags []string
+
+	// False if the component already had the requested tags.
+	Changed bool
+
+	Error error
+}
+
+// AddTags adds tags to a peer, orderer or CA and returns its tags. See SetTags.
+func (blockchain *BlockchainV3) AddTags(ctx context.Context, id string, tags ...string) ([]string, error) {
+	update := blockchain.updateTags(ctx, id, &tagChange{add: tags})
+	return update.Tags, update.Error
+}
+
+// RemoveTags removes tags from a peer, orderer or CA and returns its tags. See SetTags.
+func (blockchain *BlockchainV3) RemoveTags(ctx context.Context, id string, tags ...string) ([]string, error) {
+	update := blockchain.updateTags(ctx, id, &tagChange{remove: tags})
+	return update.Tags, update.Error
+}
+
+// SetTags replaces the tags of a peer, orderer or CA and returns its tags.
+//
+// The component is read to find its type, then edited with EditPeer, EditOrderer or EditCa, which replace all tags.
+// Updates of the same component by this process are serialized. The console has no conditional update, so tags are
+// read back after writing them and the update is written again if another writer replaced them in the meantime.
+func (blockchain *BlockchainV3) SetTags(ctx context.Context, id string, tags ...string) ([]string, error) {
+	update := blockchain.updateTags(ctx, id, &tagChange{set: tags, replace: true})
+	return update.Tags, update.Error
+}
+
+// AddTagsBySelector adds tags to every component chosen by selector. See SetTagsBySelector.
+func (blockchain *BlockchainV3) AddTagsBySelector(ctx context.Context, selector *ComponentSelector, tags ...string) ([]TagUpdate, error) {
+	return blockchain.updateTagsBySelector(ctx, selector, &tagChange{add: tags})
+}
+
+// RemoveTagsBySelector removes tags from every component chosen by selector. See SetTagsBySelector.
+func (blockchain *BlockchainV3) RemoveTagsBySelector(ctx context.Context, selector *ComponentSelector, tags ...string) ([]TagUpdate, error) {
+	return blockchain.updateTagsBySelector(ctx, selector, &tagChange{remove: tags})
+}
+
+// SetTagsBySelector replaces the tags of every component chosen by selector.
+//
+// Components are updated one after the other as by SetTags, and a failure does not stop the others. The updates are
+// returned in the order of ListComponents, and the error lists the components that failed.
+func (blockchain *BlockchainV3) SetTagsBySelector(ctx context.Context, selector *ComponentSelector, tags ...string) ([]TagUpdate, error) {
+	return blockchain.updateTagsBySelector(ctx, selector, &tagChange{set: tags, replace: true})
+}
+
+func (blockchain *BlockchainV3) updateTagsBySelector(ctx context.Context, selector *ComponentSelector, change *tagChange) (updates []TagUpdate, err error) {
+	if selector == nil {
+		return nil, fmt.Errorf("selector cannot be nil")
+	}
+	components, _, err := blockchain.ListComponentsWithContext(ctx, blockchain.NewListComponentsOptions())
+	if err != nil {
+		return
+	}
+	var failures []string
+	for i := range components.Components {
+		component := &components.Components[i]
+		if component.ID == nil || !selector.Matches(component) {
+			continue
+		}
+		update := blockchain.updateTags(ctx, *component.ID, change)
+		if update.Error != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", update.ID, update.Error.Error()))
+		}
+		updates = append(updates, update)
+	}
+	if len(failures) > 0 {
+		err = fmt.Errorf("tagging failed for %d of %d components: %s", len(failures), len(updates), strings.Join(failures, "; "))
+	}
+	return
+}
+
+func (blockchain *BlockchainV3) updateTags(ctx context.Context, id string, change *tagChange) (update TagUpdate) {
+	update.ID = id
+	lock, _ := tagLocks.LoadOrStore(blockchain.Service.Options.URL+" "+id, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	for attempt := 0; attempt < tagUpdateAttempts; attempt++ {
+		component, _, err := blockchain.GetComponentWithContext(ctx, blockchain.NewGetComponentOptions(id).SetCache(GetComponentOptions_Cache_Skip))
+		if err == nil && component == nil {
+			err = fmt.Errorf("component %s not found", id)
+		}
+		if err != nil {
+			update.Error = err
+			return
+		}
+		if component.Type != nil {
+			update.Type = *component.Type
+		}
+		update.Tags = component.Tags
+		if !isTaggable(component.Type) {
+			update.Error = fmt.Errorf("component %s is of type %q, only peers, orderers and CAs have tags", id, update.Type)
+			return
+		}
+		if change.satisfied(component.Tags) {
+			return
+		}
+
+		tags := change.apply(component.Tags)
+		switch update.Type {
+		case GenericComponentResponse_Type_FabricPeer:
+			_, _, err = blockchain.EditPeerWithContext(ctx, blockchain.NewEditPeerOptions(id).SetTags(tags))
+		case GenericComponentResponse_Type_FabricOrderer:
+			_, _, err = blockchain.EditOrdererWithContext(ctx, blockchain.NewEditOrdererOptions(id).SetTags(tags))
+		case GenericComponentResponse_Type_FabricCa:
+			_, _, err = blockchain.EditCaWithContext(ctx, blockchain.NewEditCaOptions(id).SetTags(tags))
+		}
+		if err != nil {
+			update.Error = err
+			return
+		}
+		update.Tags = tags
+		update.Changed = true
+	}
+
+	// the last write was not read back yet
+	component, _, err := blockchain.GetComponentWithContext(ctx, blockchain.NewGetComponentOptions(id).SetCache(GetComponentOptions_Cache_Skip))
+	if err == nil && component == nil {
+		err = fmt.Errorf("component %s not found", id)
+	}
+	if err != nil {
+		update.Error = err
+		return
+	}
+	update.Tags = component.Tags
+	if !change.satisfied(component.Tags) {
+		update.Error = fmt.Errorf("the tags of %s were replaced by another writer %d times in a row", id, tagUpdateAttempts)
+	}
+	return
+}
+
+func isTaggable(componentType *string) bool {
+	if componentType == nil {
+		return false
+	}
+	switch *componentType {
+	case GenericComponentResponse_Type_FabricPeer, GenericComponentResponse_Type_FabricOrderer, GenericComponentResponse_Type_FabricCa:
+		return true
+	}
+	return false
+}
+
+// tagChange is a change to the tags of a component.
+type tagChange struct {
+	add     []string
+	remove  []string
+	set     []string
+	replace bool
+}
+
+// apply returns the tags after the change. Existing tags keep their order and duplicates are dropped. The result is
+// never nil, so that an empty list of tags is sent to the console.
+func (change *tagChange) apply(current []string) []string {
+	tags := []string{}
+	add := func(values []string) {
+		for _, tag := range values {
+			if !containsString(tags, tag) && !containsString(change.remove, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	if change.replace {
+		add(change.set)
+	} else {
+		add(current)
+		add(change.add)
+	}
+	return tags
+}
+
+// satisfied reports whether tags already reflect the change.
+func (change *tagChange) satisfied(tags []string) bool {
+	if change.replace {
+		wanted := change.apply(nil)
+		if len(tags) != len(wanted) {
+			return false
+		}
+		for _, tag := range wanted {
+			if !containsString(tags, tag) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, tag := range change.add {
+		if !containsString(tags, tag) {
+			return false
+		}
+	}
+	for _, tag := range change.remove {
+		if containsString(tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
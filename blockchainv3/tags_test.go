@@ -0,0 +1,183 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+var _ = Describe(`BlockchainV3 tag management`, func() {
+	var testServer *httptest.Server
+	var blockchainService *blockchainv3.BlockchainV3
+	var mutex sync.Mutex
+	var components map[string]map[string]interface{}
+	var order []string
+	var edits []string
+	// called after an edit is stored, to simulate another writer
+	var afterEdit func(id string)
+
+	BeforeEach(func() {
+		components = map[string]map[string]interface{}{}
+		order = nil
+		edits = nil
+		afterEdit = nil
+		for _, c := range []struct{ id, kind, msp string }{
+			{"peer1", "fabric-peer", "org1"}, {"os1", "fabric-orderer", "ordererorg"}, {"ca1", "fabric-ca", "org1"}, {"msp1", "msp", "org1"},
+		} {
+			components[c.id] = map[string]interface{}{"id": c.id, "type": c.kind, "msp_id": c.msp, "tags": []string{c.kind}}
+			order = append(order, c.id)
+		}
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			mutex.Lock()
+			defer mutex.Unlock()
+			res.Header().Set("Content-type", "application/json")
+			parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/ak/api/v3/components"), "/")
+			switch {
+			case req.Method == "GET" && len(parts) == 1:
+				var list []interface{}
+				for _, id := range order {
+					list = append(list, components[id])
+				}
+				json.NewEncoder(res).Encode(map[string]interface{}{"components": list})
+			case req.Method == "GET" && len(parts) == 2:
+				Expect(req.URL.Query().Get("cache")).To(Equal("skip"))
+				json.NewEncoder(res).Encode(components[parts[1]])
+			case req.Method == "PUT" && len(parts) == 3:
+				component := components[parts[2]]
+				Expect(component["type"]).To(Equal(parts[1]))
+				var body map[string][]string
+				Expect(json.NewDecoder(req.Body).Decode(&body)).To(BeNil())
+				Expect(body).To(HaveKey("tags"))
+				component["tags"] = body["tags"]
+				edits = append(edits, parts[2])
+				json.NewEncoder(res).Encode(component)
+				if afterEdit != nil {
+					afterEdit(parts[2])
+				}
+			default:
+				res.WriteHeader(404)
+				fmt.Fprint(res, `{"message": "not found"}`)
+			}
+		}))
+		var serviceErr error
+		blockchainService, serviceErr = blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(serviceErr).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Adds, removes and sets tags of each component type`, func() {
+		ctx := context.Background()
+		tags, err := blockchainService.AddTags(ctx, "peer1", "prod", "fabric-peer", "prod")
+		Expect(err).To(BeNil())
+		Expect(tags).To(Equal([]string{"fabric-peer", "prod"}))
+
+		tags, err = blockchainService.RemoveTags(ctx, "os1", "fabric-orderer")
+		Expect(err).To(BeNil())
+		Expect(tags).To(Equal([]string{}))
+		Expect(components["os1"]["tags"]).To(Equal([]string{}))
+
+		tags, err = blockchainService.SetTags(ctx, "ca1", "root", "prod")
+		Expect(err).To(BeNil())
+		Expect(tags).To(Equal([]string{"root", "prod"}))
+		Expect(edits).To(Equal([]string{"peer1", "os1", "ca1"}))
+
+		// nothing to change
+		_, err = blockchainService.AddTags(ctx, "peer1", "prod")
+		Expect(err).To(BeNil())
+		_, err = blockchainService.SetTags(ctx, "ca1", "prod", "root")
+		Expect(err).To(BeNil())
+		Expect(edits).To(HaveLen(3))
+	})
+	It(`Rejects components without tags`, func() {
+		_, err := blockchainService.AddTags(context.Background(), "msp1", "prod")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring(`component msp1 is of type "msp"`))
+		_, err = blockchainService.AddTags(context.Background(), "nope", "prod")
+		Expect(err).ToNot(BeNil())
+	})
+	It(`Serializes concurrent updates of a component`, func() {
+		var wait sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wait.Add(1)
+			go func(tag string) {
+				defer GinkgoRecover()
+				defer wait.Done()
+				_, err := blockchainService.AddTags(context.Background(), "peer1", tag)
+				Expect(err).To(BeNil())
+			}(fmt.Sprintf("tag%d", i))
+		}
+		wait.Wait()
+		Expect(components["peer1"]["tags"]).To(HaveLen(11))
+	})
+	It(`Writes again when another writer replaced the tags`, func() {
+		replaced := 0
+		afterEdit = func(id string) {
+			if replaced < 1 {
+				replaced++
+				components[id]["tags"] = []string{"someone-else"}
+			}
+		}
+		tags, err := blockchainService.AddTags(context.Background(), "peer1", "prod")
+		Expect(err).To(BeNil())
+		Expect(tags).To(Equal([]string{"someone-else", "prod"}))
+		Expect(edits).To(HaveLen(2))
+
+		afterEdit = func(id string) { components[id]["tags"] = []string{} }
+		_, err = blockchainService.AddTags(context.Background(), "peer1", "dev")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("replaced by another writer 3 times"))
+	})
+	It(`Tags components chosen by a selector`, func() {
+		updates, err := blockchainService.AddTagsBySelector(context.Background(), &blockchainv3.ComponentSelector{MspIDs: []string{"org1"}}, "org1")
+		Expect(err).To(BeNil())
+		Expect(updates).To(HaveLen(2))
+		Expect(updates[0]).To(Equal(blockchainv3.TagUpdate{ID: "peer1", Type: "fabric-peer", Tags: []string{"fabric-peer", "org1"}, Changed: true}))
+		Expect(updates[1].ID).To(Equal("ca1"))
+
+		updates, err = blockchainService.SetTagsBySelector(context.Background(), &blockchainv3.ComponentSelector{
+			Tags:  []string{"org1"},
+			Match: func(component *blockchainv3.GenericComponentResponse) bool { return *component.Type == "fabric-ca" },
+		}, "ca")
+		Expect(err).To(BeNil())
+		Expect(updates).To(HaveLen(1))
+		Expect(components["ca1"]["tags"]).To(Equal([]string{"ca"}))
+
+		updates, err = blockchainService.RemoveTagsBySelector(context.Background(), &blockchainv3.ComponentSelector{IDs: []string{"os1", "peer1"}}, "org1")
+		Expect(err).To(BeNil())
+		Expect(updates[0].Changed).To(BeTrue())
+		Expect(updates[1].Changed).To(BeFalse())
+
+		_, err = blockchainService.AddTagsBySelector(context.Background(), nil, "x")
+		Expect(err).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,189 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/IBM/go-sdk-core/v4/core"
+	"io/ioutil"
+	"net/http"
+)
+
+// Ranks of the transport layers. A layer with a lower rank always sits in front of (is called before) a layer with a
+// higher rank, regardless of the order in which they were enabled.
+const (
+	transportRankConsoleAuth = iota * 10
+	transportRankDryRun
+	transportRankCache
+	transportRankCassette
+)
+
+// transportLayer is implemented by the http.RoundTripper decorators that this package installs on a service client
+// (console auth errors, dry-run, response cache, record/replay). Layers are chained in front of the client's original
+// transport so that every generated operation passes through them without any change to the operation itself.
+//
+// Note: EnableRetries(), DisableRetries() and Service.DisableSSLVerification() replace the http.Client of the service
+// and therefore drop any installed layers. Call them before enabling a layer.
+type transportLayer interface {
+	http.RoundTripper
+
+	// rank orders the layer within the chain, see transport*Rank.
+	rank() int
+
+	// next returns the transport that this layer forwards requests to.
+	next() http.RoundTripper
+
+	// setNext replaces the transport that this layer forwards requests to. It is only called on layers that are not
+	// linked into a chain yet, see clone.
+	setNext(http.RoundTripper)
+
+	// clone returns a copy of the layer that shares its state (cached responses, recorded interactions, ...) but can be
+	// linked to another transport. Layers that are linked into a chain are never changed, because the chain may be used
+	// by clients cloned earlier, and by requests in flight.
+	clone() transportLayer
+}
+
+// addTransportLayer links layer into the service's transport chain, in front of the client's original transport and
+// positioned by rank among the layers already installed. The layers in front of the new one are copied and the
+// http.Client is copied, so that clients produced by Clone() before this call keep their own transport chain.
+func (blockchain *BlockchainV3) addTransportLayer(layer transportLayer) {
+	client := &http.Client{}
+	if blockchain.Service.Client != nil {
+		copied := *blockchain.Service.Client
+		client = &copied
+	}
+	if client.Transport == nil {
+		client.Transport = http.DefaultTransport
+	}
+
+	// skip the layers that must stay in front of the new one
+	var front []transportLayer
+	rt := client.Transport
+	for {
+		current, ok := rt.(transportLayer)
+		if !ok || current.rank() > layer.rank() {
+			break
+		}
+		front = append(front, current)
+		rt = current.next()
+	}
+
+	layer.setNext(rt)
+	client.Transport = chainTransportLayers(front, layer)
+	blockchain.Service.SetHTTPClient(client)
+}
+
+// findTransportLayer walks the transport chain and returns the first layer accepted by match, or nil.
+func (blockchain *BlockchainV3) findTransportLayer(match func(transportLayer) bool) transportLayer {
+	if blockchain.Service.Client == nil {
+		return nil
+	}
+	for rt := blockchain.Service.Client.Transport; rt != nil; {
+		layer, ok := rt.(transportLayer)
+		if !ok {
+			return nil
+		}
+		if match(layer) {
+			return layer
+		}
+		rt = layer.next()
+	}
+	return nil
+}
+
+// removeTransportLayer unlinks every layer accepted by match from the transport chain. It reports whether a layer was
+// removed. As in addTransportLayer, the layers in front of the removed ones are copied rather than relinked.
+func (blockchain *BlockchainV3) removeTransportLayer(match func(transportLayer) bool) (removed bool) {
+	if blockchain.Service.Client == nil {
+		return false
+	}
+	client := *blockchain.Service.Client
+	var kept, front []transportLayer
+	tail := client.Transport
+	for rt := client.Transport; rt != nil; {
+		layer, ok := rt.(transportLayer)
+		if !ok {
+			break
+		}
+		rt = layer.next()
+		if match(layer) {
+			removed = true
+			// the layers kept so far must be relinked to what follows the removed layer
+			front = append(front, kept...)
+			kept = nil
+			tail = rt
+		} else {
+			kept = append(kept, layer)
+		}
+	}
+	if removed {
+		client.Transport = chainTransportLayers(front, tail)
+		blockchain.Service.SetHTTPClient(&client)
+	}
+	return
+}
+
+// chainTransportLayers links copies of the layers, in order, in front of next.
+func chainTransportLayers(layers []transportLayer, next http.RoundTripper) http.RoundTripper {
+	for i := len(layers) - 1; i >= 0; i-- {
+		layer := layers[i].clone()
+		layer.setNext(next)
+		next = layer
+	}
+	return next
+}
+
+// readRequestBody reads the body of req and replaces it with an unread copy, so that the request can still be sent. It
+// returns the body as it will be sent and decoded from gzip when the request is compressed.
+func readRequestBody(req *http.Request) (raw []byte, decoded []byte, err error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+	raw, err = ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(raw))
+
+	decoded = raw
+	if req.Header.Get("Content-Encoding") == "gzip" && len(raw) > 0 {
+		var gzipReader *gzip.Reader
+		gzipReader, err = gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return
+		}
+		decoded, err = ioutil.ReadAll(gzipReader)
+	}
+	return
+}
+
+// skippingAuthenticator wraps the authenticator of a service and leaves the requests accepted by skip unauthenticated.
+// It is installed by layers that answer requests without sending them, so that no IAM token is fetched for them.
+type skippingAuthenticator struct {
+	core.Authenticator
+	skip func(req *http.Request) bool
+}
+
+// Authenticate authenticates req unless it is skipped.
+func (authenticator *skippingAuthenticator) Authenticate(req *http.Request) error {
+	if authenticator.skip(req) {
+		return nil
+	}
+	return authenticator.Authenticator.Authenticate(req)
+}
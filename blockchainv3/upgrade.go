@@ -0,0 +1,490 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of UpgradeOptions.
+const (
+	DefaultUpgradeHealthTimeout  = 15 * time.Minute
+	DefaultUpgradeHealthInterval = 10 * time.Second
+)
+
+// Constants associated with the UpgradeStep.Status property.
+const (
+	UpgradeStep_Status_Failed   = "failed"
+	UpgradeStep_Status_Pending  = "pending"
+	UpgradeStep_Status_Skipped  = "skipped"
+	UpgradeStep_Status_Upgraded = "upgraded"
+)
+
+// UpgradeOptions : The PlanUpgrade and Upgrade options.
+type UpgradeOptions struct {
+	// The peers and orderers to upgrade. Defaults to all of them.
+	Selector *ComponentSelector
+
+	// The version to upgrade to, keyed by ComponentType_FabricPeer or ComponentType_FabricOrderer. Each must be one of
+	// the versions returned by GetFabVersions. Defaults to the latest of them.
+	TargetVersions map[string]string
+
+	// How long to wait for a node to become healthy after its upgrade. Defaults to DefaultUpgradeHealthTimeout.
+	HealthTimeout *time.Duration
+
+	// Time between two health checks. Defaults to DefaultUpgradeHealthInterval.
+	HealthInterval *time.Duration
+
+	// Reports whether a node runs version and is healthy. Defaults to reading the node with the console cache skipped,
+	// which is healthy once it reports version and a status of "ok", or no status if the console has none.
+	HealthCheck func(ctx context.Context, id string, version string) (bool, error)
+
+	// Called before and after each node is upgraded.
+	OnStep func(step *UpgradeStep)
+}
+
+// NewUpgradeOptions : Instantiate UpgradeOptions
+func (*BlockchainV3) NewUpgradeOptions() *UpgradeOptions {
+	return &UpgradeOptions{}
+}
+
+// SetSelector : Allow user to set Selector
+func (options *UpgradeOptions) SetSelector(selector *ComponentSelector) *UpgradeOptions {
+	options.Selector = selector
+	return options
+}
+
+// SetTargetVersion : Allow user to set the target version of a component type
+func (options *UpgradeOptions) SetTargetVersion(componentType string, version string) *UpgradeOptions {
+	if options.TargetVersions == nil {
+		options.TargetVersions = map[string]string{}
+	}
+	options.TargetVersions[componentType] = version
+	return options
+}
+
+// SetHealthTimeout : Allow user to set HealthTimeout
+func (options *UpgradeOptions) SetHealthTimeout(healthTimeout time.Duration) *UpgradeOptions {
+	options.HealthTimeout = &healthTimeout
+	return options
+}
+
+// SetHealthInterval : Allow user to set HealthInterval
+func (options *UpgradeOptions) SetHealthInterval(healthInterval time.Duration) *UpgradeOptions {
+	options.HealthInterval = &healthInterval
+	return options
+}
+
+// SetHealthCheck : Allow user to set HealthCheck
+func (options *UpgradeOptions) SetHealthCheck(healthCheck func(ctx context.Context, id string, version string) (bool, error)) *UpgradeOptions {
+	options.HealthCheck = healthCheck
+	return options
+}
+
+// SetOnStep : Allow user to set OnStep
+func (options *UpgradeOptions) SetOnStep(onStep func(step *UpgradeStep)) *UpgradeOptions {
+	options.OnStep = onStep
+	return options
+}
+
+// UpgradeStep : The upgrade of one node.
+type UpgradeStep struct {
+	ID string
+
+	Type string
+
+	DisplayName string
+
+	// The ordering service of an orderer.
+	ClusterID string
+
+	FromVersion string
+
+	ToVersion string
+
+	// True when the upgrade crosses a major release, so the ledger databases of a peer are upgraded too.
+	UpgradeDbs bool
+
+	// One of UpgradeStep_Status_*.
+	Status string
+
+	// Why the step failed, or why it was skipped.
+	Error error
+
+	Started  time.Time
+	Finished time.Time
+}
+
+// UpgradeReport : The plan of an upgrade, or its outcome.
+type UpgradeReport struct {
+	// The version each component type is upgraded to.
+	TargetVersions map[string]string
+
+	// The nodes in the order they are upgraded. Orderers come first, one ordering service after the other, then peers.
+	Steps []*UpgradeStep
+
+	// The step that stopped the upgrade, if any. The steps after it are still pending.
+	Failed *UpgradeStep
+}
+
+// PlanUpgrade works out the target versions and the order of an upgrade without changing anything. Nodes that already
+// run the target version or a later one are skipped.
+func (blockchain *BlockchainV3) PlanUpgrade(ctx context.Context, options *UpgradeOptions) (report *UpgradeReport, err error) {
+	if options == nil {
+		options = blockchain.NewUpgradeOptions()
+	}
+	versions, _, err := blockchain.GetFabVersionsWithContext(ctx, blockchain.NewGetFabVersionsOptions().SetCache(GetFabVersionsOptions_Cache_Skip))
+	if err != nil {
+		return
+	}
+	if versions == nil || versions.Versions == nil {
+		err = fmt.Errorf("the console returned no Fabric versions")
+		return
+	}
+	report = &UpgradeReport{TargetVersions: map[string]string{}}
+	for componentType, dictionary := range map[string]*FabricVersionDictionary{
+		ComponentType_FabricPeer:    versions.Versions.Peer,
+		ComponentType_FabricOrderer: versions.Versions.Orderer,
+	} {
+		var target string
+		target, err = targetVersion(componentType, fabricVersions(dictionary), options.TargetVersions[componentType])
+		if err != nil {
+			return nil, err
+		}
+		if target != "" {
+			report.TargetVersions[componentType] = target
+		}
+	}
+	for componentType := range options.TargetVersions {
+		if componentType != ComponentType_FabricPeer && componentType != ComponentType_FabricOrderer {
+			return nil, fmt.Errorf("only peers and orderers are upgraded, not components of type %q", componentType)
+		}
+	}
+
+	components, _, err := blockchain.ListComponentsWithContext(ctx, blockchain.NewListComponentsOptions().SetCache(ListComponentsOptions_Cache_Skip))
+	if err != nil {
+		return nil, err
+	}
+	var orderers, peers []*UpgradeStep
+	for i := range components.Components {
+		component := &components.Components[i]
+		if component.ID == nil || component.Type == nil {
+			continue
+		}
+		if *component.Type != ComponentType_FabricPeer && *component.Type != ComponentType_FabricOrderer {
+			continue
+		}
+		if options.Selector != nil && !options.Selector.Matches(component) {
+			continue
+		}
+		step := &UpgradeStep{ID: *component.ID, Type: *component.Type, Status: UpgradeStep_Status_Pending}
+		if component.DisplayName != nil {
+			step.DisplayName = *component.DisplayName
+		}
+		if component.ClusterID != nil {
+			step.ClusterID = *component.ClusterID
+		}
+		if component.Version != nil {
+			step.FromVersion = *component.Version
+		}
+		step.ToVersion = report.TargetVersions[step.Type]
+		switch {
+		case step.ToVersion == "":
+			step.Status = UpgradeStep_Status_Skipped
+			step.Error = fmt.Errorf("the console supports no version of %s", step.Type)
+		case compareFabricVersions(step.FromVersion, step.ToVersion) >= 0:
+			step.Status = UpgradeStep_Status_Skipped
+		default:
+			step.UpgradeDbs = fabricMajorVersion(step.FromVersion) != fabricMajorVersion(step.ToVersion)
+		}
+		if step.Type == ComponentType_FabricOrderer {
+			orderers = append(orderers, step)
+		} else {
+			peers = append(peers, step)
+		}
+	}
+	// orderers of the same ordering service are upgraded in a row, and orderers before the peers that use them
+	sort.SliceStable(orderers, func(i, j int) bool {
+		return orderers[i].ClusterID < orderers[j].ClusterID
+	})
+	report.Steps = append(orderers, peers...)
+	return
+}
+
+// Upgrade upgrades peers and orderers to the versions of PlanUpgrade, one node at a time.
+//
+// Each node is updated with UpdatePeer or UpdateOrderer, and the next one is only started once it is healthy again.
+// Peers upgraded across a major release also get their ledger databases upgraded with PeerAction. An orderer is only
+// upgraded while every other node of its ordering service is healthy, so that at most one Raft node is down at any
+// time. The upgrade stops on the first failure; the report shows which nodes were upgraded and which are left.
+func (blockchain *BlockchainV3) Upgrade(ctx context.Context, options *UpgradeOptions) (report *UpgradeReport, err error) {
+	if options == nil {
+		options = blockchain.NewUpgradeOptions()
+	}
+	report, err = blockchain.PlanUpgrade(ctx, options)
+	if err != nil {
+		return
+	}
+	upgrade := &upgradeRun{
+		blockchain: blockchain,
+		options:    options,
+		timeout:    DefaultUpgradeHealthTimeout,
+		interval:   DefaultUpgradeHealthInterval,
+	}
+	if options.HealthTimeout != nil {
+		upgrade.timeout = *options.HealthTimeout
+	}
+	if options.HealthInterval != nil {
+		upgrade.interval = *options.HealthInterval
+	}
+	if upgrade.interval <= 0 {
+		return report, fmt.Errorf("health interval must be positive, not %s", upgrade.interval)
+	}
+	upgrade.healthCheck = options.HealthCheck
+	if upgrade.healthCheck == nil {
+		upgrade.healthCheck = blockchain.componentRunsVersion
+	}
+
+	for _, step := range report.Steps {
+		if step.Status != UpgradeStep_Status_Pending {
+			continue
+		}
+		step.Started = time.Now()
+		upgrade.notify(step)
+		step.Error = upgrade.run(ctx, step)
+		step.Finished = time.Now()
+		if step.Error != nil {
+			step.Status = UpgradeStep_Status_Failed
+			report.Failed = step
+			upgrade.notify(step)
+			return report, fmt.Errorf("upgrade stopped at %s %s: %s", step.Type, step.ID, step.Error.Error())
+		}
+		step.Status = UpgradeStep_Status_Upgraded
+		upgrade.notify(step)
+	}
+	return
+}
+
+// upgradeRun is the state of one call of Upgrade.
+type upgradeRun struct {
+	blockchain  *BlockchainV3
+	options     *UpgradeOptions
+	timeout     time.Duration
+	interval    time.Duration
+	healthCheck func(ctx context.Context, id string, version string) (bool, error)
+}
+
+func (upgrade *upgradeRun) notify(step *UpgradeStep) {
+	if upgrade.options.OnStep != nil {
+		upgrade.options.OnStep(step)
+	}
+}
+
+// run upgrades the node of step and waits until it is healthy.
+func (upgrade *upgradeRun) run(ctx context.Context, step *UpgradeStep) (err error) {
+	blockchain := upgrade.blockchain
+	switch step.Type {
+	case ComponentType_FabricOrderer:
+		err = upgrade.checkCluster(ctx, step)
+		if err != nil {
+			return
+		}
+		_, _, err = blockchain.UpdateOrdererWithContext(ctx, blockchain.NewUpdateOrdererOptions(step.ID).SetVersion(step.ToVersion))
+	case ComponentType_FabricPeer:
+		_, _, err = blockchain.UpdatePeerWithContext(ctx, blockchain.NewUpdatePeerOptions(step.ID).SetVersion(step.ToVersion))
+		if err == nil && step.UpgradeDbs {
+			_, _, err = blockchain.PeerActionWithContext(ctx, blockchain.NewPeerActionOptions(step.ID).SetUpgradeDbs(true))
+		}
+	}
+	if err != nil {
+		return
+	}
+	return upgrade.waitHealthy(ctx, step.ID, step.ToVersion)
+}
+
+// checkCluster makes sure the other orderers of the ordering service of step are healthy, so that upgrading step
+// takes down a single Raft node. The orderers are listed again, because the selector of the options may have left some
+// of them out of the report.
+func (upgrade *upgradeRun) checkCluster(ctx context.Context, step *UpgradeStep) error {
+	if step.ClusterID == "" {
+		return nil
+	}
+	components, _, err := upgrade.blockchain.ListComponentsWithContext(ctx, upgrade.blockchain.NewListComponentsOptions().SetCache(ListComponentsOptions_Cache_Skip))
+	if err != nil {
+		return err
+	}
+	for _, other := range components.Components {
+		if other.ID == nil || *other.ID == step.ID || other.Type == nil || *other.Type != ComponentType_FabricOrderer {
+			continue
+		}
+		if other.ClusterID == nil || *other.ClusterID != step.ClusterID {
+			continue
+		}
+		var version string
+		if other.Version != nil {
+			version = *other.Version
+		}
+		healthy, err := upgrade.healthCheck(ctx, *other.ID, version)
+		if err != nil {
+			return err
+		}
+		if !healthy {
+			return fmt.Errorf("orderer %s of ordering service %s is not healthy, upgrading %s could lose the Raft quorum", *other.ID, step.ClusterID, step.ID)
+		}
+	}
+	return nil
+}
+
+// waitHealthy checks the health of a node until it runs version and is healthy, or the health timeout expires.
+func (upgrade *upgradeRun) waitHealthy(ctx context.Context, id string, version string) error {
+	deadline := time.Now().Add(upgrade.timeout)
+	for {
+		healthy, err := upgrade.healthCheck(ctx, id, version)
+		if err != nil {
+			return err
+		}
+		if healthy {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("%s did not become healthy on version %s within %s", id, version, upgrade.timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(upgrade.interval):
+		}
+	}
+}
+
+// componentRunsVersion is the default UpgradeOptions.HealthCheck.
+func (blockchain *BlockchainV3) componentRunsVersion(ctx context.Context, id string, version string) (healthy bool, err error) {
+	rawComponent, err := blockchain.getRawComponent(ctx, id, map[string]string{"cache": GetComponentOptions_Cache_Skip})
+	if err != nil {
+		return
+	}
+	var component struct {
+		Version *string
+		Status  *string
+	}
+	for field, target := range map[string]**string{"version": &component.Version, "status": &component.Status} {
+		if raw, ok := rawComponent[field]; ok {
+			if json.Unmarshal(raw, target) != nil {
+				return false, fmt.Errorf("the %s of component %s is not a string", field, id)
+			}
+		}
+	}
+	if component.Version == nil || *component.Version != version {
+		return false, nil
+	}
+	return component.Status == nil || *component.Status == "ok", nil
+}
+
+// fabricVersions returns the versions of a FabricVersionDictionary, including those only in its additional
+// properties.
+func fabricVersions(dictionary *FabricVersionDictionary) (versions []string) {
+	if dictionary == nil {
+		return
+	}
+	add := func(key string, version *string) {
+		if version != nil && *version != "" {
+			key = *version
+		}
+		if !containsString(versions, key) {
+			versions = append(versions, key)
+		}
+	}
+	if dictionary.X1462 != nil {
+		add("1.4.6-2", dictionary.X1462.Version)
+	}
+	if dictionary.X2100 != nil {
+		add("2.1.0-0", dictionary.X2100.Version)
+	}
+	for key, value := range dictionary.GetProperties() {
+		var version *string
+		if object, ok := value.(map[string]interface{}); ok {
+			if v, ok := object["version"].(string); ok {
+				version = &v
+			}
+		}
+		add(key, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareFabricVersions(versions[i], versions[j]) < 0
+	})
+	return
+}
+
+// targetVersion returns wanted if it is supported, or else the latest supported version.
+func targetVersion(componentType string, supported []string, wanted string) (string, error) {
+	if wanted == "" {
+		if len(supported) == 0 {
+			return "", nil
+		}
+		return supported[len(supported)-1], nil
+	}
+	if !containsString(supported, wanted) {
+		return "", fmt.Errorf("version %s of %s is not supported by the console, use one of %s", wanted, componentType, strings.Join(supported, ", "))
+	}
+	return wanted, nil
+}
+
+// fabricVersionNumbers splits a version such as 2.2.1-3 into its numbers. Parts that are not numbers count as 0.
+func fabricVersionNumbers(version string) []int {
+	parts := strings.FieldsFunc(strings.TrimPrefix(version, "v"), func(r rune) bool {
+		return r == '.' || r == '-'
+	})
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		numbers[i], _ = strconv.Atoi(part)
+	}
+	return numbers
+}
+
+func compareFabricVersions(a string, b string) int {
+	x, y := fabricVersionNumbers(a), fabricVersionNumbers(b)
+	for i := 0; i < len(x) || i < len(y); i++ {
+		var m, n int
+		if i < len(x) {
+			m = x[i]
+		}
+		if i < len(y) {
+			n = y[i]
+		}
+		if m != n {
+			if m < n {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func fabricMajorVersion(version string) int {
+	numbers := fabricVersionNumbers(version)
+	if len(numbers) == 0 {
+		return 0
+	}
+	return numbers[0]
+}
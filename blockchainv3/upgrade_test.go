@@ -0,0 +1,202 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blockchainv3_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM/go-sdk-core/v4/core"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ = Describe(`BlockchainV3 rolling upgrade`, func() {
+	var testServer *httptest.Server
+	var blockchainService *blockchainv3.BlockchainV3
+	var mutex sync.Mutex
+	var components map[string]map[string]interface{}
+	var order []string
+	var calls []string
+	// components that report an unhealthy status
+	var unhealthy map[string]bool
+	// components that ignore version updates
+	var stuck map[string]bool
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		components = map[string]map[string]interface{}{}
+		order = nil
+		calls = nil
+		unhealthy = map[string]bool{}
+		stuck = map[string]bool{}
+		for _, c := range []struct{ id, kind, cluster, version string }{
+			{"peer1", "fabric-peer", "", "1.4.9-0"},
+			{"os1", "fabric-orderer", "c1", "1.4.9-0"},
+			{"peer2", "fabric-peer", "", "2.2.1-1"},
+			{"os2", "fabric-orderer", "c1", "1.4.9-0"},
+			{"ca1", "fabric-ca", "", "1.4.9-0"},
+		} {
+			components[c.id] = map[string]interface{}{"id": c.id, "type": c.kind, "cluster_id": c.cluster, "version": c.version}
+			order = append(order, c.id)
+		}
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			mutex.Lock()
+			defer mutex.Unlock()
+			res.Header().Set("Content-type", "application/json")
+			op, ok := blockchainv3.LookupOperation(req.Method, req.URL.Path)
+			Expect(ok).To(BeTrue())
+			id := strings.TrimSuffix(req.URL.Path, "/actions")
+			id = id[strings.LastIndex(id, "/")+1:]
+			switch op.Name {
+			case "GetFabVersions":
+				versions := `{"1.4.6-2": {"version": "1.4.6-2"}, "2.2.1-1": {"version": "2.2.1-1", "default": true}}`
+				fmt.Fprintf(res, `{"versions": {"peer": %s, "orderer": %s}}`, versions, versions)
+			case "ListComponents":
+				Expect(req.URL.Query().Get("cache")).To(Equal("skip"))
+				var list []interface{}
+				for _, id := range order {
+					list = append(list, components[id])
+				}
+				json.NewEncoder(res).Encode(map[string]interface{}{"components": list})
+			case "GetComponent":
+				Expect(req.URL.Query().Get("cache")).To(Equal("skip"))
+				component := map[string]interface{}{"status": "ok"}
+				for key, value := range components[id] {
+					component[key] = value
+				}
+				if unhealthy[id] {
+					component["status"] = "unknown"
+				}
+				json.NewEncoder(res).Encode(component)
+			default:
+				body, _ := ioutil.ReadAll(req.Body)
+				calls = append(calls, strings.TrimSpace(fmt.Sprintf("%s %s %s", op.Name, id, body)))
+				var update map[string]interface{}
+				json.Unmarshal(body, &update)
+				if version, ok := update["version"]; ok && !stuck[id] {
+					components[id]["version"] = version
+				}
+				fmt.Fprintf(res, `{"message": "ok", "id": "%s"}`, id)
+			}
+		}))
+		var serviceErr error
+		blockchainService, serviceErr = blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(serviceErr).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	options := func() *blockchainv3.UpgradeOptions {
+		return blockchainService.NewUpgradeOptions().SetHealthInterval(time.Millisecond).SetHealthTimeout(50 * time.Millisecond)
+	}
+	stepIDs := func(report *blockchainv3.UpgradeReport, status string) (ids []string) {
+		for _, step := range report.Steps {
+			if step.Status == status {
+				ids = append(ids, step.ID)
+			}
+		}
+		return
+	}
+
+	It(`Plans orderers first and skips up to date nodes`, func() {
+		report, err := blockchainService.PlanUpgrade(ctx, nil)
+		Expect(err).To(BeNil())
+		Expect(report.TargetVersions).To(Equal(map[string]string{"fabric-peer": "2.2.1-1", "fabric-orderer": "2.2.1-1"}))
+		Expect(stepIDs(report, blockchainv3.UpgradeStep_Status_Pending)).To(Equal([]string{"os1", "os2", "peer1"}))
+		Expect(stepIDs(report, blockchainv3.UpgradeStep_Status_Skipped)).To(Equal([]string{"peer2"}))
+		Expect(report.Steps[2].UpgradeDbs).To(BeTrue())
+		Expect(calls).To(BeEmpty())
+
+		report, err = blockchainService.PlanUpgrade(ctx, blockchainService.NewUpgradeOptions().
+			SetTargetVersion(blockchainv3.ComponentType_FabricPeer, "1.4.6-2").
+			SetSelector(&blockchainv3.ComponentSelector{Types: []string{"fabric-peer"}}))
+		Expect(err).To(BeNil())
+		Expect(stepIDs(report, blockchainv3.UpgradeStep_Status_Skipped)).To(Equal([]string{"peer1", "peer2"}))
+
+		_, err = blockchainService.PlanUpgrade(ctx, blockchainService.NewUpgradeOptions().SetTargetVersion(blockchainv3.ComponentType_FabricPeer, "2.5.0"))
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("version 2.5.0 of fabric-peer is not supported by the console, use one of 1.4.6-2, 2.2.1-1"))
+	})
+	It(`Upgrades one node at a time`, func() {
+		var events []string
+		report, err := blockchainService.Upgrade(ctx, options().SetOnStep(func(step *blockchainv3.UpgradeStep) {
+			events = append(events, step.ID+" "+step.Status)
+		}))
+		Expect(err).To(BeNil())
+		Expect(calls).To(Equal([]string{
+			`UpdateOrderer os1 {"version":"2.2.1-1"}`,
+			`UpdateOrderer os2 {"version":"2.2.1-1"}`,
+			`UpdatePeer peer1 {"version":"2.2.1-1"}`,
+			`PeerAction peer1 {"upgrade_dbs":true}`,
+		}))
+		Expect(events).To(Equal([]string{"os1 pending", "os1 upgraded", "os2 pending", "os2 upgraded", "peer1 pending", "peer1 upgraded"}))
+		Expect(report.Failed).To(BeNil())
+		Expect(components["peer1"]["version"]).To(Equal("2.2.1-1"))
+	})
+	It(`Does not take down a second Raft node`, func() {
+		unhealthy["os2"] = true
+		report, err := blockchainService.Upgrade(ctx, options())
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("upgrade stopped at fabric-orderer os1: orderer os2 of ordering service c1 is not healthy, upgrading os1 could lose the Raft quorum"))
+		Expect(report.Failed.ID).To(Equal("os1"))
+		Expect(stepIDs(report, blockchainv3.UpgradeStep_Status_Pending)).To(Equal([]string{"os2", "peer1"}))
+		Expect(calls).To(BeEmpty())
+	})
+	It(`Checks the orderers left out by the selector`, func() {
+		unhealthy["os2"] = true
+		report, err := blockchainService.Upgrade(ctx, options().SetSelector(&blockchainv3.ComponentSelector{IDs: []string{"os1"}}))
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("upgrade stopped at fabric-orderer os1: orderer os2 of ordering service c1 is not healthy, upgrading os1 could lose the Raft quorum"))
+		Expect(report.Steps).To(HaveLen(1))
+		Expect(calls).To(BeEmpty())
+	})
+	It(`Stops when a node does not become healthy`, func() {
+		stuck["os2"] = true
+		report, err := blockchainService.Upgrade(ctx, options())
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("os2 did not become healthy on version 2.2.1-1 within 50ms"))
+		Expect(stepIDs(report, blockchainv3.UpgradeStep_Status_Upgraded)).To(Equal([]string{"os1"}))
+		Expect(report.Failed.ID).To(Equal("os2"))
+		Expect(stepIDs(report, blockchainv3.UpgradeStep_Status_Pending)).To(Equal([]string{"peer1"}))
+		Expect(calls).To(HaveLen(2))
+	})
+	It(`Uses a custom health check`, func() {
+		var checked []string
+		_, err := blockchainService.Upgrade(ctx, options().
+			SetSelector(&blockchainv3.ComponentSelector{IDs: []string{"peer1"}}).
+			SetHealthCheck(func(ctx context.Context, id string, version string) (bool, error) {
+				checked = append(checked, id+" "+version)
+				return false, fmt.Errorf("no metrics")
+			}))
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("upgrade stopped at fabric-peer peer1: no metrics"))
+		Expect(checked).To(Equal([]string{"peer1 2.2.1-1"}))
+	})
+})
@@ -0,0 +1,77 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Command ibp-drift compares the OpenAPI document of an IBP console with the operations and models of the blockchainv3
+// package and reports what the SDK cannot reach yet.
+//
+// The console and its credentials are read from the external configuration of the SDK, e.g. the environment variables
+// BLOCKCHAIN_URL, BLOCKCHAIN_AUTH_TYPE and BLOCKCHAIN_APIKEY. Use -spec to compare a saved document instead.
+//
+// The exit code is 0 when no drift is found, 1 when drift is found and 2 on errors.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"io/ioutil"
+	"os"
+)
+
+func main() {
+	serviceName := flag.String("service", blockchainv3.DefaultServiceName, "name of the service in the external configuration")
+	url := flag.String("url", "", "console URL, overrides the external configuration")
+	specFile := flag.String("spec", "", "compare this OpenAPI file instead of fetching the console's document")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	report, err := detect(*serviceName, *url, *specFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ibp-drift:", err)
+		os.Exit(2)
+	}
+
+	if *asJSON {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		fmt.Print(report.String())
+	}
+	if report.HasDrift() {
+		os.Exit(1)
+	}
+}
+
+func detect(serviceName, url, specFile string) (*blockchainv3.DriftReport, error) {
+	if specFile != "" {
+		spec, err := ioutil.ReadFile(specFile)
+		if err != nil {
+			return nil, err
+		}
+		return blockchainv3.CompareOpenAPI(spec)
+	}
+
+	service, err := blockchainv3.NewBlockchainV3UsingExternalConfig(&blockchainv3.BlockchainV3Options{
+		ServiceName: serviceName,
+		URL:         url,
+	})
+	if err != nil {
+		return nil, err
+	}
+	report, _, err := service.DetectDrift()
+	return report, err
+}
@@ -98,11 +98,16 @@ var _ = AfterSuite(func() {
 	// Cleanup
 	//----------------------------------------------------------------------------------------------
 	it.Logger.Println("finally, delete any existing components in the cluster")
-	err := deleteAllComponents(service)
-	Expect(err).NotTo(HaveOccurred())
-	err = deleteLocallyCreatedFiles()
-	Expect(err).NotTo(HaveOccurred())
-	if err == nil {
+	deleteErr := deleteAllComponents(service)
+	filesErr := deleteLocallyCreatedFiles()
+	var cassetteErr error
+	if os.Getenv("IBP_CASSETTE_MODE") != "" {
+		cassetteErr = service.EjectCassette()
+	}
+	Expect(deleteErr).NotTo(HaveOccurred())
+	Expect(filesErr).NotTo(HaveOccurred())
+	Expect(cassetteErr).NotTo(HaveOccurred())
+	if deleteErr == nil && filesErr == nil && cassetteErr == nil {
 		it.Logger.Println("**SUCCESS** - test completed")
 	} else {
 		it.Logger.Println("***UNSUCCESSFUL*** one or more errors occurred")
@@ -419,6 +424,21 @@ func createAService(s setupInformation) (*blockchainv3.BlockchainV3, error) {
 		return nil, err
 	}
 	it.Logger.Println("**SUCCESS** - service created")
+
+	// optionally record the requests of the run to the console into a cassette. Only recording is supported: the Fabric
+	// CA enrollments and the polling of new CAs do not go through the service client, so a run cannot be replayed.
+	if mode := os.Getenv("IBP_CASSETTE_MODE"); mode != "" {
+		if mode != blockchainv3.CassetteOptions_Mode_Record {
+			it.Logger.Println("**ERROR** - IBP_CASSETTE_MODE must be " + blockchainv3.CassetteOptions_Mode_Record)
+			return nil, errors.New("cassette mode " + mode + " is not supported by the integration test")
+		}
+		it.Logger.Println("recording the run into cassette " + os.Getenv("IBP_CASSETTE_FILE"))
+		err = service.UseCassette(service.NewCassetteOptions(os.Getenv("IBP_CASSETTE_FILE"), mode))
+		if err != nil {
+			it.Logger.Println("**ERROR** - problem using the cassette")
+			return nil, err
+		}
+	}
 	return service, nil
 }
 
@@ -0,0 +1,356 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"strings"
+	"sync"
+)
+
+// The parameters of the argon2id key derivation of new encrypted identities. Encrypted identities keep the
+// parameters they were encrypted with.
+const (
+	EncryptedStoreKdfTime    = 3
+	EncryptedStoreKdfMemory  = 64 * 1024
+	EncryptedStoreKdfThreads = 4
+)
+
+// The limits of the argon2id parameters of an encrypted identity, so that the parameters of a tampered identity cannot
+// make the key derivation take hours or exhaust the memory. The memory is in KiB.
+const (
+	encryptedIdentityKdfMaxTime    = 10
+	encryptedIdentityKdfMaxMemory  = 1024 * 1024
+	encryptedIdentityKdfMaxThreads = 16
+)
+
+// encryptedIdentityVersion is the version of the format of encrypted identities.
+const encryptedIdentityVersion = 1
+
+// encryptedIdentity is an identity encrypted with AES-256-GCM under a key derived from a passphrase with argon2id.
+type encryptedIdentity struct {
+	Version int `json:"version"`
+
+	Kdf encryptedIdentityKdf `json:"kdf"`
+
+	// A MAC of a constant under the key, to tell wrong passphrases from tampered ciphertexts. The key derivation
+	// parameters cannot be authenticated without the passphrase, so a tampered salt, parameters or check cannot be
+	// told from a wrong passphrase.
+	Check []byte `json:"check"`
+
+	Nonce []byte `json:"nonce"`
+
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// encryptedIdentityKdf are the parameters of the key derivation of an encrypted identity.
+type encryptedIdentityKdf struct {
+	Name    string `json:"name"`
+	Salt    []byte `json:"salt"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+}
+
+// EncryptedStore : A Store that encrypts identities before it stores them in another store, with authenticated
+// encryption under a key derived from a passphrase. Identities are bound to their labels, so identities that are
+// changed, or moved to other labels, fail to decrypt.
+type EncryptedStore struct {
+	store Store
+
+	mutex sync.Mutex
+
+	passphrase []byte
+
+	// the key derivation of new identities and its key
+	kdf encryptedIdentityKdf
+	key []byte
+
+	// the keys of the passphrase by key derivation, as identities have their own salt
+	keys map[string][]byte
+}
+
+// NewEncryptedStore : Instantiate EncryptedStore that keeps the identities it encrypts with passphrase in store
+func NewEncryptedStore(store Store, passphrase []byte) (*EncryptedStore, error) {
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("the passphrase of an encrypted wallet cannot be empty")
+	}
+	encrypted := &EncryptedStore{store: store, keys: map[string][]byte{}}
+	err := encrypted.setPassphrase(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return encrypted, nil
+}
+
+// NewEncryptedFileSystem : Instantiate Wallet that keeps its identities in files of a directory, encrypted with
+// passphrase
+func NewEncryptedFileSystem(dir string, passphrase []byte) (*Wallet, error) {
+	store, err := NewFileStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := NewEncryptedStore(store, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return New(encrypted), nil
+}
+
+// setPassphrase makes passphrase the passphrase of new identities, with a new salt.
+func (store *EncryptedStore) setPassphrase(passphrase []byte) error {
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return err
+	}
+	store.passphrase = append([]byte{}, passphrase...)
+	store.kdf = encryptedIdentityKdf{
+		Name:    "argon2id",
+		Salt:    salt,
+		Time:    EncryptedStoreKdfTime,
+		Memory:  EncryptedStoreKdfMemory,
+		Threads: EncryptedStoreKdfThreads,
+	}
+	store.keys = map[string][]byte{}
+	store.key, err = store.deriveKey(passphrase, store.kdf)
+	return err
+}
+
+// deriveKey returns the key of passphrase, caching the keys of the store's passphrase.
+func (store *EncryptedStore) deriveKey(passphrase []byte, kdf encryptedIdentityKdf) ([]byte, error) {
+	if kdf.Name != "argon2id" {
+		return nil, fmt.Errorf("unsupported key derivation %q", kdf.Name)
+	}
+	if kdf.Time == 0 || kdf.Memory == 0 || kdf.Threads == 0 || len(kdf.Salt) == 0 {
+		return nil, fmt.Errorf("invalid key derivation parameters")
+	}
+	if kdf.Time > encryptedIdentityKdfMaxTime || kdf.Memory > encryptedIdentityKdfMaxMemory || kdf.Threads > encryptedIdentityKdfMaxThreads {
+		return nil, fmt.Errorf("the key derivation parameters (time %d, memory %d KiB, threads %d) exceed the limits and have been tampered with", kdf.Time, kdf.Memory, kdf.Threads)
+	}
+	cacheKey := fmt.Sprintf("%x %d %d %d", kdf.Salt, kdf.Time, kdf.Memory, kdf.Threads)
+	cache := hmac.Equal(passphrase, store.passphrase)
+	if key := store.keys[cacheKey]; key != nil && cache {
+		return key, nil
+	}
+	key := argon2.IDKey(passphrase, kdf.Salt, kdf.Time, kdf.Memory, kdf.Threads, 32)
+	if cache {
+		store.keys[cacheKey] = key
+	}
+	return key, nil
+}
+
+// keyCheck returns the check of a key.
+func keyCheck(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("ibp-go-sdk wallet key check"))
+	return mac.Sum(nil)
+}
+
+// additionalData returns what the encryption of an identity authenticates besides the identity: its label and the
+// parameters of its encryption.
+func additionalData(label string, encrypted *encryptedIdentity) []byte {
+	kdf, _ := json.Marshal(encrypted.Kdf)
+	return []byte(fmt.Sprintf("%d\x00%s\x00%s", encrypted.Version, label, kdf))
+}
+
+// encrypt encrypts the data of a label with the passphrase of new identities.
+func (store *EncryptedStore) encrypt(label string, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(store.key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	encrypted := &encryptedIdentity{
+		Version: encryptedIdentityVersion,
+		Kdf:     store.kdf,
+		Check:   keyCheck(store.key),
+		Nonce:   make([]byte, aead.NonceSize()),
+	}
+	_, err = rand.Read(encrypted.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	encrypted.Ciphertext = aead.Seal(nil, encrypted.Nonce, data, additionalData(label, encrypted))
+	return json.Marshal(encrypted)
+}
+
+// wrongPassphraseError is the error of decrypting an identity with a passphrase it was not encrypted with, or an
+// identity whose key derivation parameters or check have been tampered with, see encryptedIdentity.Check.
+type wrongPassphraseError struct {
+	label string
+}
+
+func (err *wrongPassphraseError) Error() string {
+	return fmt.Sprintf("wrong passphrase for identity %s, or its key derivation parameters have been tampered with", err.label)
+}
+
+// decrypt decrypts the stored data of a label with passphrase.
+func (store *EncryptedStore) decrypt(label string, stored []byte, passphrase []byte) ([]byte, error) {
+	var encrypted encryptedIdentity
+	if err := json.Unmarshal(stored, &encrypted); err != nil || encrypted.Version == 0 || encrypted.Ciphertext == nil {
+		var plaintext sdkIdentity
+		if json.Unmarshal(stored, &plaintext) == nil && plaintext.Credentials.PrivateKey != "" {
+			return nil, fmt.Errorf("identity %s is not encrypted", label)
+		}
+		return nil, fmt.Errorf("identity %s has been tampered with or is not an encrypted identity", label)
+	}
+	if encrypted.Version != encryptedIdentityVersion {
+		return nil, fmt.Errorf("identity %s is encrypted in version %d of the format, which is not supported", label, encrypted.Version)
+	}
+	key, err := store.deriveKey(passphrase, encrypted.Kdf)
+	if err != nil {
+		return nil, fmt.Errorf("identity %s: %s", label, err.Error())
+	}
+	if !hmac.Equal(keyCheck(key), encrypted.Check) {
+		return nil, &wrongPassphraseError{label: label}
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("identity %s has been tampered with", label)
+	}
+	data, err := aead.Open(nil, encrypted.Nonce, encrypted.Ciphertext, additionalData(label, &encrypted))
+	if err != nil {
+		return nil, fmt.Errorf("identity %s has been tampered with", label)
+	}
+	return data, nil
+}
+
+// Put encrypts data and stores it.
+func (store *EncryptedStore) Put(label string, data []byte) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	encrypted, err := store.encrypt(label, data)
+	if err != nil {
+		return err
+	}
+	return store.store.Put(label, encrypted)
+}
+
+// Get decrypts the data of a label. It fails if the passphrase is wrong or the stored data has been tampered with.
+func (store *EncryptedStore) Get(label string) ([]byte, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	stored, err := store.store.Get(label)
+	if err != nil || stored == nil {
+		return nil, err
+	}
+	return store.decrypt(label, stored, store.passphrase)
+}
+
+// List returns the labels of the store.
+func (store *EncryptedStore) List() ([]string, error) {
+	return store.store.List()
+}
+
+// Remove removes a label.
+func (store *EncryptedStore) Remove(label string) error {
+	return store.store.Remove(label)
+}
+
+// RotatePassphrase : Encrypt every identity with a new passphrase
+// Every identity is decrypted before any is encrypted again, so the rotation fails without changes if an identity
+// cannot be decrypted. If storing an identity fails, the identities stored before are restored. Identities already
+// encrypted with newPassphrase are left as they are, so a rotation that was interrupted can be run again.
+func (store *EncryptedStore) RotatePassphrase(newPassphrase []byte) error {
+	if len(newPassphrase) == 0 {
+		return fmt.Errorf("the passphrase of an encrypted wallet cannot be empty")
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	labels, err := store.store.List()
+	if err != nil {
+		return err
+	}
+	type rotation struct {
+		label  string
+		stored []byte
+		data   []byte
+	}
+	var rotations []*rotation
+	for _, label := range labels {
+		stored, err := store.store.Get(label)
+		if err != nil {
+			return err
+		}
+		if stored == nil {
+			continue
+		}
+		data, err := store.decrypt(label, stored, store.passphrase)
+		// the identity may have been rotated already, otherwise the error stands, as it may also be a tampered identity
+		if _, wrongPassphrase := err.(*wrongPassphraseError); wrongPassphrase {
+			if _, rotatedErr := store.decrypt(label, stored, newPassphrase); rotatedErr == nil {
+				continue
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("rotating the passphrase failed without changes: %s", err.Error())
+		}
+		rotations = append(rotations, &rotation{label: label, stored: stored, data: data})
+	}
+
+	previous := struct {
+		passphrase []byte
+		kdf        encryptedIdentityKdf
+		key        []byte
+		keys       map[string][]byte
+	}{store.passphrase, store.kdf, store.key, store.keys}
+	err = store.setPassphrase(newPassphrase)
+	if err != nil {
+		return err
+	}
+	for i, rotation := range rotations {
+		encrypted, err := store.encrypt(rotation.label, rotation.data)
+		if err == nil {
+			err = store.store.Put(rotation.label, encrypted)
+		}
+		if err == nil {
+			continue
+		}
+		// restore the identities encrypted with the new passphrase
+		var failed []string
+		for _, rotated := range rotations[:i] {
+			if restoreErr := store.store.Put(rotated.label, rotated.stored); restoreErr != nil {
+				failed = append(failed, rotated.label)
+			}
+		}
+		store.passphrase, store.kdf, store.key, store.keys = previous.passphrase, previous.kdf, previous.key, previous.keys
+		if len(failed) > 0 {
+			return fmt.Errorf("rotating the passphrase failed on %s: %s; identities %s keep the new passphrase",
+				rotation.label, err.Error(), strings.Join(failed, ", "))
+		}
+		return fmt.Errorf("rotating the passphrase failed on %s: %s; all identities keep the old passphrase", rotation.label, err.Error())
+	}
+	return nil
+}
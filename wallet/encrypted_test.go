@@ -0,0 +1,198 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wallet_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/wallet"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// failingStore is a store that fails to store a label.
+type failingStore struct {
+	wallet.Store
+	failOn string
+}
+
+func (store *failingStore) Put(label string, data []byte) error {
+	if label == store.failOn {
+		return fmt.Errorf("disk full")
+	}
+	return store.Store.Put(label, data)
+}
+
+var _ = Describe(`Encrypted wallets`, func() {
+	var dir string
+	var admin, user *wallet.Identity
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "wallet")
+		Expect(err).To(BeNil())
+		cert, key := testEnrollment("admin", false)
+		admin, err = wallet.NewIdentity("admin", "org1", cert, key)
+		Expect(err).To(BeNil())
+		cert, key = testEnrollment("user", true)
+		user, err = wallet.NewIdentity("user", "org1", cert, key)
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It(`Keeps no plaintext key material`, func() {
+		identities, err := wallet.NewEncryptedFileSystem(dir, []byte("correct horse"))
+		Expect(err).To(BeNil())
+		Expect(identities.Put(admin)).To(BeNil())
+		data, err := ioutil.ReadFile(filepath.Join(dir, "admin.id"))
+		Expect(err).To(BeNil())
+		Expect(string(data)).ToNot(ContainSubstring("BEGIN"))
+		Expect(string(data)).ToNot(ContainSubstring("org1"))
+
+		reopened, err := wallet.NewEncryptedFileSystem(dir, []byte("correct horse"))
+		Expect(err).To(BeNil())
+		read, err := reopened.Get("admin")
+		Expect(err).To(BeNil())
+		Expect(read).To(Equal(admin))
+
+		_, err = wallet.NewEncryptedFileSystem(dir, nil)
+		Expect(err).ToNot(BeNil())
+	})
+	It(`Tells wrong passphrases from tampering`, func() {
+		identities, err := wallet.NewEncryptedFileSystem(dir, []byte("correct horse"))
+		Expect(err).To(BeNil())
+		Expect(identities.Put(admin)).To(BeNil())
+
+		wrong, err := wallet.NewEncryptedFileSystem(dir, []byte("battery staple"))
+		Expect(err).To(BeNil())
+		_, err = wrong.Get("admin")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("wrong passphrase for identity admin, or its key derivation parameters have been tampered with"))
+
+		file := filepath.Join(dir, "admin.id")
+		data, err := ioutil.ReadFile(file)
+		Expect(err).To(BeNil())
+		var encrypted map[string]interface{}
+		Expect(json.Unmarshal(data, &encrypted)).To(BeNil())
+		ciphertext, err := base64.StdEncoding.DecodeString(encrypted["ciphertext"].(string))
+		Expect(err).To(BeNil())
+		ciphertext[10] ^= 1
+		encrypted["ciphertext"] = base64.StdEncoding.EncodeToString(ciphertext)
+		tampered, err := json.Marshal(encrypted)
+		Expect(err).To(BeNil())
+		Expect(ioutil.WriteFile(file, tampered, 0600)).To(BeNil())
+		_, err = identities.Get("admin")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("identity admin has been tampered with"))
+
+		// the key derivation parameters are bounded
+		Expect(json.Unmarshal(data, &encrypted)).To(BeNil())
+		encrypted["kdf"].(map[string]interface{})["memory"] = 4 * 1024 * 1024
+		tampered, err = json.Marshal(encrypted)
+		Expect(err).To(BeNil())
+		Expect(ioutil.WriteFile(file, tampered, 0600)).To(BeNil())
+		_, err = identities.Get("admin")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("identity admin: the key derivation parameters (time 3, memory 4194304 KiB, threads 4) exceed the limits and have been tampered with"))
+
+		// identities are bound to their labels
+		Expect(ioutil.WriteFile(file, data, 0600)).To(BeNil())
+		Expect(os.Rename(file, filepath.Join(dir, "user.id"))).To(BeNil())
+		_, err = identities.Get("user")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("identity user has been tampered with"))
+
+		plaintext, err := wallet.MarshalSDKIdentity(admin)
+		Expect(err).To(BeNil())
+		Expect(ioutil.WriteFile(file, plaintext, 0600)).To(BeNil())
+		_, err = identities.Get("admin")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("identity admin is not encrypted"))
+	})
+	It(`Rotates the passphrase`, func() {
+		store, err := wallet.NewFileStore(dir)
+		Expect(err).To(BeNil())
+		encrypted, err := wallet.NewEncryptedStore(store, []byte("old"))
+		Expect(err).To(BeNil())
+		identities := wallet.New(encrypted)
+		Expect(identities.Put(admin)).To(BeNil())
+		Expect(identities.Put(user)).To(BeNil())
+
+		Expect(encrypted.RotatePassphrase([]byte("new"))).To(BeNil())
+		read, err := identities.Get("user")
+		Expect(err).To(BeNil())
+		Expect(read).To(Equal(user))
+		reopened, err := wallet.NewEncryptedFileSystem(dir, []byte("new"))
+		Expect(err).To(BeNil())
+		all, err := reopened.ListByMspID("org1")
+		Expect(err).To(BeNil())
+		Expect(all).To(Equal([]*wallet.Identity{admin, user}))
+		old, err := wallet.NewEncryptedFileSystem(dir, []byte("old"))
+		Expect(err).To(BeNil())
+		_, err = old.Get("admin")
+		Expect(err).ToNot(BeNil())
+	})
+	It(`Restores the identities if the rotation fails`, func() {
+		store := &failingStore{Store: wallet.NewInMemoryStore()}
+		encrypted, err := wallet.NewEncryptedStore(store, []byte("old"))
+		Expect(err).To(BeNil())
+		identities := wallet.New(encrypted)
+		Expect(identities.Put(admin)).To(BeNil())
+		Expect(identities.Put(user)).To(BeNil())
+
+		store.failOn = "user"
+		err = encrypted.RotatePassphrase([]byte("new"))
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("rotating the passphrase failed on user: disk full; all identities keep the old passphrase"))
+		reopened, err := wallet.NewEncryptedStore(store, []byte("old"))
+		Expect(err).To(BeNil())
+		all, err := wallet.New(reopened).ListByMspID("org1")
+		Expect(err).To(BeNil())
+		Expect(all).To(HaveLen(2))
+		// the store keeps working with the old passphrase
+		Expect(identities.Put(admin)).To(BeNil())
+		_, err = wallet.New(reopened).Get("admin")
+		Expect(err).To(BeNil())
+	})
+	It(`Resumes interrupted rotations`, func() {
+		store := wallet.NewInMemoryStore()
+		encrypted, err := wallet.NewEncryptedStore(store, []byte("old"))
+		Expect(err).To(BeNil())
+		Expect(wallet.New(encrypted).Put(admin)).To(BeNil())
+		Expect(wallet.New(encrypted).Put(user)).To(BeNil())
+		// admin was encrypted with the new passphrase before the rotation was interrupted
+		rotated, err := wallet.NewEncryptedStore(store, []byte("new"))
+		Expect(err).To(BeNil())
+		Expect(wallet.New(rotated).Put(admin)).To(BeNil())
+
+		Expect(encrypted.RotatePassphrase([]byte("new"))).To(BeNil())
+		all, err := wallet.New(rotated).ListByMspID("org1")
+		Expect(err).To(BeNil())
+		Expect(all).To(HaveLen(2))
+
+		other, err := wallet.NewEncryptedStore(store, []byte("other"))
+		Expect(err).To(BeNil())
+		err = other.RotatePassphrase([]byte("newer"))
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("rotating the passphrase failed without changes: wrong passphrase for identity admin, or its key derivation parameters have been tampered with"))
+	})
+})
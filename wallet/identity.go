@@ -0,0 +1,191 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wallet
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/hyperledger/fabric-ca/lib"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// Identity : An enrolled identity: the certificate and private key of an enrollment and the MSP it belongs to.
+type Identity struct {
+	// The name of the identity in its wallet.
+	Label string
+
+	// The MSP id of the organization the identity belongs to.
+	MspID string
+
+	// The PEM certificate.
+	Certificate []byte
+
+	// The PEM private key of the certificate.
+	PrivateKey []byte
+}
+
+// NewIdentity : Instantiate Identity
+// The private key must be the key of the certificate. Certificates and keys are PEM, or base 64 encoded PEM like the
+// console keeps them.
+func NewIdentity(label string, mspID string, certificate []byte, privateKey []byte) (identity *Identity, err error) {
+	identity = &Identity{
+		Label:       label,
+		MspID:       mspID,
+		Certificate: decodePem(certificate),
+		PrivateKey:  decodePem(privateKey),
+	}
+	err = identity.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// NewIdentityFromEnrollment : Instantiate Identity from a fabric-ca enrollment
+// The private key of the enrollment is read from keystore, the keystore directory of the MSP directory of the
+// fabric-ca client that enrolled, where the client keeps the keys it generates. The identity is labelled with the
+// enrollment id.
+func NewIdentityFromEnrollment(enrollment *lib.Identity, mspID string, keystore string) (*Identity, error) {
+	signer := enrollment.GetECert()
+	if signer == nil {
+		return nil, fmt.Errorf("enrollment %s has no X.509 certificate", enrollment.GetName())
+	}
+	certificate := signer.Cert()
+	publicKey, err := certificatePublicKey(certificate)
+	if err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(keystore)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(keystore, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if key, err := parsePrivateKey(data); err == nil && samePublicKey(key.Public(), publicKey) {
+			return NewIdentity(enrollment.GetName(), mspID, certificate, data)
+		}
+	}
+	return nil, fmt.Errorf("%s has no private key of the certificate of %s", keystore, enrollment.GetName())
+}
+
+// Validate : Check that the identity has a label, an MSP id, a certificate and the private key of the certificate
+func (identity *Identity) Validate() error {
+	if identity.Label == "" {
+		return fmt.Errorf("identities need a label")
+	}
+	if identity.MspID == "" {
+		return fmt.Errorf("identity %s has no MSP id", identity.Label)
+	}
+	publicKey, err := certificatePublicKey(identity.Certificate)
+	if err != nil {
+		return fmt.Errorf("certificate of identity %s: %s", identity.Label, err.Error())
+	}
+	key, err := parsePrivateKey(identity.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("private key of identity %s: %s", identity.Label, err.Error())
+	}
+	if !samePublicKey(key.Public(), publicKey) {
+		return fmt.Errorf("the private key of identity %s is not the key of its certificate", identity.Label)
+	}
+	return nil
+}
+
+// X509Certificate returns the parsed certificate of the identity.
+func (identity *Identity) X509Certificate() (*x509.Certificate, error) {
+	block, _ := pem.Decode(identity.Certificate)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("not a PEM certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// Base64Certificate returns the certificate as base 64 encoded PEM, the way the console takes certificates.
+func (identity *Identity) Base64Certificate() string {
+	return base64.StdEncoding.EncodeToString(identity.Certificate)
+}
+
+// EditAdminCertsOptions : Instantiate the EditAdminCertsOptions that make the identity an admin of the peer or
+// orderer with the id componentID
+func (identity *Identity) EditAdminCertsOptions(service *blockchainv3.BlockchainV3, componentID string) *blockchainv3.EditAdminCertsOptions {
+	return service.NewEditAdminCertsOptions(componentID).SetAppendAdminCerts([]string{identity.Base64Certificate()})
+}
+
+// CryptoEnrollmentComponent : Instantiate the CryptoEnrollmentComponent of a CryptoObjectEnrollment that makes the
+// identity the admin of a new node
+func (identity *Identity) CryptoEnrollmentComponent() *blockchainv3.CryptoEnrollmentComponent {
+	return &blockchainv3.CryptoEnrollmentComponent{Admincerts: []string{identity.Base64Certificate()}}
+}
+
+// decodePem returns PEM data as is and decodes base 64 encoded PEM.
+func decodePem(data []byte) []byte {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && !bytes.HasPrefix(trimmed, []byte("-----")) {
+		if decoded, err := base64.StdEncoding.DecodeString(string(trimmed)); err == nil {
+			return decoded
+		}
+	}
+	return data
+}
+
+// certificatePublicKey returns the public key of a PEM certificate.
+func certificatePublicKey(certificate []byte) (crypto.PublicKey, error) {
+	identity := &Identity{Certificate: certificate}
+	parsed, err := identity.X509Certificate()
+	if err != nil {
+		return nil, err
+	}
+	return parsed.PublicKey, nil
+}
+
+// parsePrivateKey parses a PEM private key in PKCS #8, SEC 1 or PKCS #1 form.
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("not a PEM private key")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported private key in %s block", block.Type)
+}
+
+// samePublicKey reports whether two public keys are the same.
+func samePublicKey(a crypto.PublicKey, b crypto.PublicKey) bool {
+	aDER, errA := x509.MarshalPKIXPublicKey(a)
+	bDER, errB := x509.MarshalPKIXPublicKey(b)
+	return errA == nil && errB == nil && bytes.Equal(aDER, bDER)
+}
@@ -0,0 +1,125 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wallet_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"github.com/IBM-Blockchain/ibp-go-sdk/blockchainv3"
+	"github.com/IBM-Blockchain/ibp-go-sdk/wallet"
+	"github.com/IBM/go-sdk-core/v4/core"
+	"github.com/hyperledger/fabric-ca/lib"
+	"github.com/hyperledger/fabric-ca/lib/client/credential"
+	x509cred "github.com/hyperledger/fabric-ca/lib/client/credential/x509"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// testEnrollment returns a self-signed PEM certificate and its PEM private key. SEC 1 keys are used in place of
+// PKCS #8 keys if sec1 is set.
+func testEnrollment(commonName string, sec1 bool) (certificate []byte, privateKey []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).To(BeNil())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).To(BeNil())
+	certificate = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if sec1 {
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		Expect(err).To(BeNil())
+		return certificate, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	Expect(err).To(BeNil())
+	return certificate, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+var _ = Describe(`Wallet identities`, func() {
+	It(`Checks that the key is the key of the certificate`, func() {
+		cert, key := testEnrollment("admin", false)
+		_, otherKey := testEnrollment("other", true)
+		identity, err := wallet.NewIdentity("admin", "org1", []byte(base64.StdEncoding.EncodeToString(cert)), key)
+		Expect(err).To(BeNil())
+		Expect(identity.Certificate).To(Equal(cert))
+		parsed, err := identity.X509Certificate()
+		Expect(err).To(BeNil())
+		Expect(parsed.Subject.CommonName).To(Equal("admin"))
+
+		_, err = wallet.NewIdentity("admin", "org1", cert, otherKey)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("the private key of identity admin is not the key of its certificate"))
+		_, err = wallet.NewIdentity("admin", "", cert, key)
+		Expect(err).ToNot(BeNil())
+		_, err = wallet.NewIdentity("admin", "org1", key, key)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(HavePrefix("certificate of identity admin: "))
+	})
+	It(`Hands certificates to the console`, func() {
+		cert, key := testEnrollment("admin", false)
+		identity, err := wallet.NewIdentity("admin", "org1", cert, key)
+		Expect(err).To(BeNil())
+		service, err := blockchainv3.NewBlockchainV3(&blockchainv3.BlockchainV3Options{
+			URL:           "https://console.example.com",
+			Authenticator: &core.NoAuthAuthenticator{},
+		})
+		Expect(err).To(BeNil())
+		options := identity.EditAdminCertsOptions(service, "peer1")
+		Expect(*options.ID).To(Equal("peer1"))
+		Expect(options.AppendAdminCerts).To(Equal([]string{base64.StdEncoding.EncodeToString(cert)}))
+		Expect(identity.CryptoEnrollmentComponent().Admincerts).To(Equal(options.AppendAdminCerts))
+	})
+	It(`Reads the private key of an enrollment from the keystore`, func() {
+		cert, key := testEnrollment("admin", true)
+		_, otherKey := testEnrollment("other", false)
+		keystore, err := ioutil.TempDir("", "keystore")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(keystore)
+		Expect(ioutil.WriteFile(filepath.Join(keystore, "a_sk"), otherKey, 0600)).To(BeNil())
+		Expect(ioutil.WriteFile(filepath.Join(keystore, "b_sk"), key, 0600)).To(BeNil())
+
+		signer, err := x509cred.NewSigner(nil, cert)
+		Expect(err).To(BeNil())
+		x509Credential := x509cred.NewCredential("", "", nil)
+		Expect(x509Credential.SetVal(signer)).To(BeNil())
+		enrollment := lib.NewIdentity(nil, "admin", []credential.Credential{x509Credential})
+
+		identity, err := wallet.NewIdentityFromEnrollment(enrollment, "org1", keystore)
+		Expect(err).To(BeNil())
+		Expect(identity.Label).To(Equal("admin"))
+		Expect(identity.PrivateKey).To(Equal(key))
+
+		Expect(os.Remove(filepath.Join(keystore, "b_sk"))).To(BeNil())
+		_, err = wallet.NewIdentityFromEnrollment(enrollment, "org1", keystore)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(HaveSuffix("has no private key of the certificate of admin"))
+	})
+})
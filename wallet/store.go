@@ -0,0 +1,172 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wallet
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Store : Where a wallet keeps its identities, as serialized identities keyed by label.
+type Store interface {
+	// Put stores the data of a label, replacing any data the label had.
+	Put(label string, data []byte) error
+
+	// Get returns the data of a label, or nil if the store has no such label.
+	Get(label string) ([]byte, error)
+
+	// List returns the labels of the store, sorted.
+	List() ([]string, error)
+
+	// Remove removes a label. Removing a label the store does not have is not an error.
+	Remove(label string) error
+}
+
+// memoryStore keeps identities in memory.
+type memoryStore struct {
+	mutex sync.RWMutex
+	data  map[string][]byte
+}
+
+// NewInMemoryStore : Instantiate a Store that keeps identities in memory
+func NewInMemoryStore() Store {
+	return &memoryStore{data: map[string][]byte{}}
+}
+
+func (store *memoryStore) Put(label string, data []byte) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.data[label] = append([]byte{}, data...)
+	return nil
+}
+
+func (store *memoryStore) Get(label string) ([]byte, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	data, ok := store.data[label]
+	if !ok {
+		return nil, nil
+	}
+	return append([]byte{}, data...), nil
+}
+
+func (store *memoryStore) List() (labels []string, err error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	for label := range store.data {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return
+}
+
+func (store *memoryStore) Remove(label string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.data, label)
+	return nil
+}
+
+// FileStoreExtension is the extension of the files of a file store, like in wallets of the Fabric SDKs.
+const FileStoreExtension = ".id"
+
+// fileStore keeps every identity in a file of a directory.
+type fileStore struct {
+	dir string
+}
+
+// NewFileStore : Instantiate a Store that keeps every identity in a file <label>.id of a directory
+// The directory is created if it does not exist. Files are only readable by their owner.
+func NewFileStore(dir string) (Store, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return &fileStore{dir: dir}, nil
+}
+
+// file returns the file of a label.
+func (store *fileStore) file(label string) (string, error) {
+	if label == "" || label == "." || label == ".." || strings.ContainsAny(label, `/\`) {
+		return "", fmt.Errorf("%q is not a valid label", label)
+	}
+	return filepath.Join(store.dir, label+FileStoreExtension), nil
+}
+
+func (store *fileStore) Put(label string, data []byte) error {
+	file, err := store.file(label)
+	if err != nil {
+		return err
+	}
+	// write a temporary file and rename it, so that the file of the label is never half written
+	temporary, err := ioutil.TempFile(store.dir, ".put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+	_, err = temporary.Write(data)
+	if closeErr := temporary.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(temporary.Name(), file)
+}
+
+func (store *fileStore) Get(label string) ([]byte, error) {
+	file, err := store.file(label)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (store *fileStore) List() (labels []string, err error) {
+	files, err := ioutil.ReadDir(store.dir)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), FileStoreExtension) && !strings.HasPrefix(file.Name(), ".") {
+			labels = append(labels, strings.TrimSuffix(file.Name(), FileStoreExtension))
+		}
+	}
+	sort.Strings(labels)
+	return
+}
+
+func (store *fileStore) Remove(label string) error {
+	file, err := store.file(label)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
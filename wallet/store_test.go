@@ -0,0 +1,82 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wallet_test
+
+import (
+	"github.com/IBM-Blockchain/ibp-go-sdk/wallet"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe(`Wallet stores`, func() {
+	var dir string
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "wallet")
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	behaves := func(newStore func() wallet.Store) {
+		store := newStore()
+		data, err := store.Get("admin")
+		Expect(err).To(BeNil())
+		Expect(data).To(BeNil())
+		Expect(store.Put("user", []byte("user data"))).To(BeNil())
+		Expect(store.Put("admin", []byte("old"))).To(BeNil())
+		Expect(store.Put("admin", []byte("admin data"))).To(BeNil())
+		data, err = store.Get("admin")
+		Expect(err).To(BeNil())
+		Expect(string(data)).To(Equal("admin data"))
+		labels, err := store.List()
+		Expect(err).To(BeNil())
+		Expect(labels).To(Equal([]string{"admin", "user"}))
+		Expect(store.Remove("admin")).To(BeNil())
+		Expect(store.Remove("admin")).To(BeNil())
+		labels, err = store.List()
+		Expect(err).To(BeNil())
+		Expect(labels).To(Equal([]string{"user"}))
+	}
+
+	It(`Keeps identities in memory`, func() {
+		behaves(wallet.NewInMemoryStore)
+	})
+	It(`Keeps identities in files`, func() {
+		behaves(func() wallet.Store {
+			store, err := wallet.NewFileStore(filepath.Join(dir, "wallet"))
+			Expect(err).To(BeNil())
+			return store
+		})
+		info, err := os.Stat(filepath.Join(dir, "wallet", "user.id"))
+		Expect(err).To(BeNil())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+		files, err := ioutil.ReadDir(filepath.Join(dir, "wallet"))
+		Expect(err).To(BeNil())
+		Expect(files).To(HaveLen(1))
+
+		store, err := wallet.NewFileStore(filepath.Join(dir, "wallet"))
+		Expect(err).To(BeNil())
+		Expect(store.Put("../admin", []byte("escape"))).ToNot(BeNil())
+		_, err = store.Get("")
+		Expect(err).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,236 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package wallet : Wallets of enrolled identities, compatible with the wallets of the IBP console and the Fabric SDKs
+package wallet
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// Wallet : Identities kept in a Store. Identities are stored in the identity format of the Fabric SDKs, so a wallet
+// with a file store is a wallet directory of the Fabric SDKs.
+type Wallet struct {
+	store Store
+}
+
+// New : Instantiate Wallet with a Store
+func New(store Store) *Wallet {
+	return &Wallet{store: store}
+}
+
+// NewInMemory : Instantiate Wallet that keeps its identities in memory
+func NewInMemory() *Wallet {
+	return New(NewInMemoryStore())
+}
+
+// NewFileSystem : Instantiate Wallet that keeps its identities in files of a directory
+func NewFileSystem(dir string) (*Wallet, error) {
+	store, err := NewFileStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	return New(store), nil
+}
+
+// Put : Add an identity, replacing the identity with the same label
+func (wallet *Wallet) Put(identity *Identity) error {
+	err := identity.Validate()
+	if err != nil {
+		return err
+	}
+	data, err := MarshalSDKIdentity(identity)
+	if err != nil {
+		return err
+	}
+	return wallet.store.Put(identity.Label, data)
+}
+
+// Get : Get the identity with a label
+func (wallet *Wallet) Get(label string) (*Identity, error) {
+	data, err := wallet.store.Get(label)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("the wallet has no identity %s", label)
+	}
+	return ParseSDKIdentity(label, data)
+}
+
+// Exists : Check whether the wallet has an identity with a label
+func (wallet *Wallet) Exists(label string) (bool, error) {
+	data, err := wallet.store.Get(label)
+	return data != nil, err
+}
+
+// List : Get the labels of the identities, sorted
+func (wallet *Wallet) List() ([]string, error) {
+	return wallet.store.List()
+}
+
+// ListByMspID : Get the identities of an MSP, sorted by label
+func (wallet *Wallet) ListByMspID(mspID string) (identities []*Identity, err error) {
+	labels, err := wallet.store.List()
+	if err != nil {
+		return
+	}
+	for _, label := range labels {
+		identity, err := wallet.Get(label)
+		if err != nil {
+			return nil, err
+		}
+		if identity.MspID == mspID {
+			identities = append(identities, identity)
+		}
+	}
+	return
+}
+
+// Remove : Remove the identity with a label
+func (wallet *Wallet) Remove(label string) error {
+	return wallet.store.Remove(label)
+}
+
+// ImportConsoleIdentities : Add the identities of a JSON export of the console wallet
+// The console does not keep MSP ids with identities, the identities are associated with mspID.
+func (wallet *Wallet) ImportConsoleIdentities(data []byte, mspID string) (identities []*Identity, err error) {
+	identities, err = ParseConsoleIdentities(data, mspID)
+	if err != nil {
+		return nil, err
+	}
+	for _, identity := range identities {
+		err = wallet.Put(identity)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// ExportConsoleIdentities : Export identities in the JSON format of the console wallet
+// Exports the identities with the labels, or every identity if there are no labels.
+func (wallet *Wallet) ExportConsoleIdentities(labels ...string) ([]byte, error) {
+	if len(labels) == 0 {
+		var err error
+		labels, err = wallet.store.List()
+		if err != nil {
+			return nil, err
+		}
+	}
+	identities := make([]*Identity, len(labels))
+	for i, label := range labels {
+		identity, err := wallet.Get(label)
+		if err != nil {
+			return nil, err
+		}
+		identities[i] = identity
+	}
+	return MarshalConsoleIdentities(identities...)
+}
+
+// sdkIdentity is an X.509 identity of the wallets of the Fabric SDKs.
+type sdkIdentity struct {
+	Credentials struct {
+		Certificate string `json:"certificate"`
+		PrivateKey  string `json:"privateKey"`
+	} `json:"credentials"`
+	MspID   string `json:"mspId"`
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+}
+
+// sdkIdentityType is the only identity type of the wallets of the Fabric SDKs that the wallet supports.
+const sdkIdentityType = "X.509"
+
+// ParseSDKIdentity : Parse an identity of a wallet of the Fabric SDKs, such as the content of a <label>.id file
+func ParseSDKIdentity(label string, data []byte) (*Identity, error) {
+	var parsed sdkIdentity
+	err := json.Unmarshal(data, &parsed)
+	if err != nil {
+		return nil, fmt.Errorf("identity %s: %s", label, err.Error())
+	}
+	if parsed.Type != sdkIdentityType {
+		return nil, fmt.Errorf("identity %s is of type %q, only %s identities are supported", label, parsed.Type, sdkIdentityType)
+	}
+	return NewIdentity(label, parsed.MspID, []byte(parsed.Credentials.Certificate), []byte(parsed.Credentials.PrivateKey))
+}
+
+// MarshalSDKIdentity : Marshal an identity the way wallets of the Fabric SDKs keep it
+func MarshalSDKIdentity(identity *Identity) ([]byte, error) {
+	var marshaled sdkIdentity
+	marshaled.Credentials.Certificate = string(identity.Certificate)
+	marshaled.Credentials.PrivateKey = string(identity.PrivateKey)
+	marshaled.MspID = identity.MspID
+	marshaled.Type = sdkIdentityType
+	marshaled.Version = 1
+	return json.Marshal(marshaled)
+}
+
+// consoleIdentity is an identity of a JSON export of the console wallet. Certificates and keys are base 64 encoded PEM.
+type consoleIdentity struct {
+	Name       string `json:"name"`
+	Type       string `json:"type,omitempty"`
+	Cert       string `json:"cert"`
+	PrivateKey string `json:"private_key"`
+}
+
+// consoleIdentityType is the type of the identities of console exports.
+const consoleIdentityType = "identity"
+
+// ParseConsoleIdentities : Parse a JSON export of the console wallet, which holds an identity or an array of them
+// The console does not keep MSP ids with identities, the identities are associated with mspID.
+func ParseConsoleIdentities(data []byte, mspID string) (identities []*Identity, err error) {
+	var parsed []consoleIdentity
+	if err = json.Unmarshal(data, &parsed); err != nil {
+		var single consoleIdentity
+		if json.Unmarshal(data, &single) != nil {
+			return nil, err
+		}
+		parsed = append(parsed, single)
+	}
+	for _, exported := range parsed {
+		if exported.Type != "" && exported.Type != consoleIdentityType {
+			return nil, fmt.Errorf("%s is a %s, not an identity", exported.Name, exported.Type)
+		}
+		identity, err := NewIdentity(exported.Name, mspID, []byte(exported.Cert), []byte(exported.PrivateKey))
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+	return identities, nil
+}
+
+// MarshalConsoleIdentities : Marshal identities the way the console wallet exports them
+// A single identity is marshaled as an object, several identities as an array.
+func MarshalConsoleIdentities(identities ...*Identity) ([]byte, error) {
+	exported := make([]consoleIdentity, len(identities))
+	for i, identity := range identities {
+		exported[i] = consoleIdentity{
+			Name:       identity.Label,
+			Type:       consoleIdentityType,
+			Cert:       identity.Base64Certificate(),
+			PrivateKey: base64.StdEncoding.EncodeToString(identity.PrivateKey),
+		}
+	}
+	if len(exported) == 1 {
+		return json.MarshalIndent(exported[0], "", "    ")
+	}
+	return json.MarshalIndent(exported, "", "    ")
+}
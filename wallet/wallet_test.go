@@ -0,0 +1,118 @@
+/**
+ * (C) Copyright IBM Corp. 2021.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wallet_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/IBM-Blockchain/ibp-go-sdk/wallet"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe(`Wallets`, func() {
+	var admin, user, orderer *wallet.Identity
+	BeforeEach(func() {
+		var err error
+		cert, key := testEnrollment("admin", false)
+		admin, err = wallet.NewIdentity("admin", "org1", cert, key)
+		Expect(err).To(BeNil())
+		cert, key = testEnrollment("user", true)
+		user, err = wallet.NewIdentity("user", "org1", cert, key)
+		Expect(err).To(BeNil())
+		cert, key = testEnrollment("osadmin", false)
+		orderer, err = wallet.NewIdentity("osadmin", "osmsp", cert, key)
+		Expect(err).To(BeNil())
+	})
+
+	It(`Keeps identities in the format of the Fabric SDKs`, func() {
+		dir, err := ioutil.TempDir("", "wallet")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+		identities, err := wallet.NewFileSystem(dir)
+		Expect(err).To(BeNil())
+		for _, identity := range []*wallet.Identity{admin, user, orderer} {
+			Expect(identities.Put(identity)).To(BeNil())
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, "admin.id"))
+		Expect(err).To(BeNil())
+		var file map[string]interface{}
+		Expect(json.Unmarshal(data, &file)).To(BeNil())
+		Expect(file).To(Equal(map[string]interface{}{
+			"credentials": map[string]interface{}{"certificate": string(admin.Certificate), "privateKey": string(admin.PrivateKey)},
+			"mspId":       "org1",
+			"type":        "X.509",
+			"version":     float64(1),
+		}))
+
+		read, err := identities.Get("admin")
+		Expect(err).To(BeNil())
+		Expect(read).To(Equal(admin))
+		org1, err := identities.ListByMspID("org1")
+		Expect(err).To(BeNil())
+		Expect(org1).To(Equal([]*wallet.Identity{admin, user}))
+		exists, err := identities.Exists("osadmin")
+		Expect(err).To(BeNil())
+		Expect(exists).To(BeTrue())
+		Expect(identities.Remove("osadmin")).To(BeNil())
+		_, err = identities.Get("osadmin")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("the wallet has no identity osadmin"))
+
+		_, err = wallet.ParseSDKIdentity("idemix", []byte(`{"type": "Idemix", "version": 1}`))
+		Expect(err).ToNot(BeNil())
+		Expect(identities.Put(&wallet.Identity{Label: "broken", MspID: "org1"})).ToNot(BeNil())
+	})
+	It(`Imports and exports identities of the console wallet`, func() {
+		identities := wallet.NewInMemory()
+		Expect(identities.Put(admin)).To(BeNil())
+		exported, err := identities.ExportConsoleIdentities("admin")
+		Expect(err).To(BeNil())
+		var single map[string]interface{}
+		Expect(json.Unmarshal(exported, &single)).To(BeNil())
+		Expect(single).To(Equal(map[string]interface{}{
+			"name":        "admin",
+			"type":        "identity",
+			"cert":        base64.StdEncoding.EncodeToString(admin.Certificate),
+			"private_key": base64.StdEncoding.EncodeToString(admin.PrivateKey),
+		}))
+
+		other := wallet.NewInMemory()
+		imported, err := other.ImportConsoleIdentities(exported, "org1")
+		Expect(err).To(BeNil())
+		Expect(imported).To(Equal([]*wallet.Identity{admin}))
+
+		Expect(identities.Put(user)).To(BeNil())
+		exported, err = identities.ExportConsoleIdentities()
+		Expect(err).To(BeNil())
+		imported, err = other.ImportConsoleIdentities(exported, "org2")
+		Expect(err).To(BeNil())
+		Expect(imported).To(HaveLen(2))
+		read, err := other.Get("user")
+		Expect(err).To(BeNil())
+		Expect(read.MspID).To(Equal("org2"))
+
+		_, err = other.ImportConsoleIdentities([]byte(`{"name": "org1", "type": "msp"}`), "org1")
+		Expect(err).ToNot(BeNil())
+		_, err = other.ImportConsoleIdentities([]byte(`nope`), "org1")
+		Expect(err).ToNot(BeNil())
+	})
+})